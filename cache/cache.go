@@ -0,0 +1,358 @@
+// Package cache caches the responses of the routes. The responses written by
+// Ctx.JSON, Ctx.XML, Ctx.Send and Ctx.SendString are kept in a CacheStore, by route,
+// query and vary headers, and replayed while they are fresh.
+package cache
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/tinh-tinh/tinhtinh/v2/core"
+)
+
+const (
+	// CACHE_TTL is the metadata key of the ttl of the responses of a route.
+	CACHE_TTL = "cache_ttl"
+	// CACHE_KEY is the metadata key of the key of the responses of a route.
+	CACHE_KEY = "cache_key"
+	// CACHE_TAGS is the metadata key of the tags of the responses of a route.
+	CACHE_TAGS = "cache_tags"
+	// CACHE_VARY is the metadata key of the vary headers of a route.
+	CACHE_VARY = "cache_vary"
+	// CACHE_INVALIDATE is the metadata key of the tags invalidated by a
+	// route.
+	CACHE_INVALIDATE = "cache_invalidate"
+	// NO_CACHE is the metadata key of the routes which are not cached.
+	NO_CACHE = "no_cache"
+)
+
+// DefaultTTL is the time a response is cached when no ttl is given.
+const DefaultTTL = time.Minute
+
+// HeaderCache tells whether the response was served from the cache.
+const HeaderCache = "X-Cache"
+
+type Options struct {
+	// Store keeping the responses. Default keeps them in memory.
+	Store CacheStore
+	// TTL is the time a response is cached. Default is DefaultTTL.
+	TTL time.Duration
+	// Vary are the request headers whose values select the response, such
+	// as Accept-Language. They are set in the Vary header of the responses.
+	Vary []string
+	// MaxSize is the largest body cached, in bytes. Default is 1 MiB.
+	MaxSize int
+}
+
+// Cache caches the responses of the routes using its Handler.
+type Cache struct {
+	opt Options
+}
+
+// entry is a cached response.
+type entry struct {
+	Status  int         `json:"status"`
+	Header  http.Header `json:"header"`
+	Body    []byte      `json:"body"`
+	Tags    []string    `json:"tags,omitempty"`
+	Created time.Time   `json:"created"`
+}
+
+// New creates a cache from the options.
+func New(opt Options) *Cache {
+	if opt.Store == nil {
+		opt.Store = NewMemoryStore()
+	}
+	if opt.TTL <= 0 {
+		opt.TTL = DefaultTTL
+	}
+	if opt.MaxSize <= 0 {
+		opt.MaxSize = 1 << 20
+	}
+	for i, name := range opt.Vary {
+		opt.Vary[i] = http.CanonicalHeaderKey(name)
+	}
+	return &Cache{opt: opt}
+}
+
+// Handler is a middleware caching the responses of the GET and HEAD
+// requests. A response is cached when its status is 200 and it is written by
+// Ctx.JSON, Ctx.XML, Ctx.Send or Ctx.SendString. A response varying on a
+// header which is not a vary header of the cache, such as the Accept header
+// of Ctx.Send, is not cached. The X-Cache header of the responses
+// is HIT when they come from the cache, MISS otherwise. A client already
+// having the cached response, as told by If-None-Match, is answered 304 Not
+// Modified.
+//
+// The responses are cached by method, path, query and vary headers for the
+// ttl of the options, unless the route sets its own with the TTL, Key and Vary
+// metadata. The other routes invalidate the tags of their Invalidate metadata
+// once they succeed.
+func (c *Cache) Handler(ctx core.Ctx) error {
+	if core.Reflector[bool](NO_CACHE, ctx) {
+		return ctx.Next()
+	}
+	r := ctx.Req()
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		tags := core.Reflector[[]string](CACHE_INVALIDATE, ctx)
+		if len(tags) == 0 {
+			return ctx.Next()
+		}
+		rec := &recorder{ResponseWriter: ctx.Res()}
+		ctx.SetCtx(rec, r)
+		if err := ctx.Next(); err != nil {
+			return err
+		}
+		if rec.status < http.StatusBadRequest {
+			return c.InvalidateTags(tags...)
+		}
+		return nil
+	}
+
+	vary := core.Reflector[[]string](CACHE_VARY, ctx)
+	if len(vary) == 0 {
+		vary = c.opt.Vary
+	}
+	key := c.key(ctx, vary)
+	header := ctx.Res().Header()
+	if len(vary) > 0 {
+		header.Set("Vary", strings.Join(vary, ", "))
+	}
+
+	if cached, ok := c.get(key); ok {
+		for name, values := range cached.Header {
+			header[name] = values
+		}
+		header.Set(HeaderCache, "HIT")
+		if core.NotModified(r, header) {
+			header.Del("Content-Type")
+			header.Del("Content-Length")
+			ctx.Res().WriteHeader(http.StatusNotModified)
+			return nil
+		}
+		ctx.Res().WriteHeader(cached.Status)
+		_, err := ctx.Res().Write(cached.Body)
+		return err
+	}
+
+	header.Set(HeaderCache, "MISS")
+	created := time.Now()
+	rec := &recorder{ResponseWriter: ctx.Res(), max: c.opt.MaxSize, before: header.Clone()}
+	ctx.SetCtx(rec, r)
+	if err := ctx.Next(); err != nil {
+		return err
+	}
+	if !rec.cacheable(vary) {
+		return nil
+	}
+
+	ttl := core.Reflector[time.Duration](CACHE_TTL, ctx)
+	if ttl <= 0 {
+		ttl = c.opt.TTL
+	}
+	return c.set(key, entry{
+		Status:  rec.status,
+		Header:  rec.header,
+		Body:    rec.body.Bytes(),
+		Tags:    core.Reflector[[]string](CACHE_TAGS, ctx),
+		Created: created,
+	}, ttl)
+}
+
+// InvalidateTags removes from the cache the responses of the tags.
+func (c *Cache) InvalidateTags(tags ...string) error {
+	now := make([]byte, 8)
+	binary.BigEndian.PutUint64(now, uint64(time.Now().UnixNano()))
+	for _, tag := range tags {
+		if err := c.opt.Store.Set(tagKey(tag), now, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// key returns the key of the response of the request: the method, then the
+// key of the route or the path and the query, followed by the values of the
+// vary headers.
+func (c *Cache) key(ctx core.Ctx, vary []string) string {
+	r := ctx.Req()
+	key := core.Reflector[string](CACHE_KEY, ctx)
+	if key == "" {
+		key = r.URL.Path
+		if query := r.URL.Query().Encode(); query != "" {
+			key += "?" + query
+		}
+	}
+	key = r.Method + " " + key
+	for _, name := range vary {
+		key += "|" + name + "=" + r.Header.Get(name)
+	}
+	return key
+}
+
+// get returns the response of the key, unless one of its tags was
+// invalidated after it was cached.
+func (c *Cache) get(key string) (entry, bool) {
+	data, ok := c.opt.Store.Get("cache:" + key)
+	if !ok {
+		return entry{}, false
+	}
+	var e entry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return entry{}, false
+	}
+	for _, tag := range e.Tags {
+		invalidated, ok := c.opt.Store.Get(tagKey(tag))
+		if ok && len(invalidated) == 8 && e.Created.UnixNano() <= int64(binary.BigEndian.Uint64(invalidated)) {
+			return entry{}, false
+		}
+	}
+	return e, true
+}
+
+func (c *Cache) set(key string, e entry, ttl time.Duration) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return c.opt.Store.Set("cache:"+key, data, ttl)
+}
+
+func tagKey(tag string) string {
+	return "cache:tag:" + tag
+}
+
+// Handler is a middleware caching the responses with the cache of the
+// module. It does nothing when the module is not imported.
+//
+//	ctrl.Use(cache.Handler).Get("", handler)
+func Handler(ctx core.Ctx) error {
+	c, ok := ctx.Ref(CACHE).(*Cache)
+	if !ok {
+		return ctx.Next()
+	}
+	return c.Handler(ctx)
+}
+
+// TTL sets the time the responses of a route are cached.
+func TTL(ttl time.Duration) *core.Metadata {
+	return core.SetMetadata(CACHE_TTL, ttl)
+}
+
+// Key sets the key of the responses of a route, replacing its path and query.
+func Key(key string) *core.Metadata {
+	return core.SetMetadata(CACHE_KEY, key)
+}
+
+// Tags tags the responses of a route, so they can be invalidated together.
+func Tags(tags ...string) *core.Metadata {
+	return core.SetMetadata(CACHE_TAGS, tags)
+}
+
+// Vary sets the vary headers of a route, replacing those of the options.
+func Vary(headers ...string) *core.Metadata {
+	for i, name := range headers {
+		headers[i] = http.CanonicalHeaderKey(name)
+	}
+	return core.SetMetadata(CACHE_VARY, headers)
+}
+
+// Invalidate invalidates the tags when a request of a route other than GET
+// and HEAD succeeds.
+func Invalidate(tags ...string) *core.Metadata {
+	return core.SetMetadata(CACHE_INVALIDATE, tags)
+}
+
+// NoCache disables the cache of a route.
+func NoCache() *core.Metadata {
+	return core.SetMetadata(NO_CACHE, true)
+}
+
+// recorder records the response written through it.
+type recorder struct {
+	http.ResponseWriter
+	max    int
+	status int
+	// before are the headers set before the handler, such as the request ID,
+	// which are not part of the cached response.
+	before http.Header
+	header http.Header
+	body   bytes.Buffer
+	// overflow reports whether the body is larger than max.
+	overflow bool
+}
+
+func (r *recorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+		r.header = http.Header{}
+		for name, values := range r.Header() {
+			if !slices.Equal(values, r.before[name]) {
+				r.header[name] = slices.Clone(values)
+			}
+		}
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.WriteHeader(http.StatusOK)
+	}
+	if !r.overflow {
+		if r.body.Len()+len(b) > r.max {
+			r.overflow = true
+			r.body.Reset()
+		} else {
+			r.body.Write(b)
+		}
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *recorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (r *recorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// cacheable reports whether the response was written by Ctx.JSON, Ctx.XML,
+// Ctx.Send or Ctx.SendString with a 200 status, and only varies on the vary
+// headers of the key.
+func (r *recorder) cacheable(vary []string) bool {
+	if r.status != http.StatusOK || r.overflow || r.header.Get("Set-Cookie") != "" {
+		return false
+	}
+	for _, value := range r.header.Values("Vary") {
+		for _, name := range strings.Split(value, ",") {
+			name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+			if name != "" && !slices.Contains(vary, name) {
+				return false
+			}
+		}
+	}
+	contentType := r.header.Get("Content-Type")
+	if contentType == "" {
+		// Ctx.SendString does not set the content type.
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch mediaType {
+	case "application/json", "application/xml", "text/plain", "text/csv", "application/msgpack":
+		return true
+	}
+	return false
+}
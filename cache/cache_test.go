@@ -0,0 +1,387 @@
+package cache_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tinh-tinh/tinhtinh/v2/cache"
+	"github.com/tinh-tinh/tinhtinh/v2/core"
+	"github.com/tinh-tinh/tinhtinh/v2/middleware/requestid"
+)
+
+type Order struct {
+	ID int `json:"id" xml:"id"`
+}
+
+func Test_Module(t *testing.T) {
+	var calls atomic.Int32
+
+	controller := func(module core.Module) core.Controller {
+		ctrl := module.NewController("orders").Use(cache.Handler).Registry()
+
+		ctrl.Metadata(cache.Tags("orders")).Get("", func(ctx core.Ctx) error {
+			calls.Add(1)
+			return ctx.JSON(core.Map{"data": calls.Load(), "page": ctx.Query("page")})
+		})
+
+		ctrl.Get("xml", func(ctx core.Ctx) error {
+			calls.Add(1)
+			return ctx.XML(Order{ID: int(calls.Load())})
+		})
+
+		ctrl.Get("text", func(ctx core.Ctx) error {
+			calls.Add(1)
+			return ctx.SendString(strconv.Itoa(int(calls.Load())))
+		})
+
+		ctrl.Get("missing", func(ctx core.Ctx) error {
+			calls.Add(1)
+			return ctx.Status(http.StatusNotFound).JSON(core.Map{"data": calls.Load()})
+		})
+
+		ctrl.Metadata(cache.NoCache()).Get("live", func(ctx core.Ctx) error {
+			calls.Add(1)
+			return ctx.JSON(core.Map{"data": calls.Load()})
+		})
+
+		ctrl.Metadata(cache.Invalidate("orders")).Post("", func(ctx core.Ctx) error {
+			return ctx.JSON(core.Map{"data": "created"})
+		})
+
+		return ctrl
+	}
+
+	appModule := func() core.Module {
+		return core.NewModule(core.NewModuleOptions{
+			Imports:     []core.Modules{cache.Module(cache.Options{})},
+			Controllers: []core.Controllers{controller},
+		})
+	}
+
+	app := core.CreateFactory(appModule)
+	app.SetGlobalPrefix("/api")
+	require.NotNil(t, cache.InjectCache(app.Module))
+
+	testServer := httptest.NewServer(app.PrepareBeforeListen())
+	defer testServer.Close()
+	testClient := testServer.Client()
+
+	get := func(path string) (*http.Response, string) {
+		resp, err := testClient.Get(testServer.URL + path)
+		require.Nil(t, err)
+		data, err := io.ReadAll(resp.Body)
+		require.Nil(t, err)
+		return resp, string(data)
+	}
+
+	resp, body := get("/api/orders?page=1")
+	require.Equal(t, "MISS", resp.Header.Get(cache.HeaderCache))
+	require.Equal(t, `{"data":1,"page":"1"}`, body)
+
+	resp, body = get("/api/orders?page=1")
+	require.Equal(t, "HIT", resp.Header.Get(cache.HeaderCache))
+	require.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	require.Equal(t, `{"data":1,"page":"1"}`, body)
+
+	// Another query is another response.
+	resp, body = get("/api/orders?page=2")
+	require.Equal(t, "MISS", resp.Header.Get(cache.HeaderCache))
+	require.Equal(t, `{"data":2,"page":"2"}`, body)
+
+	resp, body = get("/api/orders/xml")
+	require.Equal(t, "MISS", resp.Header.Get(cache.HeaderCache))
+	resp, cached := get("/api/orders/xml")
+	require.Equal(t, "HIT", resp.Header.Get(cache.HeaderCache))
+	require.Equal(t, "application/xml", resp.Header.Get("Content-Type"))
+	require.Equal(t, body, cached)
+
+	_, body = get("/api/orders/text")
+	resp, cached = get("/api/orders/text")
+	require.Equal(t, "HIT", resp.Header.Get(cache.HeaderCache))
+	require.Equal(t, body, cached)
+
+	// Only the successful responses are cached.
+	_, body = get("/api/orders/missing")
+	resp, cached = get("/api/orders/missing")
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	require.Equal(t, "MISS", resp.Header.Get(cache.HeaderCache))
+	require.NotEqual(t, body, cached)
+
+	_, body = get("/api/orders/live")
+	resp, cached = get("/api/orders/live")
+	require.Empty(t, resp.Header.Get(cache.HeaderCache))
+	require.NotEqual(t, body, cached)
+
+	// Creating an order invalidates the orders.
+	resp, err := testClient.Post(testServer.URL+"/api/orders", "application/json", nil)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, body = get("/api/orders?page=1")
+	require.Equal(t, "MISS", resp.Header.Get(cache.HeaderCache))
+	require.NotEqual(t, `{"data":1,"page":"1"}`, body)
+
+	resp, _ = get("/api/orders?page=1")
+	require.Equal(t, "HIT", resp.Header.Get(cache.HeaderCache))
+
+	// The other responses are not tagged.
+	resp, _ = get("/api/orders/xml")
+	require.Equal(t, "HIT", resp.Header.Get(cache.HeaderCache))
+}
+
+func Test_Metadata(t *testing.T) {
+	var calls atomic.Int32
+
+	c := cache.New(cache.Options{Vary: []string{"accept-language"}})
+
+	controller := func(module core.Module) core.Controller {
+		ctrl := module.NewController("test").Use(c.Handler).Registry()
+
+		ctrl.Metadata(cache.TTL(time.Second)).Get("short", func(ctx core.Ctx) error {
+			calls.Add(1)
+			return ctx.JSON(core.Map{"data": calls.Load()})
+		})
+
+		ctrl.Metadata(cache.Key("shared")).Get("a", func(ctx core.Ctx) error {
+			calls.Add(1)
+			return ctx.JSON(core.Map{"data": calls.Load()})
+		})
+
+		ctrl.Metadata(cache.Key("shared")).Get("b", func(ctx core.Ctx) error {
+			calls.Add(1)
+			return ctx.JSON(core.Map{"data": calls.Load()})
+		})
+
+		ctrl.Metadata(cache.Vary("X-Tenant")).Get("tenant", func(ctx core.Ctx) error {
+			calls.Add(1)
+			return ctx.JSON(core.Map{"data": ctx.Headers("X-Tenant")})
+		})
+
+		return ctrl
+	}
+
+	app := core.CreateFactory(func() core.Module {
+		return core.NewModule(core.NewModuleOptions{
+			Controllers: []core.Controllers{controller},
+		})
+	})
+
+	testServer := httptest.NewServer(app.PrepareBeforeListen())
+	defer testServer.Close()
+	testClient := testServer.Client()
+
+	get := func(path string, header ...string) (*http.Response, string) {
+		req, err := http.NewRequest(http.MethodGet, testServer.URL+path, nil)
+		require.Nil(t, err)
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		resp, err := testClient.Do(req)
+		require.Nil(t, err)
+		data, err := io.ReadAll(resp.Body)
+		require.Nil(t, err)
+		return resp, string(data)
+	}
+
+	resp, body := get("/test/short", "Accept-Language", "en")
+	require.Equal(t, "Accept-Language", resp.Header.Get("Vary"))
+	resp, cached := get("/test/short", "Accept-Language", "en")
+	require.Equal(t, "HIT", resp.Header.Get(cache.HeaderCache))
+	require.Equal(t, body, cached)
+
+	resp, _ = get("/test/short", "Accept-Language", "fr")
+	require.Equal(t, "MISS", resp.Header.Get(cache.HeaderCache))
+
+	require.Eventually(t, func() bool {
+		resp, _ := get("/test/short", "Accept-Language", "en")
+		return resp.Header.Get(cache.HeaderCache) == "MISS"
+	}, 3*time.Second, 100*time.Millisecond)
+
+	_, body = get("/test/a")
+	resp, cached = get("/test/b")
+	require.Equal(t, "HIT", resp.Header.Get(cache.HeaderCache))
+	require.Equal(t, body, cached)
+
+	resp, body = get("/test/tenant", "X-Tenant", "a")
+	require.Equal(t, "X-Tenant", resp.Header.Get("Vary"))
+	require.Equal(t, `{"data":"a"}`, body)
+	resp, body = get("/test/tenant", "X-Tenant", "b")
+	require.Equal(t, "MISS", resp.Header.Get(cache.HeaderCache))
+	require.Equal(t, `{"data":"b"}`, body)
+	resp, body = get("/test/tenant", "X-Tenant", "a")
+	require.Equal(t, "HIT", resp.Header.Get(cache.HeaderCache))
+	require.Equal(t, `{"data":"a"}`, body)
+}
+
+func Test_Negotiation(t *testing.T) {
+	var calls atomic.Int32
+
+	c := cache.New(cache.Options{})
+
+	controller := func(module core.Module) core.Controller {
+		ctrl := module.NewController("test").Use(c.Handler).Registry()
+
+		ctrl.Get("any", func(ctx core.Ctx) error {
+			calls.Add(1)
+			return ctx.Send(Order{ID: int(calls.Load())})
+		})
+
+		ctrl.Metadata(cache.Vary("Accept")).Get("vary", func(ctx core.Ctx) error {
+			calls.Add(1)
+			return ctx.Send(Order{ID: int(calls.Load())})
+		})
+
+		return ctrl
+	}
+
+	app := core.CreateFactory(func() core.Module {
+		return core.NewModule(core.NewModuleOptions{
+			Controllers: []core.Controllers{controller},
+		})
+	})
+
+	testServer := httptest.NewServer(app.PrepareBeforeListen())
+	defer testServer.Close()
+	testClient := testServer.Client()
+
+	get := func(path string, accept string) (*http.Response, string) {
+		req, err := http.NewRequest(http.MethodGet, testServer.URL+path, nil)
+		require.Nil(t, err)
+		req.Header.Set("Accept", accept)
+		resp, err := testClient.Do(req)
+		require.Nil(t, err)
+		data, err := io.ReadAll(resp.Body)
+		require.Nil(t, err)
+		return resp, string(data)
+	}
+
+	// The response varies on Accept, which is not part of the key.
+	get("/test/any", "application/json")
+	resp, _ := get("/test/any", "application/xml")
+	require.Equal(t, "MISS", resp.Header.Get(cache.HeaderCache))
+	require.Equal(t, "application/xml", resp.Header.Get("Content-Type"))
+
+	_, body := get("/test/vary", "application/json")
+	resp, cached := get("/test/vary", "application/json")
+	require.Equal(t, "HIT", resp.Header.Get(cache.HeaderCache))
+	require.Equal(t, body, cached)
+	resp, _ = get("/test/vary", "application/xml")
+	require.Equal(t, "MISS", resp.Header.Get(cache.HeaderCache))
+	require.Equal(t, "application/xml", resp.Header.Get("Content-Type"))
+}
+
+func Test_Handler_WithoutModule(t *testing.T) {
+	controller := func(module core.Module) core.Controller {
+		ctrl := module.NewController("test").Use(cache.Handler)
+		ctrl.Get("", func(ctx core.Ctx) error {
+			return ctx.JSON(core.Map{"data": "ok"})
+		})
+		return ctrl
+	}
+
+	app := core.CreateFactory(func() core.Module {
+		return core.NewModule(core.NewModuleOptions{
+			Controllers: []core.Controllers{controller},
+		})
+	})
+
+	testServer := httptest.NewServer(app.PrepareBeforeListen())
+	defer testServer.Close()
+
+	resp, err := testServer.Client().Get(testServer.URL + "/test")
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Empty(t, resp.Header.Get(cache.HeaderCache))
+}
+
+func Test_RequestHeaders(t *testing.T) {
+	controller := func(module core.Module) core.Controller {
+		ctrl := module.NewController("test").Use(cache.Handler)
+		ctrl.Get("", func(ctx core.Ctx) error {
+			ctx.Res().Header().Set("X-Version", "1")
+			return ctx.JSON(core.Map{"data": "ok"})
+		})
+		return ctrl
+	}
+
+	app := core.CreateFactory(func() core.Module {
+		return core.NewModule(core.NewModuleOptions{
+			Imports:     []core.Modules{cache.Module(cache.Options{})},
+			Controllers: []core.Controllers{controller},
+		})
+	})
+	app.Use(requestid.Handler(requestid.Options{}))
+
+	testServer := httptest.NewServer(app.PrepareBeforeListen())
+	defer testServer.Close()
+
+	first, err := testServer.Client().Get(testServer.URL + "/test")
+	require.Nil(t, err)
+	second, err := testServer.Client().Get(testServer.URL + "/test")
+	require.Nil(t, err)
+	require.Equal(t, "HIT", second.Header.Get(cache.HeaderCache))
+	require.Equal(t, "1", second.Header.Get("X-Version"))
+	// The headers set before the handler belong to each request.
+	require.NotEqual(t, first.Header.Get("X-Request-Id"), second.Header.Get("X-Request-Id"))
+}
+
+func Test_ETag(t *testing.T) {
+	var calls atomic.Int32
+	controller := func(module core.Module) core.Controller {
+		ctrl := module.NewController("test").Use(cache.Handler)
+		ctrl.Get("", func(ctx core.Ctx) error {
+			calls.Add(1)
+			return ctx.JSON(core.Map{"data": "ok"})
+		})
+		return ctrl
+	}
+
+	app := core.CreateFactory(func() core.Module {
+		return core.NewModule(core.NewModuleOptions{
+			Imports:     []core.Modules{cache.Module(cache.Options{})},
+			Controllers: []core.Controllers{controller},
+		})
+	}, core.AppOptions{ETag: core.ETagStrong})
+
+	testServer := httptest.NewServer(app.PrepareBeforeListen())
+	defer testServer.Close()
+
+	do := func(method string, etag string) (*http.Response, string) {
+		req, err := http.NewRequest(method, testServer.URL+"/test", nil)
+		require.Nil(t, err)
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		resp, err := testServer.Client().Do(req)
+		require.Nil(t, err)
+		data, err := io.ReadAll(resp.Body)
+		require.Nil(t, err)
+		return resp, string(data)
+	}
+
+	// A HEAD response is not replayed to a GET request.
+	resp, _ := do(http.MethodHead, "")
+	require.Equal(t, "MISS", resp.Header.Get(cache.HeaderCache))
+	resp, body := do(http.MethodGet, "")
+	require.Equal(t, "MISS", resp.Header.Get(cache.HeaderCache))
+	require.Equal(t, `{"data":"ok"}`, body)
+	etag := resp.Header.Get("ETag")
+	require.NotEmpty(t, etag)
+
+	resp, body = do(http.MethodGet, etag)
+	require.Equal(t, "HIT", resp.Header.Get(cache.HeaderCache))
+	require.Equal(t, http.StatusNotModified, resp.StatusCode)
+	require.Empty(t, body)
+
+	resp, body = do(http.MethodGet, `"other"`)
+	require.Equal(t, "HIT", resp.Header.Get(cache.HeaderCache))
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, `{"data":"ok"}`, body)
+	require.Equal(t, int32(2), calls.Load())
+}
@@ -0,0 +1,31 @@
+package cache
+
+import "github.com/tinh-tinh/tinhtinh/v2/core"
+
+// CACHE is the name of the provider of the *Cache. The cache module is
+// global, so the cache can be injected in every module, for example to
+// invalidate tags.
+const CACHE core.Provide = "CACHE"
+
+// Module creates a global module providing a cache created from the options.
+// The routes are cached by the Handler middleware:
+//
+//	ctrl.Use(cache.Handler).Metadata(cache.TTL(time.Minute), cache.Tags("orders")).Get("", handler)
+func Module(opt Options) core.Modules {
+	return func(module core.Module) core.Module {
+		cacheModule := module.New(core.NewModuleOptions{Global: true})
+		cacheModule.NewProvider(core.ProviderOptions{
+			Name:  CACHE,
+			Value: New(opt),
+		})
+		cacheModule.Export(CACHE)
+		return cacheModule
+	}
+}
+
+// InjectCache returns the cache of the module, or nil if the cache module is
+// not imported.
+func InjectCache(module core.Module) *Cache {
+	c, _ := module.Ref(CACHE).(*Cache)
+	return c
+}
@@ -0,0 +1,159 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/tinh-tinh/tinhtinh/v2/common/memory"
+)
+
+// CacheStore keeps the cached values. A ttl lower or equal to zero keeps the
+// value until it is deleted.
+type CacheStore interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, value []byte, ttl time.Duration) error
+	Delete(key string) error
+}
+
+type memoryStore struct {
+	store *memory.Store
+	// persistent holds the values without ttl, which memory.Store does not
+	// keep.
+	mu         sync.RWMutex
+	persistent map[string][]byte
+}
+
+// NewMemoryStore creates a store keeping the values in memory, in a
+// memory.Store. The ttl of the values is rounded up to the second.
+func NewMemoryStore() CacheStore {
+	return &memoryStore{
+		store:      memory.New(memory.Options{}),
+		persistent: make(map[string][]byte),
+	}
+}
+
+func (m *memoryStore) Get(key string) ([]byte, bool) {
+	m.mu.RLock()
+	value, ok := m.persistent[key]
+	m.mu.RUnlock()
+	if ok {
+		return value, true
+	}
+	value, ok = m.store.Get(key).([]byte)
+	return value, ok
+}
+
+func (m *memoryStore) Set(key string, value []byte, ttl time.Duration) error {
+	if ttl <= 0 {
+		m.store.Delete(key)
+		m.mu.Lock()
+		m.persistent[key] = value
+		m.mu.Unlock()
+		return nil
+	}
+	m.mu.Lock()
+	delete(m.persistent, key)
+	m.mu.Unlock()
+	if rounded := ttl.Truncate(time.Second); rounded < ttl {
+		ttl = rounded + time.Second
+	}
+	m.store.Set(key, value, ttl)
+	return nil
+}
+
+func (m *memoryStore) Delete(key string) error {
+	m.mu.Lock()
+	delete(m.persistent, key)
+	m.mu.Unlock()
+	m.store.Delete(key)
+	return nil
+}
+
+type fileStore struct {
+	dir string
+}
+
+// fileEntry is the content of a file of the file store.
+type fileEntry struct {
+	// Expires is the expiration time in Unix nanoseconds, zero when the
+	// value does not expire.
+	Expires int64  `json:"expires"`
+	Value   []byte `json:"value"`
+}
+
+// NewFileStore creates a store keeping each value in a file of the directory,
+// so the cache survives the restarts and is shared by the processes using the
+// directory. The directory is created if needed. The expired files are
+// removed when they are read.
+func NewFileStore(dir string) (CacheStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &fileStore{dir: dir}, nil
+}
+
+func (f *fileStore) Get(key string) ([]byte, bool) {
+	data, err := os.ReadFile(f.path(key))
+	if err != nil {
+		return nil, false
+	}
+	var entry fileEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, false
+	}
+	if entry.Expires != 0 && entry.Expires <= time.Now().UnixNano() {
+		_ = f.Delete(key)
+		return nil, false
+	}
+	return entry.Value, true
+}
+
+func (f *fileStore) Set(key string, value []byte, ttl time.Duration) error {
+	entry := fileEntry{Value: value}
+	if ttl > 0 {
+		entry.Expires = time.Now().Add(ttl).UnixNano()
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file then rename it, so the readers never see a
+	// partial file.
+	tmp, err := os.CreateTemp(f.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), f.path(key))
+}
+
+func (f *fileStore) Delete(key string) error {
+	err := os.Remove(f.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// path returns the file of the key, named after its hash since the keys
+// contain paths and queries.
+func (f *fileStore) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(f.dir, hex.EncodeToString(sum[:]))
+}
@@ -0,0 +1,52 @@
+package cache_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tinh-tinh/tinhtinh/v2/cache"
+)
+
+func testStore(t *testing.T, store cache.CacheStore) {
+	_, ok := store.Get("key")
+	require.False(t, ok)
+
+	require.Nil(t, store.Set("key", []byte("value"), time.Second))
+	value, ok := store.Get("key")
+	require.True(t, ok)
+	require.Equal(t, "value", string(value))
+
+	require.Nil(t, store.Set("forever", []byte("value"), 0))
+	require.Nil(t, store.Set("short", []byte("value"), 50*time.Millisecond))
+
+	require.Nil(t, store.Delete("key"))
+	_, ok = store.Get("key")
+	require.False(t, ok)
+	require.Nil(t, store.Delete("key"))
+
+	require.Eventually(t, func() bool {
+		_, ok := store.Get("short")
+		return !ok
+	}, 3*time.Second, 50*time.Millisecond)
+	_, ok = store.Get("forever")
+	require.True(t, ok)
+}
+
+func Test_MemoryStore(t *testing.T) {
+	testStore(t, cache.NewMemoryStore())
+}
+
+func Test_FileStore(t *testing.T) {
+	dir := t.TempDir()
+	store, err := cache.NewFileStore(dir)
+	require.Nil(t, err)
+	testStore(t, store)
+
+	// The values are kept by the directory.
+	reopened, err := cache.NewFileStore(dir)
+	require.Nil(t, err)
+	value, ok := reopened.Get("forever")
+	require.True(t, ok)
+	require.Equal(t, "value", string(value))
+}
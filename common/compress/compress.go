@@ -17,6 +17,37 @@ const (
 	Zlib  Alg = "zlib"
 )
 
+// Writer is a writer compressing what is written to it. Reset discards its
+// state and makes it write to another writer, so it can be reused.
+type Writer interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// NewWriter returns a Writer compressing to w with the algorithm and the
+// level, from flate.HuffmanOnly to flate.BestCompression.
+func NewWriter(w io.Writer, alg Alg, level int) (Writer, error) {
+	var (
+		writer Writer
+		err    error
+	)
+	switch alg {
+	case Gzip:
+		writer, err = gzip.NewWriterLevel(w, level)
+	case Flate:
+		writer, err = flate.NewWriter(w, level)
+	case Zlib:
+		writer, err = zlib.NewWriterLevel(w, level)
+	default:
+		return nil, errors.New("unknown compression algorithm")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return writer, nil
+}
+
 func Encode(data interface{}, alg Alg, levels ...int) ([]byte, error) {
 	valBytes, err := ToBytes(data)
 	if err != nil {
@@ -28,40 +59,15 @@ func Encode(data interface{}, alg Alg, levels ...int) ([]byte, error) {
 	}
 
 	var buf bytes.Buffer
-	switch alg {
-	case Gzip:
-		gzipWriter, err := gzip.NewWriterLevel(&buf, levels[0])
-		if err != nil {
-			return nil, err
-		}
-		_, err = gzipWriter.Write(valBytes)
-		if err != nil {
-			return nil, err
-		}
-		gzipWriter.Close()
-	case Flate:
-		flateWriter, err := flate.NewWriter(&buf, levels[0])
-		if err != nil {
-			return nil, err
-		}
-		_, err = flateWriter.Write(valBytes)
-		if err != nil {
-			return nil, err
-		}
-		flateWriter.Close()
-	case Zlib:
-		zlibWriter, err := zlib.NewWriterLevel(&buf, levels[0])
-		if err != nil {
-			return nil, err
-		}
-		_, err = zlibWriter.Write(valBytes)
-		if err != nil {
-			return nil, err
-		}
-		zlibWriter.Close()
-	default:
-		return nil, errors.New("unknown compression algorithm")
+	writer, err := NewWriter(&buf, alg, levels[0])
+	if err != nil {
+		return nil, err
+	}
+	_, err = writer.Write(valBytes)
+	if err != nil {
+		return nil, err
 	}
+	writer.Close()
 
 	return buf.Bytes(), nil
 }
@@ -1,6 +1,9 @@
 package compress_test
 
 import (
+	"bytes"
+	"compress/gzip"
+	"io"
 	"testing"
 	"time"
 
@@ -142,3 +145,32 @@ func Test_Error(t *testing.T) {
 	_, err = compress.DecodeMarshall[BigStruct]([]byte("test"), compress.Zlib)
 	require.NotNil(t, err)
 }
+
+func Test_NewWriter(t *testing.T) {
+	for _, alg := range []compress.Alg{compress.Gzip, compress.Flate, compress.Zlib} {
+		var first, second bytes.Buffer
+		writer, err := compress.NewWriter(&first, alg, gzip.BestSpeed)
+		require.Nil(t, err)
+		_, err = writer.Write([]byte("first"))
+		require.Nil(t, err)
+		require.Nil(t, writer.Close())
+
+		writer.Reset(&second)
+		_, err = writer.Write([]byte("second"))
+		require.Nil(t, err)
+		require.Nil(t, writer.Flush())
+		require.Nil(t, writer.Close())
+
+		data, err := compress.Decode(first.Bytes(), alg)
+		require.Nil(t, err)
+		require.Equal(t, "first", string(data))
+		data, err = compress.Decode(second.Bytes(), alg)
+		require.Nil(t, err)
+		require.Equal(t, "second", string(data))
+	}
+
+	_, err := compress.NewWriter(io.Discard, "invalid", gzip.DefaultCompression)
+	require.NotNil(t, err)
+	_, err = compress.NewWriter(io.Discard, compress.Gzip, 42)
+	require.NotNil(t, err)
+}
@@ -0,0 +1,45 @@
+package logger
+
+import (
+	"github.com/tinh-tinh/tinhtinh/v2/core"
+	"github.com/tinh-tinh/tinhtinh/v2/tracing"
+)
+
+// FromCtx returns a child of the logger of the module adding the request to
+// each entry. It returns nil if the logger module is not imported.
+//
+// The metadata added are:
+//
+// - request_id: the ID set by the requestid middleware
+// - route: the method and the pattern of the route
+// - user: the user set with ctx.Set(core.USER, user), when it is a string, a
+// fmt.Stringer or an integer
+// - trace_id, span_id: the span of the request set by the tracing module
+func FromCtx(ctx core.Ctx) *Logger {
+	log, ok := ctx.Ref(LOGGER).(*Logger)
+	if !ok || log == nil {
+		return nil
+	}
+	return log.With(requestMetadata(ctx))
+}
+
+// requestMetadata returns the metadata identifying the request of the context.
+func requestMetadata(ctx core.Ctx) Metadata {
+	meta := Metadata{}
+	if id := ctx.RequestID(); id != "" {
+		meta["request_id"] = id
+	}
+	if app, ok := ctx.Ref(core.APP).(*core.App); ok {
+		if route, ok := app.MatchRoute(ctx.Req()); ok {
+			meta["route"] = route.Pattern
+		}
+	}
+	if user := core.UserID(ctx); user != "" {
+		meta["user"] = user
+	}
+	if sc := tracing.SpanContextFromContext(ctx.Req().Context()); sc.IsValid() {
+		meta["trace_id"] = sc.TraceID.String()
+		meta["span_id"] = sc.SpanID.String()
+	}
+	return meta
+}
@@ -0,0 +1,174 @@
+package logger_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tinh-tinh/tinhtinh/v2/common/logger"
+	"github.com/tinh-tinh/tinhtinh/v2/core"
+	"github.com/tinh-tinh/tinhtinh/v2/middleware/requestid"
+	"github.com/tinh-tinh/tinhtinh/v2/tracing"
+)
+
+func Test_FromCtx(t *testing.T) {
+	dir := t.TempDir()
+
+	authGuard := func(ctx core.Ctx) bool {
+		ctx.Set(core.USER, 42)
+		return true
+	}
+
+	appController := func(module core.Module) core.Controller {
+		ctrl := module.NewController("orders")
+		ctrl.Guard(authGuard).Get("{id}", func(ctx core.Ctx) error {
+			logger.FromCtx(ctx).Info("order found", logger.Metadata{"order": ctx.Path("id")})
+			return ctx.JSON(core.Map{"data": "ok"})
+		})
+		return ctrl
+	}
+
+	appModule := func() core.Module {
+		return core.NewModule(core.NewModuleOptions{
+			Imports: []core.Modules{
+				logger.Module(logger.Options{Path: dir, Format: logger.FormatJSON}),
+			},
+			Controllers: []core.Controllers{appController},
+		})
+	}
+
+	app := core.CreateFactory(appModule)
+	app.SetGlobalPrefix("/api")
+	app.Use(requestid.Handler(requestid.Options{}))
+
+	testServer := httptest.NewServer(app.PrepareBeforeListen())
+	defer testServer.Close()
+
+	req, err := http.NewRequest(http.MethodGet, testServer.URL+"/api/orders/7", nil)
+	require.Nil(t, err)
+	req.Header.Set("X-Request-Id", "abc-123")
+	req.Header.Set(tracing.TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	resp, err := testServer.Client().Do(req)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var entry map[string]any
+	require.Eventually(t, func() bool {
+		files, _ := os.ReadDir(dir)
+		for _, file := range files {
+			data, _ := os.ReadFile(filepath.Join(dir, file.Name()))
+			for _, line := range strings.Split(string(data), "\n") {
+				if strings.Contains(line, "order found") {
+					return json.Unmarshal([]byte(line), &entry) == nil
+				}
+			}
+		}
+		return false
+	}, 3*time.Second, 100*time.Millisecond)
+
+	require.Equal(t, "abc-123", entry["request_id"])
+	require.Equal(t, "GET /api/orders/{id}", entry["route"])
+	require.Equal(t, "42", entry["user"])
+	require.Equal(t, "7", entry["order"])
+	require.NotContains(t, entry, "trace_id")
+}
+
+func Test_FromCtx_Tracing(t *testing.T) {
+	dir := t.TempDir()
+
+	appController := func(module core.Module) core.Controller {
+		ctrl := module.NewController("orders")
+		ctrl.Get("", func(ctx core.Ctx) error {
+			logger.FromCtx(ctx).Info("orders listed")
+			return ctx.JSON(core.Map{"data": "ok"})
+		})
+		return ctrl
+	}
+
+	appModule := func() core.Module {
+		return core.NewModule(core.NewModuleOptions{
+			Imports: []core.Modules{
+				logger.Module(logger.Options{Path: dir, Format: logger.FormatJSON}),
+				tracing.Module(tracing.Options{Exporter: tracing.NewJSONExporter(&strings.Builder{})}),
+			},
+			Controllers: []core.Controllers{appController},
+		})
+	}
+
+	app := core.CreateFactory(appModule)
+	testServer := httptest.NewServer(app.PrepareBeforeListen())
+	defer testServer.Close()
+
+	req, err := http.NewRequest(http.MethodGet, testServer.URL+"/orders", nil)
+	require.Nil(t, err)
+	req.Header.Set(tracing.TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	resp, err := testServer.Client().Do(req)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var entry map[string]any
+	require.Eventually(t, func() bool {
+		files, _ := os.ReadDir(dir)
+		for _, file := range files {
+			data, _ := os.ReadFile(filepath.Join(dir, file.Name()))
+			for _, line := range strings.Split(string(data), "\n") {
+				if strings.Contains(line, "orders listed") {
+					return json.Unmarshal([]byte(line), &entry) == nil
+				}
+			}
+		}
+		return false
+	}, 3*time.Second, 100*time.Millisecond)
+
+	require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", entry["trace_id"])
+	require.Len(t, entry["span_id"], 16)
+	require.NotContains(t, entry, "request_id")
+}
+
+func Test_With(t *testing.T) {
+	dir := t.TempDir()
+	log := logger.Create(logger.Options{Path: dir, Format: logger.FormatJSON})
+	child := log.With(logger.Metadata{"a": "1", "b": "1"}).With(logger.Metadata{"b": "2"})
+	child.Info("child", logger.Metadata{"c": "3"})
+	child.Close()
+	log.Close()
+
+	data, err := os.ReadFile(filepath.Join(dir, time.Now().Format("2006-01-02")+"-info.log"))
+	require.Nil(t, err)
+	var entry map[string]any
+	require.Nil(t, json.Unmarshal(data, &entry))
+	require.Equal(t, "child", entry["msg"])
+	require.Equal(t, "1", entry["a"])
+	require.Equal(t, "2", entry["b"])
+	require.Equal(t, "3", entry["c"])
+}
+
+func Test_FromCtx_WithoutModule(t *testing.T) {
+	appController := func(module core.Module) core.Controller {
+		ctrl := module.NewController("test")
+		ctrl.Get("", func(ctx core.Ctx) error {
+			return ctx.JSON(core.Map{"data": logger.FromCtx(ctx) == nil})
+		})
+		return ctrl
+	}
+
+	app := core.CreateFactory(func() core.Module {
+		return core.NewModule(core.NewModuleOptions{
+			Controllers: []core.Controllers{appController},
+		})
+	})
+	testServer := httptest.NewServer(app.PrepareBeforeListen())
+	defer testServer.Close()
+
+	resp, err := testServer.Client().Get(testServer.URL + "/test")
+	require.Nil(t, err)
+	var res core.Map
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&res))
+	require.Equal(t, true, res["data"])
+}
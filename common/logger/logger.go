@@ -63,6 +63,10 @@ type Logger struct {
 	logCh          chan *logEntry
 	bufferSize     int
 	consoleHandler slog.Handler
+	// root is the logger writing the entries of a child logger, which adds
+	// meta to each of them.
+	root *Logger
+	meta Metadata
 }
 
 type Options struct {
@@ -150,6 +154,23 @@ func Create(opt Options) *Logger {
 	return l
 }
 
+// With returns a child logger adding the metadata to each of its entries. The
+// entries are written by the logger, so the child does not need to be closed.
+func (log *Logger) With(meta Metadata) *Logger {
+	root := log
+	merged := make(Metadata, len(log.meta)+len(meta))
+	if log.root != nil {
+		root = log.root
+		for k, v := range log.meta {
+			merged[k] = v
+		}
+	}
+	for k, v := range meta {
+		merged[k] = v
+	}
+	return &Logger{Options: log.Options, root: root, meta: merged}
+}
+
 func (log *Logger) Info(msg string, meta ...Metadata) {
 	log.write(LevelInfo, msg, meta...)
 }
@@ -267,6 +288,10 @@ func (log *Logger) writeEntryLog(entry *logEntry) {
 }
 
 func (log *Logger) write(level Level, msg string, meta ...Metadata) {
+	if log.root != nil {
+		log.root.write(level, msg, append([]Metadata{log.meta}, meta...)...)
+		return
+	}
 	entry := &logEntry{
 		level: level,
 		msg:   msg,
@@ -415,7 +440,12 @@ func (log *Logger) rotateFile(oldPath string) {
 	}
 }
 
+// Close flushes the entries and closes the log files. It does nothing on a
+// child logger.
 func (log *Logger) Close() {
+	if log.root != nil {
+		return
+	}
 	close(log.stopCh)
 	log.wg.Wait()
 
@@ -0,0 +1,268 @@
+package msgpack
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// ErrShortData is returned by Unmarshal when the data ends inside a value.
+var ErrShortData = errors.New("msgpack: unexpected end of data")
+
+// ErrMaxDepth is returned by Unmarshal when the arrays and the maps are nested
+// deeper than MaxDepth.
+var ErrMaxDepth = errors.New("msgpack: exceeded max depth")
+
+// MaxDepth is the deepest nesting of arrays and maps decoded by Unmarshal,
+// like encoding/json, so that an untrusted body cannot exhaust the stack.
+const MaxDepth = 10000
+
+// Unmarshal decodes the MessagePack data into v, which must be a pointer. The
+// data is decoded as with encoding/json, following the json tags of the
+// structs: the maps are decoded into the structs or the maps, the arrays into
+// the slices, and so on. The timestamp extension is decoded as a time.Time.
+func Unmarshal(data []byte, v any) error {
+	d := &decoder{data: data}
+	value, err := d.decode()
+	if err != nil {
+		return err
+	}
+	if d.off != len(d.data) {
+		return fmt.Errorf("msgpack: %d bytes after the value", len(d.data)-d.off)
+	}
+	if target, ok := v.(*any); ok {
+		*target = value
+		return nil
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("msgpack: %w", err)
+	}
+	return json.Unmarshal(raw, v)
+}
+
+type decoder struct {
+	data  []byte
+	off   int
+	depth int
+}
+
+// enter enters an array or a map, until MaxDepth. The caller leaves it with
+// d.depth--.
+func (d *decoder) enter() error {
+	d.depth++
+	if d.depth > MaxDepth {
+		return ErrMaxDepth
+	}
+	return nil
+}
+
+func (d *decoder) next(n int) ([]byte, error) {
+	if n < 0 || len(d.data)-d.off < n {
+		return nil, ErrShortData
+	}
+	b := d.data[d.off : d.off+n]
+	d.off += n
+	return b, nil
+}
+
+func (d *decoder) uint(n int) (uint64, error) {
+	b, err := d.next(n)
+	if err != nil {
+		return 0, err
+	}
+	switch n {
+	case 1:
+		return uint64(b[0]), nil
+	case 2:
+		return uint64(binary.BigEndian.Uint16(b)), nil
+	case 4:
+		return uint64(binary.BigEndian.Uint32(b)), nil
+	default:
+		return binary.BigEndian.Uint64(b), nil
+	}
+}
+
+// length reads a length of n bytes.
+func (d *decoder) length(n int) (int, error) {
+	u, err := d.uint(n)
+	if err != nil {
+		return 0, err
+	}
+	if u > uint64(len(d.data)) {
+		return 0, ErrShortData
+	}
+	return int(u), nil
+}
+
+// decode decodes the next value into nil, bool, int64, uint64, float64,
+// string, []byte, []any, map[string]any or time.Time.
+func (d *decoder) decode() (any, error) {
+	b, err := d.next(1)
+	if err != nil {
+		return nil, err
+	}
+	c := b[0]
+	switch {
+	case c <= 0x7f:
+		return int64(c), nil
+	case c >= 0xe0:
+		return int64(int8(c)), nil
+	case c&0xf0 == 0x80:
+		return d.decodeMap(int(c & 0x0f))
+	case c&0xf0 == 0x90:
+		return d.decodeArray(int(c & 0x0f))
+	case c&0xe0 == 0xa0:
+		return d.decodeString(int(c & 0x1f))
+	}
+
+	switch c {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := d.length(1 << (c - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		bin, err := d.next(n)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte(nil), bin...), nil
+	case 0xc7, 0xc8, 0xc9:
+		n, err := d.length(1 << (c - 0xc7))
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeExt(n)
+	case 0xca:
+		u, err := d.uint(4)
+		return float64(math.Float32frombits(uint32(u))), err
+	case 0xcb:
+		u, err := d.uint(8)
+		return math.Float64frombits(u), err
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		return d.uint(1 << (c - 0xcc))
+	case 0xd0:
+		u, err := d.uint(1)
+		return int64(int8(u)), err
+	case 0xd1:
+		u, err := d.uint(2)
+		return int64(int16(u)), err
+	case 0xd2:
+		u, err := d.uint(4)
+		return int64(int32(u)), err
+	case 0xd3:
+		u, err := d.uint(8)
+		return int64(u), err
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return d.decodeExt(1 << (c - 0xd4))
+	case 0xd9, 0xda, 0xdb:
+		n, err := d.length(1 << (c - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeString(n)
+	case 0xdc, 0xdd:
+		n, err := d.length(2 << (c - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeArray(n)
+	case 0xde, 0xdf:
+		n, err := d.length(2 << (c - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeMap(n)
+	}
+	return nil, fmt.Errorf("msgpack: invalid format 0x%02x", c)
+}
+
+func (d *decoder) decodeString(n int) (any, error) {
+	b, err := d.next(n)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func (d *decoder) decodeArray(n int) (any, error) {
+	if err := d.enter(); err != nil {
+		return nil, err
+	}
+	defer func() { d.depth-- }()
+	array := make([]any, 0, min(n, len(d.data)-d.off))
+	for i := 0; i < n; i++ {
+		value, err := d.decode()
+		if err != nil {
+			return nil, err
+		}
+		array = append(array, value)
+	}
+	return array, nil
+}
+
+// decodeMap decodes a map. Its keys which are not strings are formatted with
+// fmt, as JSON objects only have string keys.
+func (d *decoder) decodeMap(n int) (any, error) {
+	if err := d.enter(); err != nil {
+		return nil, err
+	}
+	defer func() { d.depth-- }()
+	m := make(map[string]any, min(n, len(d.data)-d.off))
+	for i := 0; i < n; i++ {
+		key, err := d.decode()
+		if err != nil {
+			return nil, err
+		}
+		value, err := d.decode()
+		if err != nil {
+			return nil, err
+		}
+		switch k := key.(type) {
+		case string:
+			m[k] = value
+		case []byte:
+			m[string(k)] = value
+		default:
+			m[fmt.Sprint(k)] = value
+		}
+	}
+	return m, nil
+}
+
+// decodeExt decodes an extension of n bytes. Only the timestamp extension,
+// of type -1, is supported.
+func (d *decoder) decodeExt(n int) (any, error) {
+	b, err := d.next(1)
+	if err != nil {
+		return nil, err
+	}
+	if int8(b[0]) != -1 {
+		return nil, fmt.Errorf("msgpack: unsupported extension type %d", int8(b[0]))
+	}
+	data, err := d.next(n)
+	if err != nil {
+		return nil, err
+	}
+	switch n {
+	case 4:
+		return time.Unix(int64(binary.BigEndian.Uint32(data)), 0).UTC(), nil
+	case 8:
+		u := binary.BigEndian.Uint64(data)
+		return time.Unix(int64(u&0x3ffffffff), int64(u>>34)).UTC(), nil
+	case 12:
+		nsec := binary.BigEndian.Uint32(data[:4])
+		sec := int64(binary.BigEndian.Uint64(data[4:]))
+		return time.Unix(sec, int64(nsec)).UTC(), nil
+	}
+	return nil, fmt.Errorf("msgpack: invalid timestamp of %d bytes", n)
+}
@@ -0,0 +1,114 @@
+package msgpack_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tinh-tinh/tinhtinh/v2/common/msgpack"
+)
+
+func Test_Marshal(t *testing.T) {
+	cases := []struct {
+		value any
+		want  []byte
+	}{
+		{nil, []byte{0xc0}},
+		{true, []byte{0xc3}},
+		{false, []byte{0xc2}},
+		{1, []byte{0x01}},
+		{-1, []byte{0xff}},
+		{200, []byte{0xcc, 0xc8}},
+		{-200, []byte{0xd1, 0xff, 0x38}},
+		{70000, []byte{0xce, 0x00, 0x01, 0x11, 0x70}},
+		{1.5, []byte{0xcb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0}},
+		{"abc", []byte{0xa3, 'a', 'b', 'c'}},
+		{[]byte{1, 2}, []byte{0xc4, 0x02, 0x01, 0x02}},
+		{[]int{1, 2}, []byte{0x92, 0x01, 0x02}},
+		{map[string]int{"b": 2, "a": 1}, []byte{0x82, 0xa1, 'a', 0x01, 0xa1, 'b', 0x02}},
+	}
+	for _, c := range cases {
+		data, err := msgpack.Marshal(c.value)
+		require.Nil(t, err)
+		require.Equal(t, c.want, data, "%v", c.value)
+	}
+
+	_, err := msgpack.Marshal(make(chan int))
+	require.ErrorIs(t, err, errors.ErrUnsupported)
+}
+
+type Base struct {
+	ID int `json:"id"`
+}
+
+type User struct {
+	Base
+	Name     string    `json:"name"`
+	Email    string    `json:"email,omitempty"`
+	Password string    `json:"-"`
+	Tags     []string  `json:"tags"`
+	Created  time.Time `json:"created"`
+	secret   string
+}
+
+func Test_Struct(t *testing.T) {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	data, err := msgpack.Marshal(User{
+		Base:     Base{ID: 1},
+		Name:     "john",
+		Password: "secret",
+		Tags:     []string{"a"},
+		Created:  created,
+		secret:   "secret",
+	})
+	require.Nil(t, err)
+
+	var generic any
+	require.Nil(t, msgpack.Unmarshal(data, &generic))
+	require.Equal(t, map[string]any{
+		"id":      int64(1),
+		"name":    "john",
+		"tags":    []any{"a"},
+		"created": "2024-01-02T03:04:05Z",
+	}, generic)
+
+	var user User
+	require.Nil(t, msgpack.Unmarshal(data, &user))
+	require.Equal(t, 1, user.ID)
+	require.Equal(t, "john", user.Name)
+	require.Empty(t, user.Password)
+	require.Equal(t, []string{"a"}, user.Tags)
+	require.True(t, created.Equal(user.Created))
+}
+
+func Test_Unmarshal(t *testing.T) {
+	var n int
+	require.Nil(t, msgpack.Unmarshal([]byte{0xd1, 0xff, 0x38}, &n))
+	require.Equal(t, -200, n)
+
+	var s string
+	require.Nil(t, msgpack.Unmarshal([]byte{0xd9, 0x03, 'a', 'b', 'c'}, &s))
+	require.Equal(t, "abc", s)
+
+	var ts time.Time
+	require.Nil(t, msgpack.Unmarshal([]byte{0xd6, 0xff, 0x00, 0x00, 0x00, 0x3c}, &ts))
+	require.Equal(t, int64(60), ts.Unix())
+
+	require.ErrorIs(t, msgpack.Unmarshal([]byte{0xa3, 'a'}, &s), msgpack.ErrShortData)
+	require.NotNil(t, msgpack.Unmarshal([]byte{0x01, 0x02}, &n))
+	require.NotNil(t, msgpack.Unmarshal([]byte{0xc1}, &n))
+}
+
+func Test_MaxDepth(t *testing.T) {
+	var v any
+	nested := bytes.Repeat([]byte{0x91}, 8<<20)
+	require.ErrorIs(t, msgpack.Unmarshal(nested, &v), msgpack.ErrMaxDepth)
+
+	nested = bytes.Repeat([]byte{0x81, 0xa1, 'a'}, msgpack.MaxDepth+1)
+	require.ErrorIs(t, msgpack.Unmarshal(nested, &v), msgpack.ErrMaxDepth)
+
+	nested = append(bytes.Repeat([]byte{0x91}, 100), 0xc0)
+	require.Nil(t, msgpack.Unmarshal(nested, &v))
+}
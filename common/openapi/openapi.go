@@ -0,0 +1,178 @@
+package openapi
+
+import "reflect"
+
+// Version is the OpenAPI specification version emitted by the Document.
+const Version = "3.1.0"
+
+// Document is the root object of an OpenAPI 3.1 specification.
+type Document struct {
+	OpenAPI    string                `json:"openapi" yaml:"openapi"`
+	Info       Info                  `json:"info" yaml:"info"`
+	Servers    []Server              `json:"servers,omitempty" yaml:"servers,omitempty"`
+	Tags       []Tag                 `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Paths      map[string]*PathItem  `json:"paths" yaml:"paths"`
+	Components *Components           `json:"components,omitempty" yaml:"components,omitempty"`
+	Security   []SecurityRequirement `json:"security,omitempty" yaml:"security,omitempty"`
+
+	// schemaNames holds the name of the component of each named type.
+	schemaNames map[reflect.Type]string
+}
+
+type Info struct {
+	Title       string `json:"title" yaml:"title"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	Version     string `json:"version" yaml:"version"`
+}
+
+type Server struct {
+	URL         string `json:"url" yaml:"url"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+}
+
+type Tag struct {
+	Name        string `json:"name" yaml:"name"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+}
+
+// PathItem holds the operations of a single path keyed by the lower case
+// HTTP method (get, post, ...).
+type PathItem map[string]*Operation
+
+type Operation struct {
+	Tags        []string              `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Summary     string                `json:"summary,omitempty" yaml:"summary,omitempty"`
+	Description string                `json:"description,omitempty" yaml:"description,omitempty"`
+	OperationID string                `json:"operationId,omitempty" yaml:"operationId,omitempty"`
+	Parameters  []*Parameter          `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty" yaml:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses" yaml:"responses"`
+	Security    []SecurityRequirement `json:"security,omitempty" yaml:"security,omitempty"`
+	Deprecated  bool                  `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`
+	// XVersion is the API version of the route when it is not expressed in
+	// the path or a header parameter (media type and custom versioning).
+	XVersion string `json:"x-version,omitempty" yaml:"x-version,omitempty"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name" yaml:"name"`
+	In          string  `json:"in" yaml:"in"`
+	Description string  `json:"description,omitempty" yaml:"description,omitempty"`
+	Required    bool    `json:"required,omitempty" yaml:"required,omitempty"`
+	Schema      *Schema `json:"schema,omitempty" yaml:"schema,omitempty"`
+}
+
+type RequestBody struct {
+	Description string                `json:"description,omitempty" yaml:"description,omitempty"`
+	Required    bool                  `json:"required,omitempty" yaml:"required,omitempty"`
+	Content     map[string]*MediaType `json:"content" yaml:"content"`
+}
+
+type Response struct {
+	Description string                `json:"description" yaml:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty" yaml:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema  *Schema     `json:"schema,omitempty" yaml:"schema,omitempty"`
+	Example interface{} `json:"example,omitempty" yaml:"example,omitempty"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas,omitempty" yaml:"schemas,omitempty"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty" yaml:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme describes an authentication mechanism that routes can
+// reference by name through their security requirements.
+type SecurityScheme struct {
+	Type         string `json:"type" yaml:"type"`
+	Description  string `json:"description,omitempty" yaml:"description,omitempty"`
+	Name         string `json:"name,omitempty" yaml:"name,omitempty"`
+	In           string `json:"in,omitempty" yaml:"in,omitempty"`
+	Scheme       string `json:"scheme,omitempty" yaml:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty" yaml:"bearerFormat,omitempty"`
+}
+
+// SecurityRequirement maps a security scheme name to the scopes it needs.
+type SecurityRequirement map[string][]string
+
+// Options configures the top level information of a generated Document.
+type Options struct {
+	Title           string
+	Description     string
+	Version         string
+	Servers         []Server
+	SecuritySchemes map[string]*SecurityScheme
+	// Security is applied to every operation that does not declare its own.
+	Security []string
+}
+
+// New creates an empty Document from the given options. The title and
+// version default to "API" and "1.0.0".
+func New(opt Options) *Document {
+	if opt.Title == "" {
+		opt.Title = "API"
+	}
+	if opt.Version == "" {
+		opt.Version = "1.0.0"
+	}
+
+	doc := &Document{
+		OpenAPI: Version,
+		Info: Info{
+			Title:       opt.Title,
+			Description: opt.Description,
+			Version:     opt.Version,
+		},
+		Servers: opt.Servers,
+		Paths:   make(map[string]*PathItem),
+		Components: &Components{
+			Schemas:         make(map[string]*Schema),
+			SecuritySchemes: opt.SecuritySchemes,
+		},
+	}
+	if len(opt.Security) > 0 {
+		doc.Security = Requirements(opt.Security)
+	}
+
+	return doc
+}
+
+// Requirements converts a list of security scheme names into security
+// requirements without scopes.
+func Requirements(names []string) []SecurityRequirement {
+	reqs := make([]SecurityRequirement, 0, len(names))
+	for _, name := range names {
+		reqs = append(reqs, SecurityRequirement{name: []string{}})
+	}
+	return reqs
+}
+
+// AddOperation registers the operation for the given path and method. If an
+// operation already exists for the pair, the existing one is returned.
+func (d *Document) AddOperation(path string, method string, op *Operation) *Operation {
+	item, ok := d.Paths[path]
+	if !ok {
+		item = &PathItem{}
+		d.Paths[path] = item
+	}
+	if existing, ok := (*item)[method]; ok {
+		return existing
+	}
+	(*item)[method] = op
+	return op
+}
+
+// AddTag registers a tag once in the order it is first seen.
+func (d *Document) AddTag(name string) {
+	if name == "" {
+		return
+	}
+	for _, t := range d.Tags {
+		if t.Name == name {
+			return
+		}
+	}
+	d.Tags = append(d.Tags, Tag{Name: name})
+}
@@ -0,0 +1,269 @@
+package openapi
+
+import (
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Schema is the subset of JSON Schema used by OpenAPI 3.1 documents.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty" yaml:"type,omitempty"`
+	Format               string             `json:"format,omitempty" yaml:"format,omitempty"`
+	Description          string             `json:"description,omitempty" yaml:"description,omitempty"`
+	Pattern              string             `json:"pattern,omitempty" yaml:"pattern,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty" yaml:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty" yaml:"maxLength,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty" yaml:"enum,omitempty"`
+	Default              interface{}        `json:"default,omitempty" yaml:"default,omitempty"`
+	Example              interface{}        `json:"example,omitempty" yaml:"example,omitempty"`
+	Items                *Schema            `json:"items,omitempty" yaml:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty" yaml:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty" yaml:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty" yaml:"required,omitempty"`
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// patterns mirrors the regular expressions used by dto/validator so the
+// documented constraints match the enforced ones.
+var patterns = map[string]string{
+	"isAlpha":        `^[a-zA-Z]+$`,
+	"isAlphaNumeric": `^[a-zA-Z0-9]+$`,
+	"isObjectId":     `^[a-f0-9]{24}$`,
+}
+
+var formats = map[string]string{
+	"isEmail":          "email",
+	"isUUID":           "uuid",
+	"isDate":           "date-time",
+	"isDateString":     "date",
+	"isStrongPassword": "password",
+}
+
+// SchemaOf returns the schema of the given type. Named struct types are
+// registered once in the document components and referenced with $ref (see
+// schemaName).
+func (d *Document) SchemaOf(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: d.SchemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: d.SchemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return d.structSchema(t)
+		}
+		name, ok := d.schemaNames[t]
+		if !ok {
+			name = d.schemaName(t)
+			// Reserve the name first so recursive types terminate.
+			d.Components.Schemas[name] = &Schema{}
+			*d.Components.Schemas[name] = *d.structSchema(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + name}
+	default:
+		return &Schema{}
+	}
+}
+
+// schemaName reserves the name of the component of the named type t. It is
+// the name of the type, its type arguments included, such as Page_User for
+// Page[User]. When the name is taken by another type, such as a type of the
+// same name in another package, it is prefixed by the name of the package,
+// then suffixed by a number.
+func (d *Document) schemaName(t reflect.Type) string {
+	if d.schemaNames == nil {
+		d.schemaNames = make(map[reflect.Type]string)
+	}
+
+	short := componentName(t.Name())
+	name := short
+	if _, taken := d.Components.Schemas[name]; taken {
+		name = componentName(path.Base(t.PkgPath())) + "." + short
+	}
+	for i := 2; ; i++ {
+		if _, taken := d.Components.Schemas[name]; !taken {
+			break
+		}
+		name = short + "_" + strconv.Itoa(i)
+	}
+	d.schemaNames[t] = name
+	return name
+}
+
+// componentName converts the name of a type to a component name, which only
+// contains letters, digits, ".", "-" and "_". The type arguments of a generic
+// type are joined with "_", without their package.
+func componentName(typeName string) string {
+	parts := strings.FieldsFunc(typeName, func(r rune) bool {
+		return strings.ContainsRune("[],*() ", r)
+	})
+	for i, part := range parts {
+		if dot := strings.LastIndexByte(part, '.'); dot >= 0 {
+			part = part[dot+1:]
+		}
+		parts[i] = strings.Map(func(r rune) rune {
+			if r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_') {
+				return r
+			}
+			return '_'
+		}, part)
+	}
+	return strings.Join(parts, "_")
+}
+
+// structSchema builds an object schema from the exported fields of t using
+// their json names and validate tags.
+func (d *Document) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, ok := fieldName(field, "json")
+		if !ok {
+			continue
+		}
+		if field.Anonymous && field.Tag.Get("json") == "" && indirect(field.Type).Kind() == reflect.Struct {
+			embedded := d.structSchema(indirect(field.Type))
+			for k, v := range embedded.Properties {
+				schema.Properties[k] = v
+			}
+			schema.Required = append(schema.Required, embedded.Required...)
+			continue
+		}
+
+		prop, required := d.fieldSchema(field)
+		schema.Properties[name] = prop
+		if required {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	return schema
+}
+
+// fieldSchema returns the schema of a struct field enriched with the rules of
+// its validate tag, and whether the field is required.
+func (d *Document) fieldSchema(field reflect.StructField) (*Schema, bool) {
+	schema := d.SchemaOf(field.Type)
+	required := false
+
+	if desc := field.Tag.Get("description"); desc != "" {
+		schema.Description = desc
+	}
+	if example := field.Tag.Get("example"); example != "" {
+		schema.Example = example
+	}
+	if def := field.Tag.Get("default"); def != "" {
+		schema.Default = def
+	}
+
+	tag := field.Tag.Get("validate")
+	if tag == "" {
+		return schema, required
+	}
+	for _, rule := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			required = true
+		case "minLength":
+			if n, err := strconv.Atoi(param); err == nil {
+				schema.MinLength = &n
+			}
+		case "maxLength":
+			if n, err := strconv.Atoi(param); err == nil {
+				schema.MaxLength = &n
+			}
+		case "isInt":
+			schema.Type = "integer"
+		case "isFloat", "isNumber":
+			schema.Type = "number"
+		case "isBool":
+			schema.Type = "boolean"
+		default:
+			if format, ok := formats[name]; ok {
+				schema.Type = "string"
+				schema.Format = format
+			} else if pattern, ok := patterns[name]; ok {
+				schema.Pattern = pattern
+			}
+		}
+	}
+
+	return schema, required
+}
+
+// ParametersOf returns the parameters described by the fields of t that carry
+// the tag matching the location (query or path). Path parameters are always
+// required.
+func (d *Document) ParametersOf(t reflect.Type, in string) []*Parameter {
+	t = indirect(t)
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	params := []*Parameter{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := field.Tag.Get(in)
+		if name == "" || !field.IsExported() {
+			continue
+		}
+		schema, required := d.fieldSchema(field)
+		params = append(params, &Parameter{
+			Name:        name,
+			In:          in,
+			Description: schema.Description,
+			Required:    required || in == "path",
+			Schema:      schema,
+		})
+	}
+	return params
+}
+
+func fieldName(field reflect.StructField, tagName string) (string, bool) {
+	tag := field.Tag.Get(tagName)
+	if tag == "-" {
+		return "", false
+	}
+	name, _, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = field.Name
+	}
+	return name, true
+}
+
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
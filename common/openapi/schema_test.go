@@ -0,0 +1,145 @@
+package openapi_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tinh-tinh/tinhtinh/v2/common/openapi"
+)
+
+type Address struct {
+	City string `json:"city" validate:"required,isAlpha"`
+}
+
+type Base struct {
+	ID string `json:"id" validate:"isUUID"`
+}
+
+type Profile struct {
+	Base
+	Name      string            `json:"name" validate:"required,maxLength=20" example:"John"`
+	Password  string            `json:"password" validate:"isStrongPassword"`
+	Score     float64           `json:"score"`
+	Active    bool              `json:"active"`
+	Tags      []string          `json:"tags"`
+	Extra     map[string]int    `json:"extra"`
+	Address   *Address          `json:"address" validate:"nested"`
+	Friends   []*Profile        `json:"friends"`
+	CreatedAt time.Time         `json:"created_at"`
+	Secret    string            `json:"-"`
+	Raw       []byte            `json:"raw"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	private   string
+}
+
+func Test_SchemaOf(t *testing.T) {
+	doc := openapi.New(openapi.Options{})
+	require.Equal(t, "API", doc.Info.Title)
+	require.Equal(t, "1.0.0", doc.Info.Version)
+
+	schema := doc.SchemaOf(reflect.TypeOf(&Profile{}))
+	require.Equal(t, "#/components/schemas/Profile", schema.Ref)
+
+	profile := doc.Components.Schemas["Profile"]
+	require.Equal(t, "object", profile.Type)
+	require.Equal(t, []string{"name"}, profile.Required)
+	require.Equal(t, "uuid", profile.Properties["id"].Format)
+	require.Equal(t, 20, *profile.Properties["name"].MaxLength)
+	require.Equal(t, "John", profile.Properties["name"].Example)
+	require.Equal(t, "password", profile.Properties["password"].Format)
+	require.Equal(t, "number", profile.Properties["score"].Type)
+	require.Equal(t, "boolean", profile.Properties["active"].Type)
+	require.Equal(t, "array", profile.Properties["tags"].Type)
+	require.Equal(t, "string", profile.Properties["tags"].Items.Type)
+	require.Equal(t, "integer", profile.Properties["extra"].AdditionalProperties.Type)
+	require.Equal(t, "#/components/schemas/Address", profile.Properties["address"].Ref)
+	require.Equal(t, "#/components/schemas/Profile", profile.Properties["friends"].Items.Ref)
+	require.Equal(t, "date-time", profile.Properties["created_at"].Format)
+	require.Equal(t, "byte", profile.Properties["raw"].Format)
+	require.Contains(t, profile.Properties, "labels")
+	require.NotContains(t, profile.Properties, "Secret")
+	require.NotContains(t, profile.Properties, "private")
+
+	address := doc.Components.Schemas["Address"]
+	require.Equal(t, []string{"city"}, address.Required)
+	require.Equal(t, "^[a-zA-Z]+$", address.Properties["city"].Pattern)
+}
+
+func Test_ParametersOf(t *testing.T) {
+	type Query struct {
+		Page  int    `query:"page" validate:"required"`
+		Sort  string `query:"sort" description:"sort field"`
+		IDs   []int  `query:"ids"`
+		NoTag string
+	}
+
+	doc := openapi.New(openapi.Options{})
+	params := doc.ParametersOf(reflect.TypeOf(Query{}), "query")
+	require.Len(t, params, 3)
+	require.True(t, params[0].Required)
+	require.Equal(t, "sort field", params[1].Description)
+	require.False(t, params[1].Required)
+	require.Equal(t, "array", params[2].Schema.Type)
+
+	require.Nil(t, doc.ParametersOf(reflect.TypeOf(""), "query"))
+}
+
+func Test_Document(t *testing.T) {
+	doc := openapi.New(openapi.Options{Title: "Test", Security: []string{"apiKey"}})
+	require.Equal(t, []openapi.SecurityRequirement{{"apiKey": {}}}, doc.Security)
+
+	op := &openapi.Operation{}
+	require.Equal(t, op, doc.AddOperation("/a", "get", op))
+	require.Equal(t, op, doc.AddOperation("/a", "get", &openapi.Operation{}))
+
+	doc.AddTag("a")
+	doc.AddTag("a")
+	doc.AddTag("")
+	require.Len(t, doc.Tags, 1)
+}
+
+// Schema has the name of openapi.Schema, in another package.
+type Schema struct {
+	Name string `json:"name"`
+}
+
+type Page[T any] struct {
+	Items []T `json:"items"`
+	Total int `json:"total"`
+}
+
+func Test_SchemaOf_Names(t *testing.T) {
+	doc := openapi.New(openapi.Options{})
+
+	require.Equal(t, "#/components/schemas/Schema", doc.SchemaOf(reflect.TypeOf(openapi.Schema{})).Ref)
+	require.Equal(t, "#/components/schemas/openapi_test.Schema", doc.SchemaOf(reflect.TypeOf(Schema{})).Ref)
+	require.Equal(t, "#/components/schemas/Schema", doc.SchemaOf(reflect.TypeOf(&openapi.Schema{})).Ref)
+	require.Equal(t, "#/components/schemas/openapi_test.Schema", doc.SchemaOf(reflect.TypeOf(&Schema{})).Ref)
+	require.Contains(t, doc.Components.Schemas["Schema"].Properties, "$ref")
+	require.Contains(t, doc.Components.Schemas["openapi_test.Schema"].Properties, "name")
+
+	page := doc.SchemaOf(reflect.TypeOf(Page[Address]{}))
+	require.Equal(t, "#/components/schemas/Page_Address", page.Ref)
+	require.Equal(t, "#/components/schemas/Address", doc.Components.Schemas["Page_Address"].Properties["items"].Items.Ref)
+	require.Equal(t, "#/components/schemas/Page_map_string_int", doc.SchemaOf(reflect.TypeOf(Page[map[string]*int]{})).Ref)
+
+	first := func() reflect.Type {
+		type Item struct{ A string }
+		return reflect.TypeOf(Item{})
+	}()
+	second := func() reflect.Type {
+		type Item struct{ B string }
+		return reflect.TypeOf(Item{})
+	}()
+	third := func() reflect.Type {
+		type Item struct{ C string }
+		return reflect.TypeOf(Item{})
+	}()
+	require.Equal(t, "#/components/schemas/Item", doc.SchemaOf(first).Ref)
+	require.Equal(t, "#/components/schemas/openapi_test.Item", doc.SchemaOf(second).Ref)
+	require.Equal(t, "#/components/schemas/Item_2", doc.SchemaOf(third).Ref)
+	require.Equal(t, "#/components/schemas/Item", doc.SchemaOf(first).Ref)
+	require.Contains(t, doc.Components.Schemas["Item_2"].Properties, "C")
+}
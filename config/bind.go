@@ -0,0 +1,162 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// applyDefaults sets the fields with a default tag that are still zero,
+// including the fields of nested structs.
+func applyDefaults(val reflect.Value) error {
+	return walkFields(val, func(field reflect.StructField, fv reflect.Value) error {
+		def, ok := field.Tag.Lookup("default")
+		if !ok || !fv.IsZero() {
+			return nil
+		}
+		if err := setValue(fv, def); err != nil {
+			return fmt.Errorf("config: default of field %s: %w", field.Name, err)
+		}
+		return nil
+	})
+}
+
+// applyEnv sets the fields with an env tag from the variables. A variable
+// not set is read from the file named by the variable suffixed with _FILE.
+func applyEnv(val reflect.Value, vars map[string]string) error {
+	return walkFields(val, func(field reflect.StructField, fv reflect.Value) error {
+		name := field.Tag.Get("env")
+		if name == "" || name == "-" {
+			return nil
+		}
+		value, ok := vars[name]
+		if !ok {
+			path, isFile := vars[name+"_FILE"]
+			if !isFile {
+				return nil
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("config: %s_FILE of field %s: %w", name, field.Name, err)
+			}
+			value = strings.TrimRight(string(data), "\r\n")
+		}
+		if err := setValue(fv, value); err != nil {
+			return fmt.Errorf("config: %s of field %s: %w", name, field.Name, err)
+		}
+		return nil
+	})
+}
+
+// walkFields calls fnc for each exported field of the struct, recursing into
+// the nested structs, including the ones behind a pointer. A nil pointer is
+// allocated when one of the fields of its struct is set.
+func walkFields(val reflect.Value, fnc func(field reflect.StructField, fv reflect.Value) error) error {
+	typ := val.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		fv := val.Field(i)
+		if fv.Kind() == reflect.Pointer && fv.IsNil() && isNested(fv.Type().Elem()) {
+			ptr := reflect.New(fv.Type().Elem())
+			if err := walkFields(ptr.Elem(), fnc); err != nil {
+				return err
+			}
+			if !ptr.Elem().IsZero() {
+				fv.Set(ptr)
+			}
+			continue
+		}
+		nested := fv
+		if nested.Kind() == reflect.Pointer && !nested.IsNil() {
+			nested = nested.Elem()
+		}
+		if isNested(nested.Type()) {
+			if err := walkFields(nested, fnc); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := fnc(field, fv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isNested reports whether the fields of the type are walked.
+func isNested(typ reflect.Type) bool {
+	return typ.Kind() == reflect.Struct && typ != reflect.TypeOf(time.Time{})
+}
+
+// setValue parses the string into the field. Slices are comma-separated.
+func setValue(fv reflect.Value, value string) error {
+	if fv.Kind() == reflect.Pointer {
+		ptr := reflect.New(fv.Type().Elem())
+		if err := setValue(ptr.Elem(), value); err != nil {
+			return err
+		}
+		fv.Set(ptr)
+		return nil
+	}
+
+	if fv.Type() == durationType {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		fv.SetInt(int64(d))
+		return nil
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(f)
+	case reflect.Slice:
+		var parts []string
+		if value != "" {
+			parts = strings.Split(value, ",")
+		}
+		slice := reflect.MakeSlice(fv.Type(), len(parts), len(parts))
+		for i, part := range parts {
+			if err := setValue(slice.Index(i), strings.TrimSpace(part)); err != nil {
+				return err
+			}
+		}
+		fv.Set(slice)
+	default:
+		return fmt.Errorf("unsupported type %s", fv.Type())
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+// Package config loads the configuration of the application into a typed
+// struct and provides it to every module.
+//
+// The values are read from, by increasing precedence:
+//
+//   - the default tags of the struct fields
+//   - the JSON and YAML files, then their profile overlays
+//   - the .env files, then their profile overlays
+//   - the environment variables
+//
+// A field is bound to a variable with the env tag. When the variable is not
+// set, the variable suffixed with _FILE is read as the path of a file holding
+// the value, as done for Docker secrets. The struct is then validated with the
+// validate tags of dto/validator:
+//
+//	type Config struct {
+//		Port       int    `env:"PORT" default:"3000" validate:"isInt"`
+//		DbURL      string `env:"DB_URL" yaml:"dbUrl" validate:"required"`
+//		DbPassword string `env:"DB_PASSWORD"`
+//	}
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/tinh-tinh/tinhtinh/v2/core"
+	"github.com/tinh-tinh/tinhtinh/v2/dto/validator"
+)
+
+// ProfileEnv is the environment variable selecting the profile when
+// Options.Profile is empty.
+const ProfileEnv = "APP_PROFILE"
+
+type Options struct {
+	// EnvFiles are the .env files to load. Default is ".env". A missing .env
+	// file is ignored.
+	EnvFiles []string
+	// IgnoreEnvFiles disables the .env files, the configuration is only read
+	// from the environment and the files.
+	IgnoreEnvFiles bool
+	// Files are the JSON (.json) or YAML (.yaml, .yml) files to load, in
+	// order. A missing file fails the loading.
+	Files []string
+	// Profile loads the overlay of each file after it: config.production.yaml
+	// for config.yaml and .env.production for .env with the production
+	// profile. A missing overlay is ignored. Default is the value of the
+	// APP_PROFILE environment variable.
+	Profile string
+}
+
+// ForRoot creates a global module providing the configuration. The
+// configuration is loaded when the module is imported and an invalid
+// configuration panics, so the application fails at startup. Any module can
+// then get it with core.Inject[T].
+func ForRoot[T any](opts ...Options) core.Modules {
+	var opt Options
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+
+	return func(module core.Module) core.Module {
+		cfg, err := Load[T](opt)
+		if err != nil {
+			panic(err)
+		}
+
+		configModule := module.New(core.NewModuleOptions{Global: true})
+		provider := configModule.NewProvider(cfg)
+		configModule.Export(provider.GetName())
+		return configModule
+	}
+}
+
+// Load reads the configuration into a new T and validates it.
+func Load[T any](opt Options) (*T, error) {
+	cfg := new(T)
+	val := reflect.ValueOf(cfg).Elem()
+	if val.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("config: %T is not a struct", *cfg)
+	}
+
+	profile := opt.Profile
+	if profile == "" {
+		profile = os.Getenv(ProfileEnv)
+	}
+
+	if err := applyDefaults(val); err != nil {
+		return nil, err
+	}
+
+	for _, file := range opt.Files {
+		if err := loadFile(file, cfg, false); err != nil {
+			return nil, err
+		}
+		if profile != "" {
+			if err := loadFile(profilePath(file, profile), cfg, true); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	vars := make(map[string]string)
+	if !opt.IgnoreEnvFiles {
+		envFiles := opt.EnvFiles
+		if len(envFiles) == 0 {
+			envFiles = []string{".env"}
+		}
+		for _, file := range envFiles {
+			if err := loadEnvFile(file, vars); err != nil {
+				return nil, err
+			}
+			if profile != "" {
+				if err := loadEnvFile(file+"."+profile, vars); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	for _, kv := range os.Environ() {
+		if key, value, ok := strings.Cut(kv, "="); ok {
+			vars[key] = value
+		}
+	}
+
+	if err := applyEnv(val, vars); err != nil {
+		return nil, err
+	}
+
+	v := validator.Validator{}
+	if err := v.Validate(cfg); err != nil {
+		return nil, fmt.Errorf("config: invalid configuration: %w", err)
+	}
+	return cfg, nil
+}
+
+// profilePath returns the path of the overlay of the file for the profile:
+// config.production.yaml for config.yaml.
+func profilePath(file string, profile string) string {
+	ext := filepath.Ext(file)
+	return strings.TrimSuffix(file, ext) + "." + profile + ext
+}
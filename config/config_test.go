@@ -0,0 +1,201 @@
+package config_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tinh-tinh/tinhtinh/v2/config"
+	"github.com/tinh-tinh/tinhtinh/v2/core"
+)
+
+type Database struct {
+	URL      string `env:"DB_URL" yaml:"url" json:"url" validate:"required"`
+	Password string `env:"DB_PASSWORD" yaml:"password" json:"password"`
+	Pool     int    `env:"DB_POOL" yaml:"pool" json:"pool" default:"5"`
+}
+
+type Config struct {
+	Port     int           `env:"PORT" yaml:"port" json:"port" default:"3000"`
+	Debug    bool          `env:"DEBUG" yaml:"debug" json:"debug"`
+	Timeout  time.Duration `env:"TIMEOUT" default:"5s"`
+	Origins  []string      `env:"ORIGINS" yaml:"origins" json:"origins"`
+	Name     string        `env:"APP_NAME" yaml:"name" json:"name" validate:"isAlpha"`
+	Database Database      `yaml:"database" json:"database" validate:"nested"`
+}
+
+func writeFile(t *testing.T, dir string, name string, content string) string {
+	path := filepath.Join(dir, name)
+	require.Nil(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func Test_Load(t *testing.T) {
+	dir := t.TempDir()
+	yamlFile := writeFile(t, dir, "config.yaml", `
+port: 8080
+name: base
+origins: [a.com]
+database:
+  url: postgres://base
+`)
+	writeFile(t, dir, "config.production.yaml", `
+name: production
+database:
+  url: postgres://production
+`)
+	envFile := writeFile(t, dir, ".env", `
+# comment
+export DEBUG=true
+ORIGINS="a.com, b.com"
+DB_POOL=10 # inline comment
+`)
+	writeFile(t, dir, ".env.production", "DB_POOL=20\n")
+	secret := writeFile(t, dir, "db_password", "s3cret\n")
+
+	t.Setenv("PORT", "9090")
+	t.Setenv("DB_PASSWORD_FILE", secret)
+
+	cfg, err := config.Load[Config](config.Options{
+		EnvFiles: []string{envFile},
+		Files:    []string{yamlFile},
+		Profile:  "production",
+	})
+	require.Nil(t, err)
+	require.Equal(t, 9090, cfg.Port)
+	require.True(t, cfg.Debug)
+	require.Equal(t, 5*time.Second, cfg.Timeout)
+	require.Equal(t, []string{"a.com", "b.com"}, cfg.Origins)
+	require.Equal(t, "production", cfg.Name)
+	require.Equal(t, "postgres://production", cfg.Database.URL)
+	require.Equal(t, "s3cret", cfg.Database.Password)
+	require.Equal(t, 20, cfg.Database.Pool)
+}
+
+func Test_Load_JSON(t *testing.T) {
+	dir := t.TempDir()
+	jsonFile := writeFile(t, dir, "config.json", `{"port": 4000, "database": {"url": "mysql://json"}}`)
+	t.Setenv(config.ProfileEnv, "staging")
+	writeFile(t, dir, "config.staging.json", `{"debug": true}`)
+
+	cfg, err := config.Load[Config](config.Options{
+		IgnoreEnvFiles: true,
+		Files:          []string{jsonFile},
+	})
+	require.Nil(t, err)
+	require.Equal(t, 4000, cfg.Port)
+	require.True(t, cfg.Debug)
+	require.Equal(t, "mysql://json", cfg.Database.URL)
+	require.Equal(t, 5, cfg.Database.Pool)
+}
+
+type Cache struct {
+	Host string `env:"CACHE_HOST"`
+	TTL  int    `env:"CACHE_TTL"`
+}
+
+type PointerConfig struct {
+	Cache   *Cache
+	Replica *Database
+}
+
+func Test_Load_NilPointer(t *testing.T) {
+	t.Setenv("CACHE_HOST", "redis")
+
+	cfg, err := config.Load[PointerConfig](config.Options{IgnoreEnvFiles: true})
+	require.Nil(t, err)
+	require.NotNil(t, cfg.Cache)
+	require.Equal(t, "redis", cfg.Cache.Host)
+	require.Zero(t, cfg.Cache.TTL)
+	// The default of a nested field allocates its struct too.
+	require.NotNil(t, cfg.Replica)
+	require.Equal(t, 5, cfg.Replica.Pool)
+}
+
+func Test_Load_Error(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := config.Load[Config](config.Options{IgnoreEnvFiles: true})
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "URL is required")
+
+	t.Setenv("DB_URL", "postgres://env")
+	t.Setenv("PORT", "abc")
+	_, err = config.Load[Config](config.Options{IgnoreEnvFiles: true})
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "PORT of field Port")
+
+	t.Setenv("PORT", "3000")
+	_, err = config.Load[Config](config.Options{
+		IgnoreEnvFiles: true,
+		Files:          []string{filepath.Join(dir, "missing.yaml")},
+	})
+	require.NotNil(t, err)
+
+	toml := writeFile(t, dir, "config.toml", "port = 1")
+	_, err = config.Load[Config](config.Options{IgnoreEnvFiles: true, Files: []string{toml}})
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "unsupported file")
+
+	envFile := writeFile(t, dir, ".env", "INVALID\n")
+	_, err = config.Load[Config](config.Options{EnvFiles: []string{envFile}})
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "line 1")
+}
+
+func Test_ForRoot(t *testing.T) {
+	t.Setenv("DB_URL", "postgres://env")
+	t.Setenv("APP_NAME", "tinhtinh")
+
+	userController := func(module core.Module) core.Controller {
+		ctrl := module.NewController("users")
+		ctrl.Get("", func(ctx core.Ctx) error {
+			cfg := core.Inject[Config](module)
+			return ctx.JSON(core.Map{"data": cfg.Name})
+		})
+		return ctrl
+	}
+
+	userModule := func(module core.Module) core.Module {
+		return module.New(core.NewModuleOptions{
+			Controllers: []core.Controllers{userController},
+		})
+	}
+
+	appModule := func() core.Module {
+		return core.NewModule(core.NewModuleOptions{
+			// The config module is global: the user module imported first
+			// can inject it.
+			Imports: []core.Modules{userModule, config.ForRoot[Config](config.Options{IgnoreEnvFiles: true})},
+		})
+	}
+
+	app := core.CreateFactory(appModule)
+	app.SetGlobalPrefix("api")
+	cfg := core.Inject[Config](app.Module)
+	require.NotNil(t, cfg)
+	require.Equal(t, 3000, cfg.Port)
+
+	testServer := httptest.NewServer(app.PrepareBeforeListen())
+	defer testServer.Close()
+
+	resp, err := testServer.Client().Get(testServer.URL + "/api/users")
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func Test_ForRoot_Invalid(t *testing.T) {
+	t.Setenv("APP_NAME", "not valid")
+
+	require.Panics(t, func() {
+		core.CreateFactory(func() core.Module {
+			return core.NewModule(core.NewModuleOptions{
+				Imports: []core.Modules{config.ForRoot[Config](config.Options{IgnoreEnvFiles: true})},
+			})
+		})
+	})
+}
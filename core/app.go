@@ -51,6 +51,23 @@ type App struct {
 	timeout      time.Duration
 	Services     []Service
 	pipe         PipeFnc
+	// routes and routers are the snapshots of the registered routes kept
+	// after free.
+	routes  []DocRoute
+	routers []*Router
+	// patterns maps each pattern registered with the Mux to the indexes of
+	// its routes.
+	patterns map[string][]int
+	// stageObservers are notified of the stages of the routes.
+	stageObservers []StageObserver
+	// etag is the ETagMode of the routes without ETAG metadata.
+	etag ETagMode
+	// preconditions is the ResourceState of the routes without
+	// PRECONDITIONS metadata.
+	preconditions ResourceState
+	// serializers are the serializers of Ctx.Send and Ctx.BodyParser, in
+	// order of preference.
+	serializers []Serializer
 }
 
 type (
@@ -68,6 +85,22 @@ type (
 		Timeout time.Duration
 		// Custom Validate
 		CustomValidation PipeFnc
+		// Overrides replaces providers of the module tree when it is built.
+		Overrides []ProviderOverride
+		// ETag generates the entity tags of the responses of Ctx.JSON and
+		// Ctx.XML, and answers the conditional requests. Default is
+		// ETagDisabled. The routes override it with the ETAG metadata.
+		ETag ETagMode
+		// Preconditions returns the state of the resource targeted by the PUT,
+		// PATCH and DELETE requests, so their If-Match and
+		// If-Unmodified-Since headers are evaluated before the handler. The
+		// routes override it with the PRECONDITIONS metadata.
+		Preconditions ResourceState
+		// Serializers are added to the serializers of Ctx.Send and
+		// Ctx.BodyParser, replacing those of the same media type. The
+		// default serializers are JSON, with the Encoder and Decoder, XML,
+		// CSV, MessagePack and plain text.
+		Serializers []Serializer
 	}
 )
 
@@ -88,12 +121,22 @@ type Service interface {
 // The App is created with a default session of nil.
 // The App is created with a default error handler of ErrorHandlerDefault.
 //
-// The App is initialized by calling the init method of the module.
+// The App is initialized by calling the init method of the module, then the
+// OnModuleInit and OnApplicationBootstrap hooks of the providers. It panics
+// with the aggregated errors if any hook fails.
 // The App is then returned.
 func CreateFactory(module ModuleParam, opt ...AppOptions) *App {
 	v := validator.Validator{}
+	var (
+		overrides   []ProviderOverride
+		serializers []Serializer
+	)
+	for _, o := range opt {
+		overrides = append(overrides, o.Overrides...)
+		serializers = append(serializers, o.Serializers...)
+	}
 	app := &App{
-		Module:       module(),
+		Module:       buildWithOverrides(module, overrides),
 		Mux:          http.NewServeMux(),
 		encoder:      json.Marshal,
 		decoder:      json.Unmarshal,
@@ -127,6 +170,17 @@ func CreateFactory(module ModuleParam, opt ...AppOptions) *App {
 		if mergeOpts.CustomValidation != nil {
 			app.pipe = mergeOpts.CustomValidation
 		}
+		if mergeOpts.ETag != "" {
+			app.etag = mergeOpts.ETag
+		}
+		if mergeOpts.Preconditions != nil {
+			app.preconditions = mergeOpts.Preconditions
+		}
+	}
+
+	app.serializers = defaultSerializers(app.encoder, app.decoder)
+	for _, s := range serializers {
+		app.serializers = setSerializer(app.serializers, s)
 	}
 
 	fmt.Printf("%s %s %s %s\n",
@@ -135,7 +189,14 @@ func CreateFactory(module ModuleParam, opt ...AppOptions) *App {
 		color.Yellow("[Module Initializer]"),
 		color.Green(common.GetFunctionName(module)),
 	)
+	if dynMod, ok := app.Module.(*DynamicModule); ok {
+		dynMod.app = app
+		dynMod.resolveProviders()
+	}
 	app.Module.init()
+	if err := app.initLifecycle(); err != nil {
+		panic(err)
+	}
 	return app
 }
 
@@ -212,8 +273,13 @@ func (app *App) PrepareBeforeListen() http.Handler {
 // indicating that the server is running.
 //
 // The server is then shut down when the process receives a SIGINT or SIGTERM
-// signal. It waits for 10 seconds for the server to shut down, and if it does
-// not shut down within that time, it prints an error message to the console.
+// signal. Once the before shutdown hooks returned, it waits for 10 seconds for
+// the server to shut down, and if it does not shut down within that time, it
+// prints an error message to the console.
+//
+// Providers implementing BeforeApplicationShutdown are called before the server
+// stops, and providers implementing OnModuleDestroy and OnApplicationShutdown
+// after it stopped, with the name of the received signal.
 //
 // Finally, it runs any hooks registered with the AFTER_SHUTDOWN run-at value.
 func (app *App) Listen(port int) {
@@ -233,21 +299,28 @@ func (app *App) Listen(port int) {
 
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-	<-sigChan
-
-	shutdownCtx, shutdownRelease := context.WithTimeout(context.Background(), 10*time.Second)
-	defer shutdownRelease()
+	sig := signalName(<-sigChan)
 
 	for _, hook := range app.hooks {
 		if hook.RunAt == BEFORE_SHUTDOWN {
 			hook.fnc()
 		}
 	}
+	if err := app.beforeShutdown(sig); err != nil {
+		log.Printf("error when shutdown providers %v", err)
+	}
 
+	// The before shutdown hooks may drain the traffic for a while, the server
+	// has its own budget once they return.
+	shutdownCtx, shutdownRelease := context.WithTimeout(context.Background(), 10*time.Second)
+	defer shutdownRelease()
 	err := server.Shutdown(shutdownCtx)
 	if err != nil {
 		log.Fatalf("error when shutdown server %v", err)
 	}
+	if err := app.Shutdown(sig); err != nil {
+		log.Printf("error when shutdown providers %v", err)
+	}
 	log.Println("Server shutdown")
 	for _, hook := range app.hooks {
 		if hook.RunAt == AFTER_SHUTDOWN {
@@ -0,0 +1,41 @@
+package core
+
+import (
+	"os"
+
+	"github.com/tinh-tinh/tinhtinh/v2/common"
+)
+
+// ConditionalModule returns a module imported only if the predicate returns
+// true. The predicate is evaluated when the importing module is initialized,
+// not when ConditionalModule is called. A skipped module is listed as
+// disabled in the module tree, but none of its providers or controllers are
+// registered:
+//
+//	core.NewModuleOptions{
+//		Imports: []core.Modules{
+//			core.ConditionalModule(core.IfEnv("ENABLE_PPROF", "1"), pprof.Module),
+//		},
+//	}
+func ConditionalModule(predicate func() bool, module Modules) Modules {
+	name := common.GetFunctionName(module)
+	return func(parent Module) Module {
+		dynMod, ok := parent.(*DynamicModule)
+		if !ok {
+			return module(parent)
+		}
+		dynMod.importing = name
+		if predicate != nil && !predicate() {
+			return &DynamicModule{Name: name, parent: dynMod, Scope: Global, disabled: true}
+		}
+		return module(parent)
+	}
+}
+
+// IfEnv returns a predicate reporting whether the environment variable is set
+// to the value.
+func IfEnv(key string, value string) func() bool {
+	return func() bool {
+		return os.Getenv(key) == value
+	}
+}
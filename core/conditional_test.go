@@ -0,0 +1,129 @@
+package core_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tinh-tinh/tinhtinh/v2/core"
+)
+
+func paymentModule(module core.Module) core.Module {
+	payment := module.New(core.NewModuleOptions{
+		Controllers: []core.Controllers{func(module core.Module) core.Controller {
+			ctrl := module.NewController("payments")
+			ctrl.Get("", func(ctx core.Ctx) error {
+				return ctx.JSON(core.Map{"data": "ok"})
+			})
+			return ctrl
+		}},
+	})
+	payment.NewProvider(core.ProviderOptions{
+		Name:  "payment",
+		Value: "stripe",
+	})
+	payment.Export("payment")
+	return payment
+}
+
+func Test_ConditionalModule(t *testing.T) {
+	t.Setenv("ENABLE_PAYMENT", "0")
+
+	appModule := func() core.Module {
+		return core.NewModule(core.NewModuleOptions{
+			Imports: []core.Modules{
+				core.ConditionalModule(core.IfEnv("ENABLE_PAYMENT", "1"), paymentModule),
+				core.ConditionalModule(func() bool { return true }, configModule),
+			},
+		})
+	}
+
+	app := core.CreateFactory(appModule)
+	app.SetGlobalPrefix("api")
+	require.Nil(t, app.Module.Ref("payment"))
+	require.Equal(t, "config", app.Module.Ref("config"))
+
+	testServer := httptest.NewServer(app.PrepareBeforeListen())
+	defer testServer.Close()
+
+	resp, err := testServer.Client().Get(testServer.URL + "/api/payments")
+	require.Nil(t, err)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	tree := app.GetTree()
+	require.Len(t, tree.Imports, 2)
+	require.Equal(t, "github.com/tinh-tinh/tinhtinh/v2/core_test.paymentModule", tree.Imports[0].Name)
+	require.True(t, tree.Imports[0].Disabled)
+	require.Empty(t, tree.Imports[0].Providers)
+	require.Equal(t, "github.com/tinh-tinh/tinhtinh/v2/core_test.configModule", tree.Imports[1].Name)
+	require.False(t, tree.Imports[1].Disabled)
+	require.True(t, tree.Imports[1].Global)
+	for _, p := range tree.Imports[1].Providers {
+		require.NotContains(t, p.VisibleIn, tree.Imports[0].Name)
+	}
+
+	discovery := app.Module.Ref(core.DISCOVERY).(*core.DiscoveryService)
+	modules := discovery.Modules()
+	require.Len(t, modules, 3)
+	require.True(t, modules[1].Disabled)
+
+	path := filepath.Join(t.TempDir(), "tree.html")
+	require.Nil(t, app.Visualize(path))
+	html, err := os.ReadFile(path)
+	require.Nil(t, err)
+	require.Contains(t, string(html), `"disabled": true`)
+}
+
+func Test_ConditionalModule_Enabled(t *testing.T) {
+	t.Setenv("ENABLE_PAYMENT", "1")
+
+	appModule := func() core.Module {
+		return core.NewModule(core.NewModuleOptions{
+			Imports: []core.Modules{
+				core.ConditionalModule(core.IfEnv("ENABLE_PAYMENT", "1"), paymentModule),
+			},
+		})
+	}
+
+	app := core.CreateFactory(appModule)
+	app.SetGlobalPrefix("api")
+	require.Equal(t, "stripe", app.Module.Ref("payment"))
+
+	testServer := httptest.NewServer(app.PrepareBeforeListen())
+	defer testServer.Close()
+
+	resp, err := testServer.Client().Get(testServer.URL + "/api/payments")
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	tree := app.GetTree()
+	require.Equal(t, "github.com/tinh-tinh/tinhtinh/v2/core_test.paymentModule", tree.Imports[0].Name)
+	require.False(t, tree.Imports[0].Disabled)
+	require.Len(t, tree.Imports[0].Controllers, 1)
+}
+
+func Test_ImportsFunc(t *testing.T) {
+	appModule := func() core.Module {
+		return core.NewModule(core.NewModuleOptions{
+			Imports: []core.Modules{configModule},
+			ImportsFunc: func(module core.Module) []core.Modules {
+				mock := module.Ref("config") == "config"
+				return []core.Modules{
+					core.ConditionalModule(func() bool { return !mock }, paymentModule),
+					userModule,
+				}
+			},
+		})
+	}
+
+	app := core.CreateFactory(appModule)
+	require.Nil(t, app.Module.Ref("payment"))
+	require.Equal(t, "user:config", app.Module.Ref("user"))
+
+	tree := app.GetTree()
+	require.Len(t, tree.Imports, 3)
+	require.True(t, tree.Imports[1].Disabled)
+}
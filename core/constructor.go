@@ -0,0 +1,149 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+)
+
+// ConstructorOptions customizes the provider registered by ProvideConstructor.
+type ConstructorOptions struct {
+	// Name of the provider. Default is the name Inject would look up: the
+	// result of the ProvideName method of T if any, otherwise the name of T.
+	Name Provide
+	// Scope of the provider. Default is the scope of the module.
+	Scope Scope
+}
+
+var (
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+	requestType = reflect.TypeOf((*http.Request)(nil))
+)
+
+// ProvideConstructor registers a provider of type T created by the
+// constructor. The dependencies are inferred from the parameter types of the
+// constructor, each one looked up under the name ProvideConstructor or Inject
+// would use for it:
+//
+//	core.ProvideConstructor[UserService](module, func(repo *UserRepository, cfg Config) (*UserService, error) {
+//		return &UserService{repo: repo, cfg: cfg}, nil
+//	})
+//
+// The constructor returns *T or T, optionally followed by an error. When T is
+// an interface, the constructor can return any implementation of it, and the
+// provider is registered under the name of the interface.
+//
+// A *http.Request parameter receives the current request and makes the
+// provider request-scoped.
+//
+// It panics if the constructor does not have one of the expected signatures.
+func ProvideConstructor[T any](module Module, constructor any, opts ...ConstructorOptions) Provider {
+	target := reflect.TypeOf((*T)(nil)).Elem()
+	fnc := reflect.ValueOf(constructor)
+	fncType := fnc.Type()
+	if fncType.Kind() != reflect.Func {
+		panic(fmt.Sprintf("constructor of %s must be a function, got %s", target, fncType))
+	}
+	checkConstructorResults(target, fncType)
+
+	var opt ConstructorOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	name := opt.Name
+	if name == "" {
+		name = typeProvideName(target)
+	}
+
+	inject := make([]Provide, 0, fncType.NumIn())
+	for i := 0; i < fncType.NumIn(); i++ {
+		in := fncType.In(i)
+		if in == requestType {
+			inject = append(inject, REQUEST)
+			continue
+		}
+		inject = append(inject, typeProvideName(in))
+	}
+
+	return module.NewProvider(ProviderOptions{
+		Name:   name,
+		Scope:  opt.Scope,
+		Inject: inject,
+		FactoryErr: func(param ...interface{}) (interface{}, error) {
+			args := make([]reflect.Value, 0, len(param))
+			for i, p := range param {
+				arg, err := constructorArg(fncType.In(i), p)
+				if err != nil {
+					return nil, fmt.Errorf("parameter %d (%s): %w", i, inject[i], err)
+				}
+				args = append(args, arg)
+			}
+
+			results := fnc.Call(args)
+			if len(results) == 2 && !results[1].IsNil() {
+				return nil, results[1].Interface().(error)
+			}
+			if isNil(results[0]) {
+				return nil, nil
+			}
+			return results[0].Interface(), nil
+		},
+	})
+}
+
+// checkConstructorResults panics if the results of the constructor are not
+// (R) or (R, error) with R assignable to T or *T.
+func checkConstructorResults(target reflect.Type, fncType reflect.Type) {
+	valid := fncType.NumOut() == 1 || (fncType.NumOut() == 2 && fncType.Out(1) == errorType)
+	if valid {
+		out := fncType.Out(0)
+		valid = out.AssignableTo(target) || out.AssignableTo(reflect.PointerTo(target))
+	}
+	if !valid {
+		panic(fmt.Sprintf("constructor of %s must return *%s or %s, optionally with an error, got %s",
+			target, target, target, fncType))
+	}
+}
+
+// typeProvideName returns the name a provider of the type is registered
+// under: the name of the interface, or the name getProvideName returns for the
+// struct.
+func typeProvideName(t reflect.Type) Provide {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return Provide(t.Name())
+	}
+	return Provide(getProvideName(reflect.New(t).Interface()))
+}
+
+// constructorArg converts the value of a provider to the type of the
+// constructor parameter, dereferencing it when the parameter is not a
+// pointer.
+func constructorArg(t reflect.Type, value interface{}) (reflect.Value, error) {
+	if value == nil {
+		return reflect.Zero(t), nil
+	}
+	v := reflect.ValueOf(value)
+	if v.Type().AssignableTo(t) {
+		return v, nil
+	}
+	if v.Kind() == reflect.Pointer && !v.IsNil() && v.Elem().Type().AssignableTo(t) {
+		return v.Elem(), nil
+	}
+	if t.Kind() == reflect.Pointer && v.Type().AssignableTo(t.Elem()) {
+		ptr := reflect.New(t.Elem())
+		ptr.Elem().Set(v)
+		return ptr, nil
+	}
+	return reflect.Value{}, fmt.Errorf("cannot use %T as %s", value, t)
+}
+
+func isNil(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+		return v.IsNil()
+	}
+	return false
+}
@@ -0,0 +1,154 @@
+package core_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tinh-tinh/tinhtinh/v2/core"
+)
+
+type ctorConfig struct {
+	DSN string
+}
+
+type ctorRepository interface {
+	Find() string
+}
+
+type ctorPgRepository struct {
+	dsn string
+}
+
+func (r *ctorPgRepository) Find() string { return "pg:" + r.dsn }
+
+type ctorService struct {
+	repo ctorRepository
+}
+
+type ctorNamed struct{}
+
+func (ctorNamed) ProvideName() string { return "named" }
+
+type ctorTenant struct {
+	ID string
+}
+
+type ctorCounter struct {
+	N int
+}
+
+func Test_ProvideConstructor(t *testing.T) {
+	appModule := func() core.Module {
+		module := core.NewModule(core.NewModuleOptions{})
+		// Declared before its dependencies.
+		core.ProvideConstructor[ctorService](module, func(repo ctorRepository) *ctorService {
+			return &ctorService{repo: repo}
+		})
+		core.ProvideConstructor[ctorRepository](module, func(cfg ctorConfig) (*ctorPgRepository, error) {
+			return &ctorPgRepository{dsn: cfg.DSN}, nil
+		})
+		core.ProvideConstructor[ctorConfig](module, func() ctorConfig {
+			return ctorConfig{DSN: "localhost"}
+		})
+		core.ProvideConstructor[ctorNamed](module, func(cfg *ctorConfig) *ctorNamed {
+			return &ctorNamed{}
+		})
+		return module
+	}
+
+	app := core.CreateFactory(appModule)
+
+	svc := core.Inject[ctorService](app.Module)
+	require.NotNil(t, svc)
+	require.Equal(t, "pg:localhost", svc.repo.Find())
+
+	repo, ok := app.Module.Ref("ctorRepository").(ctorRepository)
+	require.True(t, ok)
+	require.Equal(t, "pg:localhost", repo.Find())
+
+	require.Equal(t, ctorConfig{DSN: "localhost"}, app.Module.Ref("ctorConfig"))
+	require.NotNil(t, app.Module.Ref("named"))
+	require.NotNil(t, core.Inject[ctorNamed](app.Module))
+}
+
+func Test_ProvideConstructor_Error(t *testing.T) {
+	appModule := func() core.Module {
+		module := core.NewModule(core.NewModuleOptions{})
+		core.ProvideConstructor[ctorConfig](module, func() (ctorConfig, error) {
+			return ctorConfig{}, errors.New("missing DSN")
+		})
+		return module
+	}
+
+	require.PanicsWithError(t, "failed to create provider ctorConfig in module AppModule (inject: []): missing DSN", func() {
+		_ = core.CreateFactory(appModule)
+	})
+
+	appModule = func() core.Module {
+		module := core.NewModule(core.NewModuleOptions{})
+		core.ProvideConstructor[ctorService](module, func(cfg *ctorConfig) *ctorService {
+			return &ctorService{}
+		})
+		return module
+	}
+
+	require.PanicsWithError(t, "provider ctorService in module AppModule injects ctorConfig which is not provided: register it in the module or import a module exporting it", func() {
+		_ = core.CreateFactory(appModule)
+	})
+
+	require.PanicsWithValue(t, "constructor of core_test.ctorService must return *core_test.ctorService or core_test.ctorService, optionally with an error, got func() *core_test.ctorConfig", func() {
+		module := core.NewModule(core.NewModuleOptions{})
+		core.ProvideConstructor[ctorService](module, func() *ctorConfig {
+			return nil
+		})
+	})
+}
+
+func Test_ProvideConstructor_Scope(t *testing.T) {
+	var count int
+	counter := func(module core.Module) core.Provider {
+		return core.ProvideConstructor[ctorCounter](module, func() *ctorCounter {
+			count++
+			return &ctorCounter{N: count}
+		}, core.ConstructorOptions{Scope: core.Transient})
+	}
+	tenant := func(module core.Module) core.Provider {
+		return core.ProvideConstructor[ctorTenant](module, func(req *http.Request) *ctorTenant {
+			return &ctorTenant{ID: req.Header.Get("x-tenant")}
+		})
+	}
+
+	controller := func(module core.Module) core.Controller {
+		ctrl := module.NewController("test")
+		ctrl.Get("", func(ctx core.Ctx) error {
+			tenant := ctrl.Ref("ctorTenant", ctx).(*ctorTenant)
+			return ctx.JSON(core.Map{"data": tenant.ID})
+		})
+		return ctrl
+	}
+
+	appModule := func() core.Module {
+		return core.NewModule(core.NewModuleOptions{
+			Providers:   []core.Providers{counter, tenant},
+			Controllers: []core.Controllers{controller},
+		})
+	}
+
+	app := core.CreateFactory(appModule)
+	first := app.Module.Ref("ctorCounter").(*ctorCounter)
+	second := app.Module.Ref("ctorCounter").(*ctorCounter)
+	require.NotEqual(t, first.N, second.N)
+
+	testServer := httptest.NewServer(app.PrepareBeforeListen())
+	defer testServer.Close()
+
+	req, err := http.NewRequest("GET", testServer.URL+"/test", nil)
+	require.Nil(t, err)
+	req.Header.Set("x-tenant", "abc")
+	resp, err := testServer.Client().Do(req)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+}
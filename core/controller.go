@@ -44,6 +44,9 @@ type DynamicController struct {
 	middlewares []Middleware
 	// Use for apply middlewares for all routes
 	globalMiddlewares []Middleware
+	// Number of guards of the next route and of all routes
+	guards       int
+	globalGuards int
 	// Parent module for this controller
 	module            *DynamicModule
 	globalInterceptor Interceptor
@@ -59,6 +62,7 @@ func (module *DynamicModule) NewController(name string) Controller {
 	return &DynamicController{
 		name:              strings.ToLower(name),
 		globalMiddlewares: module.Middlewares,
+		globalGuards:      module.guards,
 		globalInterceptor: module.interceptor,
 		Dtos:              []PipeDto{},
 		module:            module,
@@ -104,6 +108,8 @@ func (c *DynamicController) Pipe(dtos ...PipeDto) Controller {
 func (c *DynamicController) Registry() Controller {
 	c.globalMiddlewares = append(c.globalMiddlewares, c.middlewares...)
 	c.middlewares = []Middleware{}
+	c.globalGuards += c.guards
+	c.guards = 0
 	c.globalMetadata = append(c.globalMetadata, c.metadata...)
 	c.metadata = []*Metadata{}
 	if c.interceptor != nil {
@@ -156,6 +162,7 @@ func (c *DynamicController) Handler(path string, handler http.Handler) {
 		Metadata:    append(c.globalMetadata, c.metadata...),
 		Dtos:        c.Dtos,
 		Version:     c.version,
+		guards:      c.globalGuards + c.guards,
 		interceptor: c.interceptor,
 		httpHandler: handler,
 	}
@@ -186,6 +193,7 @@ func (c *DynamicController) registry(method string, path string, handler Handler
 		Handler:     handler,
 		Dtos:        c.Dtos,
 		Version:     c.version,
+		guards:      c.globalGuards + c.guards,
 	}
 	if c.interceptor != nil {
 		router.interceptor = c.interceptor
@@ -205,6 +213,7 @@ func (c *DynamicController) registry(method string, path string, handler Handler
 // of the sub-controller are not executed twice.
 func (c *DynamicController) free() {
 	c.middlewares = []Middleware{}
+	c.guards = 0
 	c.Dtos = nil
 	c.interceptor = nil
 	c.metadata = []*Metadata{}
@@ -17,7 +17,8 @@ import (
 // structs.
 //
 // If the given interface{} is not a slice, the function will return a [][]string
-// with only the given headers.
+// with only the given headers. The structs may be given by pointer, their
+// unexported fields and those tagged `csv:"-"` are skipped.
 func ParseCsv(data interface{}, headers []string) [][]string {
 	parseBody := [][]string{}
 
@@ -33,11 +34,17 @@ func ParseCsv(data interface{}, headers []string) [][]string {
 	arrVal := reflect.ValueOf(data)
 	if arrVal.IsValid() {
 		for i := 0; i < arrVal.Len(); i++ {
-			item := arrVal.Index(i).Interface()
-			ctItem := reflect.ValueOf(item).Elem()
+			ctItem := reflect.Indirect(arrVal.Index(i))
+			if ctItem.Kind() != reflect.Struct {
+				continue
+			}
 			row := []string{}
 
 			for i := 0; i < ctItem.NumField(); i++ {
+				field := ctItem.Type().Field(i)
+				if !field.IsExported() || field.Tag.Get("csv") == "-" {
+					continue
+				}
 				value := ctItem.Field(i).Interface()
 				valStr := fmt.Sprintf("%v", value)
 
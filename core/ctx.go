@@ -15,6 +15,7 @@ import (
 
 	"github.com/tinh-tinh/tinhtinh/v2/common/exception"
 	"github.com/tinh-tinh/tinhtinh/v2/middleware/cookie"
+	"github.com/tinh-tinh/tinhtinh/v2/middleware/requestid"
 	"github.com/tinh-tinh/tinhtinh/v2/middleware/storage"
 )
 
@@ -41,6 +42,7 @@ type Ctx interface {
 	QueryBool(key string, defaultVal ...bool) bool
 	SetCallHandler(call CallHandler)
 	JSON(data any) error
+	Send(data any) error
 	Get(key interface{}) interface{}
 	Set(key interface{}, val interface{})
 	Next() error
@@ -58,8 +60,10 @@ type Ctx interface {
 	XML(data any) error
 	Render(name string, bind Map, layouts ...string) error
 	StreamableFile(filePath string, opts ...StreamableFileOptions) error
+	StreamableContent(name string, content io.ReadSeeker, opts ...StreamableFileOptions) error
 	Scan(val any) error
 	SendString(str string) error
+	RequestID() string
 }
 
 // Custom ResponseWriter to prevent duplicate WriteHeader calls
@@ -179,14 +183,27 @@ func (ctx *DefaultCtx) SignedCookie(key string, val ...string) (string, error) {
 	return value, nil
 }
 
-// BodyParser is a helper to parse the request body into a given interface
+// BodyParser is a helper to parse the request body into a given interface.
+// The body is decoded by the serializer of its Content-Type. When the
+// serializer cannot decode into the payload, such as a text/plain body into a
+// struct, the Decoder of the App is used.
 func (ctx *DefaultCtx) BodyParser(payload interface{}) error {
 	body, err := io.ReadAll(ctx.r.Body)
 	if err != nil {
 		return err
 	}
 
-	err = ctx.app.decoder(body, payload)
+	decode, err := ctx.app.decoderOf(ctx.r.Header.Get("Content-Type"))
+	if err != nil {
+		return err
+	}
+	err = decode(body, payload)
+	if errors.Is(err, errors.ErrUnsupported) {
+		if ctx.app.decoder(body, payload) == nil {
+			return nil
+		}
+		return exception.UnsupportedMediaType(err.Error())
+	}
 	if err != nil {
 		return err
 	}
@@ -395,9 +412,12 @@ func (ctx *DefaultCtx) SetCallHandler(call CallHandler) {
 // The Content-Type of the response is set to "application/json".
 //
 // If there is an error while encoding the data, it panics.
+//
+// When the entity tags are enabled with AppOptions.ETag or the ETAG metadata,
+// the response gets an ETag header and a client already having it is answered
+// 304 Not Modified.
 func (ctx *DefaultCtx) JSON(data any) error {
 	ctx.w.Header().Set("Content-Type", "application/json")
-	ctx.w.WriteHeader(ctx.statusCode)
 
 	if ctx.callHandler != nil {
 		data = ctx.callHandler(data)
@@ -407,17 +427,11 @@ func (ctx *DefaultCtx) JSON(data any) error {
 		return err
 	}
 
-	_, err = ctx.w.Write(res)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return ctx.write(res)
 }
 
 func (ctx *DefaultCtx) XML(data any) error {
 	ctx.w.Header().Set("Content-Type", "application/xml")
-	ctx.w.WriteHeader(ctx.statusCode)
 
 	if ctx.callHandler != nil {
 		data = ctx.callHandler(data)
@@ -427,12 +441,7 @@ func (ctx *DefaultCtx) XML(data any) error {
 		return err
 	}
 
-	_, err = ctx.w.Write(res)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return ctx.write(res)
 }
 
 func (ctx *DefaultCtx) Render(name string, bind Map, layouts ...string) error {
@@ -517,12 +526,15 @@ func NewCtx(app *App) *DefaultCtx {
 }
 
 // SetCtx sets the http.ResponseWriter and *http.Request fields of the Ctx
-// to the given values.
+// to the given values. The status code and call handler left by a previous
+// request of the pooled Ctx are reset.
 //
 // It returns nothing.
 func (ctx *DefaultCtx) SetCtx(w http.ResponseWriter, r *http.Request) {
 	ctx.w = &SafeResponseWriter{ResponseWriter: w}
 	ctx.r = r
+	ctx.statusCode = http.StatusOK
+	ctx.callHandler = nil
 }
 
 // SetHandler sets the http.Handler field of the Ctx to the given value.
@@ -540,7 +552,11 @@ func (ctx *DefaultCtx) SetHandler(h http.Handler) {
 // returns without doing anything else.
 //
 // The returned http.HandlerFunc can be used as a handler for an HTTP request.
+// The handler runs as the handler stage of the request, named after the
+// pattern of the route.
 func ParseCtx(app *App, router *Router) http.Handler {
+	route := app.parseRouter(router)
+	name := route.GetPath()
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx := app.pool.Get().(*DefaultCtx)
 		defer app.pool.Put(ctx)
@@ -558,7 +574,12 @@ func ParseCtx(app *App, router *Router) http.Handler {
 				return
 			}
 		}()
-		err = router.Handler(ctx)
+		err = runStage(ctx, StageHandler, name, func() error {
+			if err := ctx.checkPreconditions(); err != nil {
+				return err
+			}
+			return router.Handler(ctx)
+		})
 		if err != nil {
 			app.errorHandler(err, ctx)
 			return
@@ -622,6 +643,12 @@ func (ctx *DefaultCtx) Ref(name Provide) interface{} {
 	return ctx.app.Module.Ref(name, ctx)
 }
 
+// RequestID returns the ID of the request set by the requestid middleware,
+// or an empty string when the middleware is not used.
+func (ctx *DefaultCtx) RequestID() string {
+	return requestid.FromContext(ctx.r.Context())
+}
+
 func (ctx *DefaultCtx) SendString(str string) error {
 	ctx.w.WriteHeader(ctx.statusCode)
 	ctx.w.Write([]byte(str))
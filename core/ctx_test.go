@@ -15,6 +15,7 @@ import (
 	"github.com/tinh-tinh/tinhtinh/v2/common"
 	"github.com/tinh-tinh/tinhtinh/v2/core"
 	"github.com/tinh-tinh/tinhtinh/v2/middleware/cookie"
+	"github.com/tinh-tinh/tinhtinh/v2/middleware/requestid"
 	"github.com/tinh-tinh/tinhtinh/v2/middleware/session"
 )
 
@@ -860,6 +861,49 @@ func Test_Ctx_SendString(t *testing.T) {
 	require.Equal(t, "created", string(data2))
 }
 
+func Test_Ctx_RequestID(t *testing.T) {
+	controller := func(module core.Module) core.Controller {
+		ctrl := module.NewController("test")
+
+		ctrl.Get("", func(ctx core.Ctx) error {
+			return ctx.SendString(ctx.RequestID())
+		})
+
+		return ctrl
+	}
+
+	module := func() core.Module {
+		return core.NewModule(core.NewModuleOptions{
+			Controllers: []core.Controllers{controller},
+		})
+	}
+
+	app := core.CreateFactory(module)
+	app.SetGlobalPrefix("/api")
+	app.Use(requestid.Handler(requestid.Options{}))
+
+	testServer := httptest.NewServer(app.PrepareBeforeListen())
+	defer testServer.Close()
+
+	req, err := http.NewRequest(http.MethodGet, testServer.URL+"/api/test", nil)
+	require.Nil(t, err)
+	req.Header.Set("X-Request-Id", "abc-123")
+	resp, err := testServer.Client().Do(req)
+	require.Nil(t, err)
+
+	data, err := io.ReadAll(resp.Body)
+	require.Nil(t, err)
+	require.Equal(t, "abc-123", string(data))
+
+	resp, err = testServer.Client().Get(testServer.URL + "/api/test")
+	require.Nil(t, err)
+
+	data, err = io.ReadAll(resp.Body)
+	require.Nil(t, err)
+	require.Len(t, string(data), 36)
+	require.Equal(t, string(data), resp.Header.Get("X-Request-Id"))
+}
+
 func Test_Ctx_Status(t *testing.T) {
 	controller := func(module core.Module) core.Controller {
 		ctrl := module.NewController("test")
@@ -893,6 +937,30 @@ func Test_Ctx_Status(t *testing.T) {
 	require.Equal(t, http.StatusNotFound, resp.StatusCode)
 }
 
+func Test_Ctx_SetCtx_Reset(t *testing.T) {
+	app := core.CreateFactory(func() core.Module {
+		return core.NewModule(core.NewModuleOptions{})
+	})
+	ctx := core.NewCtx(app)
+
+	// A first request leaves a status and a call handler on the Ctx.
+	ctx.SetCtx(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	ctx.SetCallHandler(func(data interface{}) interface{} {
+		return core.Map{"wrapped": data}
+	})
+	ctx.Status(http.StatusNotFound)
+
+	// The next request reusing the Ctx starts from a clean state.
+	rec := httptest.NewRecorder()
+	ctx.SetCtx(rec, httptest.NewRequest("GET", "/", nil))
+	require.Nil(t, ctx.JSON(core.Map{"data": "ok"}))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var res core.Map
+	require.Nil(t, json.NewDecoder(rec.Body).Decode(&res))
+	require.Equal(t, core.Map{"data": "ok"}, res)
+}
+
 func Test_QueryParser(t *testing.T) {
 	type QueryData struct {
 		Age    uint    `query:"age"`
@@ -0,0 +1,217 @@
+package core
+
+import "slices"
+
+// DISCOVERY resolves to the DiscoveryService of the module tree. It can be
+// injected in any module.
+const DISCOVERY Provide = "DISCOVERY"
+
+// ModuleInfo describes a module of the application.
+type ModuleInfo struct {
+	Name     string
+	Scope    Scope
+	Global   bool
+	Disabled bool
+	Parent   string
+	Imports  []string
+}
+
+// ProviderInfo describes a provider of the application. Value is only set
+// for singletons: request-scoped and transient providers have no single
+// value.
+type ProviderInfo struct {
+	Name   Provide
+	Module string
+	Scope  Scope
+	Status ProvideStatus
+	Multi  bool
+	Tags   []string
+	Value  interface{}
+}
+
+// RouteInfo describes a route of the application.
+type RouteInfo struct {
+	Module     string
+	Controller string
+	Method     string
+	Path       string
+	Version    string
+	Metadata   []*Metadata
+	Dtos       []PipeDto
+	Guards     int
+	Handler    Handler
+}
+
+// HandlerInfo describes a handler registered outside of the HTTP routes,
+// such as the event and RPC handlers of the microservices package. Source is
+// the name of the provider holding the handler.
+type HandlerInfo struct {
+	Kind        string
+	Name        string
+	Middlewares int
+	Source      Provide
+	Handler     interface{}
+}
+
+// HandlerSource is implemented by providers holding handlers, so they are
+// listed by DiscoveryService.Handlers.
+type HandlerSource interface {
+	DiscoverHandlers() []HandlerInfo
+}
+
+// DiscoveryService lists the modules, providers, routes and handlers of the
+// application, so extension modules can find the handlers decorated with
+// their metadata, typically in OnApplicationBootstrap.
+type DiscoveryService struct {
+	root *DynamicModule
+}
+
+// Modules returns the modules of the application, the root module first.
+func (d *DiscoveryService) Modules() []ModuleInfo {
+	var modules []ModuleInfo
+	d.walk(func(m *DynamicModule) {
+		info := ModuleInfo{
+			Name:     m.Name,
+			Scope:    m.Scope,
+			Global:   m.global,
+			Disabled: m.disabled,
+		}
+		if m.parent != nil {
+			info.Parent = m.parent.Name
+		}
+		for _, sub := range m.SubModules {
+			info.Imports = append(info.Imports, sub.Name)
+		}
+		modules = append(modules, info)
+	})
+	return modules
+}
+
+// Providers returns every provider of the application once, with the module
+// registering it.
+func (d *DiscoveryService) Providers() []ProviderInfo {
+	tags := make(map[Provider][]string)
+	for _, t := range d.root.tagged {
+		tags[t.provider] = t.tags
+	}
+
+	var providers []ProviderInfo
+	for _, p := range d.providers() {
+		info := ProviderInfo{
+			Name:   p.GetName(),
+			Scope:  p.GetScope(),
+			Status: p.GetStatus(),
+			Multi:  d.root.multi[p],
+			Tags:   tags[p],
+		}
+		owner := d.root.ownerOf(p, d.root)
+		info.Module = owner.Name
+		if p.GetScope() != Request && p.GetScope() != Transient {
+			owner.resolvePending(p)
+			info.Value = p.GetValue()
+		}
+		providers = append(providers, info)
+	}
+	return providers
+}
+
+// Routes returns the routes of the application. Before the routes are
+// registered by PrepareBeforeListen, Path is the path given to the
+// controller; afterwards it is the full pattern served by the App.
+func (d *DiscoveryService) Routes() []RouteInfo {
+	owners := make(map[*Router]*DynamicModule)
+	d.walk(func(m *DynamicModule) {
+		for _, r := range m.SnapshotRouters {
+			owners[r] = m
+		}
+	})
+
+	routers := d.root.Routers
+	var app *App
+	if d.root.app != nil && len(d.root.app.routers) > 0 {
+		app = d.root.app
+		routers = app.routers
+	}
+
+	routes := make([]RouteInfo, 0, len(routers))
+	for _, r := range routers {
+		owner := d.root
+		if m, ok := owners[r]; ok {
+			owner = m
+		}
+		path := r.Path
+		if app != nil {
+			path = app.parseRouter(r).Path
+		}
+		routes = append(routes, RouteInfo{
+			Module:     owner.Name,
+			Controller: r.Name,
+			Method:     r.Method,
+			Path:       path,
+			Version:    r.Version,
+			Metadata:   r.Metadata,
+			Dtos:       r.Dtos,
+			Guards:     r.guards,
+			Handler:    r.Handler,
+		})
+	}
+	return routes
+}
+
+// RoutesWithMetadata returns the routes having a metadata with the key.
+func (d *DiscoveryService) RoutesWithMetadata(key string) []RouteInfo {
+	var routes []RouteInfo
+	for _, r := range d.Routes() {
+		if slices.ContainsFunc(r.Metadata, func(m *Metadata) bool { return m.Key == key }) {
+			routes = append(routes, r)
+		}
+	}
+	return routes
+}
+
+// Handlers returns the handlers of the singleton providers implementing
+// HandlerSource.
+func (d *DiscoveryService) Handlers() []HandlerInfo {
+	var handlers []HandlerInfo
+	for _, p := range d.providers() {
+		if p.GetScope() == Request || p.GetScope() == Transient {
+			continue
+		}
+		source, ok := p.GetValue().(HandlerSource)
+		if !ok {
+			continue
+		}
+		for _, h := range source.DiscoverHandlers() {
+			h.Source = p.GetName()
+			handlers = append(handlers, h)
+		}
+	}
+	return handlers
+}
+
+// walk calls the function on every module, parents before their imports.
+func (d *DiscoveryService) walk(fn func(m *DynamicModule)) {
+	var walk func(m *DynamicModule)
+	walk = func(m *DynamicModule) {
+		fn(m)
+		for _, sub := range m.SubModules {
+			walk(sub)
+		}
+	}
+	walk(d.root)
+}
+
+// providers returns the providers of the tree once each, in module order.
+func (d *DiscoveryService) providers() []Provider {
+	seen := make(map[Provider]bool)
+	var providers []Provider
+	d.walk(func(m *DynamicModule) {
+		for _, p := range m.DataProviders {
+			if !seen[p] {
+				seen[p] = true
+				providers = append(providers, p)
+			}
+		}
+	})
+	return providers
+}
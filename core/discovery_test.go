@@ -0,0 +1,101 @@
+package core_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tinh-tinh/tinhtinh/v2/core"
+)
+
+const scheduled = "scheduled"
+
+type discoveryConsumer struct {
+	routes []core.RouteInfo
+}
+
+func Test_DiscoveryService(t *testing.T) {
+	var atBootstrap []core.RouteInfo
+
+	jobController := func(module core.Module) core.Controller {
+		ctrl := module.NewController("jobs")
+		ctrl.Guard(func(ctx core.Ctx) bool { return true })
+		ctrl.Metadata(core.SetMetadata(scheduled, "* * * * *")).Guard(func(ctx core.Ctx) bool { return true }).Get("run", func(ctx core.Ctx) error {
+			return ctx.JSON(core.Map{"data": "ok"})
+		})
+		ctrl.Version("2").Post("", func(ctx core.Ctx) error {
+			return ctx.JSON(core.Map{"data": "ok"})
+		})
+		return ctrl
+	}
+
+	jobModule := func(module core.Module) core.Module {
+		job := module.New(core.NewModuleOptions{
+			Guards:      []core.Guard{func(ctx core.Ctx) bool { return true }},
+			Controllers: []core.Controllers{jobController},
+		})
+		job.NewProvider(core.ProviderOptions{
+			Name:  "job",
+			Tags:  []string{"worker"},
+			Value: "job",
+		})
+		return job
+	}
+
+	appModule := func() core.Module {
+		module := core.NewModule(core.NewModuleOptions{
+			Imports: []core.Modules{jobModule},
+		})
+		module.NewProvider(core.ProviderOptions{
+			Name: "scheduler",
+			Factory: func(param ...interface{}) interface{} {
+				discovery := param[0].(*core.DiscoveryService)
+				atBootstrap = discovery.RoutesWithMetadata(scheduled)
+				return &discoveryConsumer{routes: atBootstrap}
+			},
+			Inject: []core.Provide{core.DISCOVERY},
+		})
+		return module
+	}
+
+	app := core.CreateFactory(appModule)
+	require.Len(t, atBootstrap, 1)
+	require.Equal(t, "run", atBootstrap[0].Path)
+	require.Equal(t, 3, atBootstrap[0].Guards)
+
+	discovery := app.Module.Ref(core.DISCOVERY).(*core.DiscoveryService)
+
+	modules := discovery.Modules()
+	require.Len(t, modules, 2)
+	require.Equal(t, "AppModule", modules[0].Name)
+	require.Equal(t, []string{modules[1].Name}, modules[0].Imports)
+	require.Equal(t, "AppModule", modules[1].Parent)
+
+	providers := discovery.Providers()
+	require.Len(t, providers, 2)
+	require.Equal(t, core.Provide("scheduler"), providers[0].Name)
+	require.Equal(t, "AppModule", providers[0].Module)
+	require.IsType(t, &discoveryConsumer{}, providers[0].Value)
+	require.Equal(t, core.Provide("job"), providers[1].Name)
+	require.Equal(t, modules[1].Name, providers[1].Module)
+	require.Equal(t, []string{"worker"}, providers[1].Tags)
+	require.Equal(t, core.PRIVATE, providers[1].Status)
+
+	app.SetGlobalPrefix("api")
+	app.PrepareBeforeListen()
+
+	routes := discovery.Routes()
+	require.Len(t, routes, 2)
+	require.Equal(t, modules[1].Name, routes[0].Module)
+	require.Equal(t, "jobs", routes[0].Controller)
+	require.Equal(t, "GET", routes[0].Method)
+	require.Equal(t, "/api/jobs/run", routes[0].Path)
+	require.Equal(t, 3, routes[0].Guards)
+	require.NotNil(t, routes[0].Handler)
+	require.Equal(t, "POST", routes[1].Method)
+	require.Equal(t, "2", routes[1].Version)
+	// Controller guards only apply to the next route.
+	require.Equal(t, 1, routes[1].Guards)
+
+	require.Len(t, discovery.RoutesWithMetadata(scheduled), 1)
+	require.Empty(t, discovery.Handlers())
+}
@@ -0,0 +1,205 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/tinh-tinh/tinhtinh/v2/common/exception"
+)
+
+// ETAG holds the ETagMode of a route, overriding the ETag option of the App.
+const ETAG = "ETAG"
+
+// PRECONDITIONS holds the ResourceState of a route, overriding the
+// Preconditions option of the App.
+const PRECONDITIONS = "PRECONDITIONS"
+
+// ETagMode selects the entity tags generated for the responses of Ctx.JSON
+// and Ctx.XML.
+type ETagMode string
+
+const (
+	// ETagDisabled generates no entity tag. It is the default of the App.
+	ETagDisabled ETagMode = "disabled"
+	// ETagStrong generates strong entity tags, for byte-identical bodies.
+	ETagStrong ETagMode = "strong"
+	// ETagWeak generates weak entity tags, for semantically equivalent
+	// bodies.
+	ETagWeak ETagMode = "weak"
+)
+
+// ResourceState returns the current entity tag and last modification of the
+// resource targeted by the request, empty and zero when it does not exist or
+// they are unknown.
+type ResourceState func(ctx Ctx) (etag string, lastModified time.Time, err error)
+
+// Preconditions makes the PUT, PATCH and DELETE requests of a route evaluate
+// their If-Match and If-Unmodified-Since headers against the state of the
+// resource before the handler runs (see CheckPreconditions).
+//
+//	ctrl.Metadata(core.Preconditions(func(ctx core.Ctx) (string, time.Time, error) {
+//		order := service.Find(ctx.Path("id"))
+//		return order.ETag(), order.UpdatedAt, nil
+//	})).Put(":id", handler)
+func Preconditions(state ResourceState) *Metadata {
+	return SetMetadata(PRECONDITIONS, state)
+}
+
+// GenerateETag returns the entity tag of the body, a hash of its content.
+func GenerateETag(body []byte, weak bool) string {
+	sum := sha256.Sum256(body)
+	tag := `"` + base64.RawURLEncoding.EncodeToString(sum[:16]) + `"`
+	if weak {
+		return "W/" + tag
+	}
+	return tag
+}
+
+// etagMode returns the ETagMode of the route of the context.
+func (ctx *DefaultCtx) etagMode() ETagMode {
+	if mode, ok := ctx.GetMetadata(ETAG).(ETagMode); ok && mode != "" {
+		return mode
+	}
+	if ctx.app.etag != "" {
+		return ctx.app.etag
+	}
+	return ETagDisabled
+}
+
+// write writes the body with the status of the context. When the entity tags
+// are enabled, the successful responses of GET and HEAD get one, unless the
+// handler set its own, and are answered 304 Not Modified when the client
+// already has them, as told by If-None-Match or If-Modified-Since.
+func (ctx *DefaultCtx) write(body []byte) error {
+	mode := ctx.etagMode()
+	method := ctx.r.Method
+	if mode != ETagDisabled && ctx.statusCode == http.StatusOK && (method == http.MethodGet || method == http.MethodHead) {
+		header := ctx.w.Header()
+		if header.Get("ETag") == "" {
+			header.Set("ETag", GenerateETag(body, mode == ETagWeak))
+		}
+		if NotModified(ctx.r, header) {
+			header.Del("Content-Type")
+			header.Del("Content-Length")
+			ctx.w.WriteHeader(http.StatusNotModified)
+			return nil
+		}
+	}
+
+	ctx.w.WriteHeader(ctx.statusCode)
+	_, err := ctx.w.Write(body)
+	return err
+}
+
+// NotModified reports whether the response with the header is fresh for the
+// client, which can be answered 304 Not Modified. The If-Modified-Since header
+// is only evaluated when the request has no If-None-Match header.
+func NotModified(r *http.Request, header http.Header) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		return matchETag(inm, header.Get("ETag"), false)
+	}
+	ims, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	modified, err := http.ParseTime(header.Get("Last-Modified"))
+	if err != nil {
+		return false
+	}
+	return !modified.After(ims)
+}
+
+// CheckPreconditions evaluates the If-Match and If-Unmodified-Since headers of
+// a PUT, PATCH or DELETE request against the current state of the resource,
+// before it is modified. The etag is the current entity tag of the resource,
+// empty when it does not exist, and lastModified its last modification, zero
+// when unknown. It returns a 412 Precondition Failed error when the client
+// modifies a state it has not seen, and nil otherwise.
+//
+// The routes with a ResourceState, given by AppOptions.Preconditions or the
+// PRECONDITIONS metadata, are checked before their handler. The others call it
+// from their handler:
+//
+//	order := service.Find(ctx.Path("id"))
+//	if err := core.CheckPreconditions(ctx, order.ETag(), order.UpdatedAt); err != nil {
+//		return err
+//	}
+func CheckPreconditions(ctx Ctx, etag string, lastModified time.Time) error {
+	r := ctx.Req()
+	switch r.Method {
+	case http.MethodPut, http.MethodPatch, http.MethodDelete:
+	default:
+		return nil
+	}
+
+	if im := r.Header.Get("If-Match"); im != "" {
+		if !matchETag(im, etag, true) {
+			return exception.PreconditionFailed("the resource does not match If-Match")
+		}
+		return nil
+	}
+	ius, err := http.ParseTime(r.Header.Get("If-Unmodified-Since"))
+	if err != nil || lastModified.IsZero() {
+		return nil
+	}
+	if lastModified.Truncate(time.Second).After(ius) {
+		return exception.PreconditionFailed("the resource was modified since If-Unmodified-Since")
+	}
+	return nil
+}
+
+// checkPreconditions evaluates the conditional headers of the request with
+// the ResourceState of the route. The state is only read when the request has
+// preconditions.
+func (ctx *DefaultCtx) checkPreconditions() error {
+	switch ctx.r.Method {
+	case http.MethodPut, http.MethodPatch, http.MethodDelete:
+	default:
+		return nil
+	}
+	if ctx.r.Header.Get("If-Match") == "" && ctx.r.Header.Get("If-Unmodified-Since") == "" {
+		return nil
+	}
+
+	state, _ := ctx.GetMetadata(PRECONDITIONS).(ResourceState)
+	if state == nil {
+		state = ctx.app.preconditions
+	}
+	if state == nil {
+		return nil
+	}
+	etag, lastModified, err := state(ctx)
+	if err != nil {
+		return err
+	}
+	return CheckPreconditions(ctx, etag, lastModified)
+}
+
+// matchETag reports whether the list of entity tags of a conditional header
+// matches the tag. "*" matches any tag. The strong comparison never matches
+// weak tags, the weak comparison ignores the W/ prefixes.
+func matchETag(list string, tag string, strong bool) bool {
+	if tag == "" {
+		return false
+	}
+	if strings.TrimSpace(list) == "*" {
+		return true
+	}
+	if strong && strings.HasPrefix(tag, "W/") {
+		return false
+	}
+	tag = strings.TrimPrefix(tag, "W/")
+	for _, candidate := range strings.Split(list, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if strong && strings.HasPrefix(candidate, "W/") {
+			continue
+		}
+		if strings.TrimPrefix(candidate, "W/") == tag {
+			return true
+		}
+	}
+	return false
+}
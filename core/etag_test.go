@@ -0,0 +1,290 @@
+package core_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tinh-tinh/tinhtinh/v2/common/exception"
+	"github.com/tinh-tinh/tinhtinh/v2/core"
+)
+
+type etagBody struct {
+	Data string `xml:"data"`
+}
+
+func Test_ETag(t *testing.T) {
+	modified := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	controller := func(module core.Module) core.Controller {
+		ctrl := module.NewController("test")
+
+		ctrl.Get("", func(ctx core.Ctx) error {
+			return ctx.JSON(core.Map{"data": "ok"})
+		})
+
+		ctrl.Get("xml", func(ctx core.Ctx) error {
+			return ctx.XML(etagBody{Data: "ok"})
+		})
+
+		ctrl.Metadata(core.SetMetadata(core.ETAG, core.ETagWeak)).Get("weak", func(ctx core.Ctx) error {
+			return ctx.JSON(core.Map{"data": "ok"})
+		})
+
+		ctrl.Metadata(core.SetMetadata(core.ETAG, core.ETagDisabled)).Get("disabled", func(ctx core.Ctx) error {
+			return ctx.JSON(core.Map{"data": "ok"})
+		})
+
+		ctrl.Get("modified", func(ctx core.Ctx) error {
+			ctx.Res().Header().Set("Last-Modified", modified.Format(http.TimeFormat))
+			return ctx.JSON(core.Map{"data": "ok"})
+		})
+
+		ctrl.Get("created", func(ctx core.Ctx) error {
+			return ctx.Status(http.StatusCreated).JSON(core.Map{"data": "ok"})
+		})
+
+		return ctrl
+	}
+
+	module := func() core.Module {
+		return core.NewModule(core.NewModuleOptions{
+			Controllers: []core.Controllers{controller},
+		})
+	}
+
+	app := core.CreateFactory(module, core.AppOptions{ETag: core.ETagStrong})
+	app.SetGlobalPrefix("/api")
+
+	testServer := httptest.NewServer(app.PrepareBeforeListen())
+	defer testServer.Close()
+	testClient := testServer.Client()
+
+	get := func(path string, header ...string) *http.Response {
+		req, err := http.NewRequest(http.MethodGet, testServer.URL+path, nil)
+		require.Nil(t, err)
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		resp, err := testClient.Do(req)
+		require.Nil(t, err)
+		return resp
+	}
+
+	resp := get("/api/test")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	etag := resp.Header.Get("ETag")
+	require.Equal(t, core.GenerateETag([]byte(`{"data":"ok"}`), false), etag)
+	require.False(t, strings.HasPrefix(etag, "W/"))
+
+	resp = get("/api/test", "If-None-Match", etag)
+	require.Equal(t, http.StatusNotModified, resp.StatusCode)
+	require.Equal(t, etag, resp.Header.Get("ETag"))
+	data, err := io.ReadAll(resp.Body)
+	require.Nil(t, err)
+	require.Empty(t, data)
+
+	resp = get("/api/test", "If-None-Match", `"other", `+etag)
+	require.Equal(t, http.StatusNotModified, resp.StatusCode)
+
+	resp = get("/api/test", "If-None-Match", `"other"`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = get("/api/test/xml")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = get("/api/test/xml", "If-None-Match", resp.Header.Get("ETag"))
+	require.Equal(t, http.StatusNotModified, resp.StatusCode)
+
+	resp = get("/api/test/weak")
+	weak := resp.Header.Get("ETag")
+	require.True(t, strings.HasPrefix(weak, "W/"))
+	// If-None-Match uses the weak comparison.
+	resp = get("/api/test/weak", "If-None-Match", strings.TrimPrefix(weak, "W/"))
+	require.Equal(t, http.StatusNotModified, resp.StatusCode)
+
+	resp = get("/api/test/disabled")
+	require.Empty(t, resp.Header.Get("ETag"))
+	resp = get("/api/test/disabled", "If-None-Match", "*")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = get("/api/test/modified", "If-Modified-Since", modified.Format(http.TimeFormat))
+	require.Equal(t, http.StatusNotModified, resp.StatusCode)
+	resp = get("/api/test/modified", "If-Modified-Since", modified.Add(-time.Hour).Format(http.TimeFormat))
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	// If-None-Match takes precedence over If-Modified-Since.
+	resp = get("/api/test/modified", "If-None-Match", `"other"`, "If-Modified-Since", modified.Format(http.TimeFormat))
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = get("/api/test/created", "If-None-Match", "*")
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	require.Empty(t, resp.Header.Get("ETag"))
+}
+
+func Test_ETag_Disabled(t *testing.T) {
+	controller := func(module core.Module) core.Controller {
+		ctrl := module.NewController("test")
+
+		ctrl.Get("", func(ctx core.Ctx) error {
+			return ctx.JSON(core.Map{"data": "ok"})
+		})
+
+		ctrl.Metadata(core.SetMetadata(core.ETAG, core.ETagStrong)).Get("enabled", func(ctx core.Ctx) error {
+			return ctx.JSON(core.Map{"data": "ok"})
+		})
+
+		return ctrl
+	}
+
+	app := core.CreateFactory(func() core.Module {
+		return core.NewModule(core.NewModuleOptions{
+			Controllers: []core.Controllers{controller},
+		})
+	})
+
+	testServer := httptest.NewServer(app.PrepareBeforeListen())
+	defer testServer.Close()
+
+	resp, err := testServer.Client().Get(testServer.URL + "/test")
+	require.Nil(t, err)
+	require.Empty(t, resp.Header.Get("ETag"))
+
+	resp, err = testServer.Client().Get(testServer.URL + "/test/enabled")
+	require.Nil(t, err)
+	require.NotEmpty(t, resp.Header.Get("ETag"))
+}
+
+func Test_CheckPreconditions(t *testing.T) {
+	modified := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	etag := core.GenerateETag([]byte("v1"), false)
+
+	controller := func(module core.Module) core.Controller {
+		ctrl := module.NewController("test")
+
+		handler := func(ctx core.Ctx) error {
+			if err := core.CheckPreconditions(ctx, etag, modified); err != nil {
+				return err
+			}
+			return ctx.JSON(core.Map{"data": "updated"})
+		}
+		ctrl.Put("", handler)
+		ctrl.Patch("", handler)
+		ctrl.Delete("", handler)
+		ctrl.Post("", handler)
+
+		ctrl.Put("missing", func(ctx core.Ctx) error {
+			if err := core.CheckPreconditions(ctx, "", time.Time{}); err != nil {
+				return err
+			}
+			return ctx.JSON(core.Map{"data": "created"})
+		})
+
+		return ctrl
+	}
+
+	app := core.CreateFactory(func() core.Module {
+		return core.NewModule(core.NewModuleOptions{
+			Controllers: []core.Controllers{controller},
+		})
+	})
+
+	testServer := httptest.NewServer(app.PrepareBeforeListen())
+	defer testServer.Close()
+
+	do := func(method string, path string, header ...string) int {
+		req, err := http.NewRequest(method, testServer.URL+path, nil)
+		require.Nil(t, err)
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		resp, err := testServer.Client().Do(req)
+		require.Nil(t, err)
+		return resp.StatusCode
+	}
+
+	require.Equal(t, http.StatusOK, do(http.MethodPut, "/test"))
+	require.Equal(t, http.StatusOK, do(http.MethodPut, "/test", "If-Match", etag))
+	require.Equal(t, http.StatusOK, do(http.MethodPatch, "/test", "If-Match", `"old", `+etag))
+	require.Equal(t, http.StatusOK, do(http.MethodDelete, "/test", "If-Match", "*"))
+	require.Equal(t, http.StatusPreconditionFailed, do(http.MethodPut, "/test", "If-Match", `"old"`))
+	require.Equal(t, http.StatusPreconditionFailed, do(http.MethodPatch, "/test", "If-Match", "W/"+etag))
+	require.Equal(t, http.StatusPreconditionFailed, do(http.MethodDelete, "/test", "If-Match", `"old"`))
+
+	require.Equal(t, http.StatusOK, do(http.MethodPut, "/test", "If-Unmodified-Since", modified.Format(http.TimeFormat)))
+	require.Equal(t, http.StatusPreconditionFailed, do(http.MethodPut, "/test", "If-Unmodified-Since", modified.Add(-time.Hour).Format(http.TimeFormat)))
+
+	// Only the PUT, PATCH and DELETE requests are checked.
+	require.Equal(t, http.StatusOK, do(http.MethodPost, "/test", "If-Match", `"old"`))
+
+	require.Equal(t, http.StatusPreconditionFailed, do(http.MethodPut, "/test/missing", "If-Match", "*"))
+	require.Equal(t, http.StatusOK, do(http.MethodPut, "/test/missing"))
+}
+
+func Test_Preconditions(t *testing.T) {
+	modified := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	etag := core.GenerateETag([]byte("v1"), false)
+
+	var reads int
+	controller := func(module core.Module) core.Controller {
+		ctrl := module.NewController("test")
+
+		handler := func(ctx core.Ctx) error {
+			return ctx.JSON(core.Map{"data": "updated"})
+		}
+		ctrl.Put("", handler)
+		ctrl.Get("", handler)
+
+		ctrl.Metadata(core.Preconditions(func(ctx core.Ctx) (string, time.Time, error) {
+			return "", time.Time{}, nil
+		})).Put("missing", handler)
+
+		ctrl.Metadata(core.Preconditions(func(ctx core.Ctx) (string, time.Time, error) {
+			return "", time.Time{}, exception.NotFound("order not found")
+		})).Delete("failing", handler)
+
+		return ctrl
+	}
+
+	app := core.CreateFactory(func() core.Module {
+		return core.NewModule(core.NewModuleOptions{
+			Controllers: []core.Controllers{controller},
+		})
+	}, core.AppOptions{
+		Preconditions: func(ctx core.Ctx) (string, time.Time, error) {
+			reads++
+			return etag, modified, nil
+		},
+	})
+
+	testServer := httptest.NewServer(app.PrepareBeforeListen())
+	defer testServer.Close()
+
+	do := func(method string, path string, header ...string) int {
+		req, err := http.NewRequest(method, testServer.URL+path, nil)
+		require.Nil(t, err)
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		resp, err := testServer.Client().Do(req)
+		require.Nil(t, err)
+		return resp.StatusCode
+	}
+
+	// The state is only read for the conditional requests.
+	require.Equal(t, http.StatusOK, do(http.MethodPut, "/test"))
+	require.Equal(t, http.StatusOK, do(http.MethodGet, "/test", "If-Match", `"old"`))
+	require.Equal(t, 0, reads)
+
+	require.Equal(t, http.StatusOK, do(http.MethodPut, "/test", "If-Match", etag))
+	require.Equal(t, http.StatusPreconditionFailed, do(http.MethodPut, "/test", "If-Match", `"old"`))
+	require.Equal(t, http.StatusPreconditionFailed, do(http.MethodPut, "/test", "If-Unmodified-Since", modified.Add(-time.Hour).Format(http.TimeFormat)))
+	require.Equal(t, 3, reads)
+
+	// The metadata overrides the option of the App.
+	require.Equal(t, http.StatusPreconditionFailed, do(http.MethodPut, "/test/missing", "If-Match", "*"))
+	require.Equal(t, http.StatusNotFound, do(http.MethodDelete, "/test/failing", "If-Match", "*"))
+	require.Equal(t, 3, reads)
+}
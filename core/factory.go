@@ -0,0 +1,149 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// FactoryErr is a factory returning an error when the provider cannot be
+// created, for example when a connection cannot be opened.
+type FactoryErr func(param ...interface{}) (interface{}, error)
+
+// FactoryCtx is a factory receiving a context cancelled once the timeout of
+// the provider elapsed, for providers initialized asynchronously.
+//
+// The factory must return once the context is done: the creation fails at the
+// timeout whether the factory returned or not, and a value it returns later is
+// dropped, after being closed if it implements io.Closer.
+type FactoryCtx func(ctx context.Context, param ...interface{}) (interface{}, error)
+
+// DefaultFactoryTimeout is the time a FactoryCtx has to create its provider
+// when ProviderOptions.Timeout is not set.
+const DefaultFactoryTimeout = 30 * time.Second
+
+// resolver returns a function creating the value of the provider from the
+// error-returning factories of the options, or nil if none is set.
+func (opt ProviderOptions) resolver() func(param ...interface{}) (interface{}, error) {
+	var resolve func(param ...interface{}) (interface{}, error)
+	switch {
+	case opt.FactoryCtx != nil:
+		timeout := opt.Timeout
+		if timeout <= 0 {
+			timeout = DefaultFactoryTimeout
+		}
+		resolve = func(param ...interface{}) (interface{}, error) {
+			return callWithTimeout(opt.FactoryCtx, timeout, param...)
+		}
+	case opt.FactoryErr != nil:
+		resolve = opt.FactoryErr
+	default:
+		return nil
+	}
+
+	return func(param ...interface{}) (interface{}, error) {
+		value, err := resolve(param...)
+		if err == nil && value == nil {
+			err = errors.New("factory returned nil")
+		}
+		return value, err
+	}
+}
+
+type factoryResult struct {
+	value interface{}
+	err   error
+}
+
+// callWithTimeout calls the factory and gives up once the timeout elapsed,
+// even if the factory does not watch its context. The value returned after
+// the timeout is closed.
+func callWithTimeout(factory FactoryCtx, timeout time.Duration, param ...interface{}) (interface{}, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	done := make(chan factoryResult, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- factoryResult{err: fmt.Errorf("%v", r)}
+			}
+		}()
+		value, err := factory(ctx, param...)
+		done <- factoryResult{value: value, err: err}
+	}()
+
+	select {
+	case res := <-done:
+		return res.value, res.err
+	case <-ctx.Done():
+		go closeLate(done)
+		return nil, fmt.Errorf("timed out after %s", timeout)
+	}
+}
+
+// closeLate waits for the result of a factory which timed out and closes its
+// value, which nobody will use.
+func closeLate(done <-chan factoryResult) {
+	res := <-done
+	if closer, ok := res.value.(io.Closer); ok {
+		closer.Close()
+	}
+}
+
+// wrapFactory converts the error-returning factories of the options into a
+// Factory panicking with a message naming the provider, its module and its
+// injections when the provider cannot be created. It panics with the same
+// message when the options have neither a factory nor a value.
+func wrapFactory(module Module, opt ProviderOptions) ProviderOptions {
+	resolve := opt.resolver()
+	if resolve == nil {
+		if opt.Factory == nil && opt.Value == nil {
+			panic(providerError(module, opt, errors.New("no factory nor value")))
+		}
+		return opt
+	}
+
+	opt.Factory = func(param ...interface{}) interface{} {
+		value, err := resolve(param...)
+		if err != nil {
+			panic(providerError(module, opt, err))
+		}
+		return value
+	}
+	return opt
+}
+
+// providerError describes the failure of the provider with the chain of
+// modules importing it and the providers it injects.
+func providerError(module Module, opt ProviderOptions, err error) error {
+	inject := make([]string, 0, len(opt.Inject))
+	for _, p := range opt.Inject {
+		inject = append(inject, string(p))
+	}
+
+	return fmt.Errorf("failed to create provider %s in module %s (inject: [%s]): %w",
+		opt.Name, moduleChain(module), strings.Join(inject, ", "), err)
+}
+
+// moduleChain returns the names of the modules from the root to the module,
+// such as "AppModule -> UserModule".
+func moduleChain(module Module) string {
+	dynMod, ok := module.(*DynamicModule)
+	if !ok {
+		return "unknown"
+	}
+
+	var names []string
+	for m := dynMod; m != nil; m = m.parent {
+		name := m.Name
+		if name == "" {
+			name = "anonymous"
+		}
+		names = append([]string{name}, names...)
+	}
+	return strings.Join(names, " -> ")
+}
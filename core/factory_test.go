@@ -0,0 +1,252 @@
+package core_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tinh-tinh/tinhtinh/v2/core"
+)
+
+func panicMessage(fn func()) (msg string) {
+	defer func() {
+		if r := recover(); r != nil {
+			msg = fmt.Sprint(r)
+		}
+	}()
+	fn()
+	return ""
+}
+
+func Test_FactoryErr(t *testing.T) {
+	databaseModule := func(module core.Module) core.Module {
+		db := module.New(core.NewModuleOptions{})
+		db.NewProvider(core.ProviderOptions{
+			Name:  "config",
+			Value: "postgres://localhost",
+		})
+		db.NewProvider(core.ProviderOptions{
+			Name: "db",
+			FactoryErr: func(param ...interface{}) (interface{}, error) {
+				return nil, errors.New("connection refused")
+			},
+			Inject: []core.Provide{"config"},
+		})
+		return db
+	}
+
+	appModule := func() core.Module {
+		return core.NewModule(core.NewModuleOptions{
+			Imports: []core.Modules{databaseModule},
+		})
+	}
+
+	msg := panicMessage(func() {
+		_ = core.CreateFactory(appModule)
+	})
+	require.Contains(t, msg, "failed to create provider db in module AppModule -> ")
+	require.Contains(t, msg, "Test_FactoryErr.func1")
+	require.Contains(t, msg, "(inject: [config]): connection refused")
+}
+
+func Test_FactoryErr_Nil(t *testing.T) {
+	appModule := func() core.Module {
+		module := core.NewModule(core.NewModuleOptions{})
+		module.NewProvider(core.ProviderOptions{
+			Name: "db",
+			FactoryErr: func(param ...interface{}) (interface{}, error) {
+				return nil, nil
+			},
+		})
+		return module
+	}
+
+	require.PanicsWithError(t, "failed to create provider db in module AppModule (inject: []): factory returned nil", func() {
+		_ = core.CreateFactory(appModule)
+	})
+}
+
+func Test_Factory_Nil(t *testing.T) {
+	appModule := func() core.Module {
+		module := core.NewModule(core.NewModuleOptions{})
+		module.NewProvider(core.ProviderOptions{
+			Name:  "config",
+			Value: "config",
+		})
+		module.NewProvider(core.ProviderOptions{
+			Name: "db",
+			Factory: func(param ...interface{}) interface{} {
+				return nil
+			},
+			Inject: []core.Provide{"config"},
+		})
+		return module
+	}
+
+	require.PanicsWithError(t, "failed to create provider db in module AppModule (inject: [config]): factory returned nil", func() {
+		_ = core.CreateFactory(appModule)
+	})
+}
+
+func Test_FactoryErr_Value(t *testing.T) {
+	appModule := func() core.Module {
+		module := core.NewModule(core.NewModuleOptions{})
+		module.NewProvider(core.ProviderOptions{
+			Name: "db",
+			FactoryErr: func(param ...interface{}) (interface{}, error) {
+				return "connected", nil
+			},
+		})
+		module.NewProvider(core.ProviderOptions{
+			Name: "cache",
+			FactoryCtx: func(ctx context.Context, param ...interface{}) (interface{}, error) {
+				return "cache:" + param[0].(string), nil
+			},
+			Inject: []core.Provide{"db"},
+		})
+		return module
+	}
+
+	app := core.CreateFactory(appModule)
+	require.Equal(t, "connected", app.Module.Ref("db"))
+	require.Equal(t, "cache:connected", app.Module.Ref("cache"))
+}
+
+func Test_FactoryCtx_Timeout(t *testing.T) {
+	appModule := func() core.Module {
+		module := core.NewModule(core.NewModuleOptions{})
+		module.NewProvider(core.ProviderOptions{
+			Name:    "watching",
+			Timeout: 10 * time.Millisecond,
+			FactoryCtx: func(ctx context.Context, param ...interface{}) (interface{}, error) {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			},
+		})
+		return module
+	}
+
+	msg := panicMessage(func() {
+		_ = core.CreateFactory(appModule)
+	})
+	require.Contains(t, msg, "failed to create provider watching in module AppModule")
+
+	blocking := make(chan struct{})
+	defer close(blocking)
+	appModule = func() core.Module {
+		module := core.NewModule(core.NewModuleOptions{})
+		module.NewProvider(core.ProviderOptions{
+			Name:    "blocking",
+			Timeout: 10 * time.Millisecond,
+			FactoryCtx: func(ctx context.Context, param ...interface{}) (interface{}, error) {
+				<-blocking
+				return "late", nil
+			},
+		})
+		return module
+	}
+
+	require.PanicsWithError(t, "failed to create provider blocking in module AppModule (inject: []): timed out after 10ms", func() {
+		_ = core.CreateFactory(appModule)
+	})
+}
+
+type lateConn struct {
+	closed chan struct{}
+}
+
+func (c *lateConn) Close() error {
+	close(c.closed)
+	return nil
+}
+
+func Test_FactoryCtx_Late(t *testing.T) {
+	conn := &lateConn{closed: make(chan struct{})}
+	appModule := func() core.Module {
+		module := core.NewModule(core.NewModuleOptions{})
+		module.NewProvider(core.ProviderOptions{
+			Name:    "conn",
+			Timeout: 10 * time.Millisecond,
+			FactoryCtx: func(ctx context.Context, param ...interface{}) (interface{}, error) {
+				time.Sleep(30 * time.Millisecond)
+				return conn, nil
+			},
+		})
+		return module
+	}
+
+	require.PanicsWithError(t, "failed to create provider conn in module AppModule (inject: []): timed out after 10ms", func() {
+		_ = core.CreateFactory(appModule)
+	})
+	select {
+	case <-conn.closed:
+	case <-time.After(time.Second):
+		t.Fatal("the value returned after the timeout is not closed")
+	}
+}
+
+func Test_Provider_NoFactory(t *testing.T) {
+	appModule := func() core.Module {
+		module := core.NewModule(core.NewModuleOptions{})
+		module.NewProvider(core.ProviderOptions{
+			Name:   "db",
+			Inject: []core.Provide{"config"},
+		})
+		return module
+	}
+
+	require.PanicsWithError(t, "failed to create provider db in module AppModule (inject: [config]): no factory nor value", func() {
+		_ = core.CreateFactory(appModule)
+	})
+}
+
+func Test_FactoryErr_Request(t *testing.T) {
+	tenant := func(module core.Module) core.Provider {
+		return module.NewProvider(core.ProviderOptions{
+			Name: "tenant",
+			FactoryErr: func(param ...interface{}) (interface{}, error) {
+				id := param[0].(*http.Request).Header.Get("x-tenant")
+				if id == "" {
+					return nil, errors.New("missing tenant")
+				}
+				return id, nil
+			},
+			Inject: []core.Provide{core.REQUEST},
+		})
+	}
+
+	controller := func(module core.Module) core.Controller {
+		ctrl := module.NewController("test")
+		ctrl.Get("", func(ctx core.Ctx) error {
+			return ctx.JSON(core.Map{"data": ctrl.Ref("tenant", ctx)})
+		})
+		return ctrl
+	}
+
+	appModule := func() core.Module {
+		return core.NewModule(core.NewModuleOptions{
+			Providers:   []core.Providers{tenant},
+			Controllers: []core.Controllers{controller},
+		})
+	}
+
+	app := core.CreateFactory(appModule)
+	testServer := httptest.NewServer(app.PrepareBeforeListen())
+	defer testServer.Close()
+
+	resp, err := testServer.Client().Get(testServer.URL + "/test")
+	require.Nil(t, err)
+	require.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+
+	req, err := http.NewRequest("GET", testServer.URL+"/test", nil)
+	require.Nil(t, err)
+	req.Header.Set("x-tenant", "1")
+	resp, err = testServer.Client().Do(req)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+}
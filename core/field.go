@@ -0,0 +1,179 @@
+package core
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// injectField is a struct field populated with a provider.
+type injectField struct {
+	index int
+	name  string
+	token Provide
+}
+
+// injectFields returns the fields of the struct injected with a provider.
+// The value of the inject tag is the name of the provider; an empty value
+// injects the provider registered for the type of the field, as
+// ProvideConstructor would. The untagged exported fields pointing to a
+// struct, still nil, are injected by type too, when a provider of their type
+// is visible once the provider is resolved. The tag inject:"-" skips a field.
+//
+//	type UserController struct {
+//		Service *UserService `inject:""`
+//		Tenant  string       `inject:"tenant"`
+//		Logger  *Logger
+//	}
+//
+// It panics if a tagged field is unexported.
+func injectFields(param interface{}) (fields []injectField, byType []injectField) {
+	t := reflect.TypeOf(param)
+	if t == nil || t.Kind() != reflect.Pointer || t.Elem().Kind() != reflect.Struct {
+		return nil, nil
+	}
+	v := reflect.ValueOf(param).Elem()
+	t = t.Elem()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		token, ok := field.Tag.Lookup("inject")
+		if !ok {
+			if field.IsExported() && !field.Anonymous && field.Type.Kind() == reflect.Pointer &&
+				field.Type.Elem().Kind() == reflect.Struct && field.Type.Elem().Name() != "" &&
+				field.Type.Elem() != t && v.Field(i).IsNil() {
+				byType = append(byType, injectField{index: i, name: field.Name, token: typeProvideName(field.Type)})
+			}
+			continue
+		}
+		if token == "-" {
+			continue
+		}
+		if !field.IsExported() {
+			panic(fmt.Sprintf("field %s.%s tagged with inject must be exported", t.Name(), field.Name))
+		}
+		if token == "" {
+			token = string(typeProvideName(field.Type))
+		}
+		fields = append(fields, injectField{index: i, name: field.Name, token: Provide(token)})
+	}
+	return fields, byType
+}
+
+// fieldProvider describes a provider registered from a struct with injected
+// fields.
+type fieldProvider struct {
+	structName string
+	// fields are the tagged fields, followed by the untagged fields injected
+	// by type once the provider is resolved.
+	fields []injectField
+	byType []injectField
+	// described maps each injected token to the first field injecting it.
+	described map[Provide]string
+}
+
+// bind adds the untagged fields whose type is provided to the injections of
+// the provider, from the module owning it.
+func (fp *fieldProvider) bind(m *DynamicModule, provider Provider) {
+	if len(fp.byType) == 0 {
+		return
+	}
+	inject := provider.GetInject()
+	for _, f := range fp.byType {
+		if f.token == provider.GetName() || m.lookup(f.token) == nil {
+			continue
+		}
+		fp.fields = append(fp.fields, f)
+		inject = append(inject, f.token)
+		if _, ok := fp.described[f.token]; !ok {
+			fp.described[f.token] = fp.structName + "." + f.name
+		}
+	}
+	fp.byType = nil
+	provider.SetInject(inject)
+}
+
+// newFieldProvider registers the struct as a provider whose fields are
+// populated once the injected providers are resolved. The provider becomes
+// request-scoped when it is resolved if one of them is request-scoped,
+// whatever the declaration order. A request-scoped or transient provider
+// gives a copy of the struct with its own fields to every request or
+// injection.
+func (module *DynamicModule) newFieldProvider(name Provide, param interface{}, fields []injectField, byType []injectField) Provider {
+	fp := &fieldProvider{
+		structName: reflect.TypeOf(param).Elem().Name(),
+		fields:     fields,
+		byType:     byType,
+		described:  make(map[Provide]string, len(fields)),
+	}
+	inject := make([]Provide, 0, len(fields))
+	for _, f := range fields {
+		inject = append(inject, f.token)
+		if _, ok := fp.described[f.token]; !ok {
+			fp.described[f.token] = fp.structName + "." + f.name
+		}
+	}
+
+	populate := func(target reflect.Value, values []interface{}) {
+		for i, f := range fp.fields {
+			field := target.Field(f.index)
+			arg, err := constructorArg(field.Type(), values[i])
+			if err != nil {
+				panic(fmt.Errorf("field %s.%s in module %s injects %s: %w", fp.structName, f.name, moduleChain(module), f.token, err))
+			}
+			field.Set(arg)
+		}
+	}
+
+	// provider is nil while InitProviders creates a singleton value.
+	var provider Provider
+	provider = InitProviders(module, ProviderOptions{
+		Name:   name,
+		Inject: inject,
+		Factory: func(values ...interface{}) interface{} {
+			// The scope is read once the provider is resolved, as REQUEST in
+			// the inject list, the scope of the module or a request-scoped
+			// dependency change it.
+			if provider != nil && (provider.GetScope() == Request || provider.GetScope() == Transient) {
+				clone := reflect.New(reflect.TypeOf(param).Elem())
+				clone.Elem().Set(reflect.ValueOf(param).Elem())
+				populate(clone.Elem(), values)
+				return clone.Interface()
+			}
+			populate(reflect.ValueOf(param).Elem(), values)
+			return param
+		},
+	})
+
+	root := module.root()
+	root.graphMu.Lock()
+	if root.fields == nil {
+		root.fields = make(map[Provider]*fieldProvider)
+	}
+	root.fields[provider] = fp
+	root.graphMu.Unlock()
+
+	return provider
+}
+
+// fieldProvider returns the description of the provider registered from a
+// struct with injected fields, nil for the other providers.
+func (m *DynamicModule) fieldProvider(provider Provider) *fieldProvider {
+	root := m.root()
+	root.graphMu.RLock()
+	defer root.graphMu.RUnlock()
+	return root.fields[provider]
+}
+
+// injectsRequest reports whether the provider injects a request-scoped
+// provider visible from the module.
+func (m *DynamicModule) injectsRequest(provider Provider) bool {
+	for _, name := range provider.GetInject() {
+		if name == REQUEST {
+			return true
+		}
+		if dep := m.lookup(name); dep != nil && dep.GetScope() == Request {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,315 @@
+package core_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tinh-tinh/tinhtinh/v2/core"
+)
+
+type fieldRepository struct {
+	Name string
+}
+
+type fieldService struct {
+	Repo   *fieldRepository `inject:""`
+	Config string           `inject:"config"`
+	Plain  string
+}
+
+type fieldController struct {
+	Service *fieldService `inject:""`
+	Tenant  string        `inject:"tenant"`
+}
+
+type fieldRequest struct {
+	Req  *http.Request    `inject:"REQUEST"`
+	Repo *fieldRepository `inject:""`
+}
+
+type fieldByType struct {
+	Repo    *fieldRepository
+	Preset  *fieldRepository
+	Skipped *fieldRepository `inject:"-"`
+	Missing *fieldMissing
+}
+
+type fieldMissing struct {
+	Repo *fieldRepository `inject:"repo"`
+}
+
+type fieldUnexported struct {
+	repo *fieldRepository `inject:""`
+}
+
+func Test_FieldInjection(t *testing.T) {
+	appModule := func() core.Module {
+		module := core.NewModule(core.NewModuleOptions{})
+		// Declared before its dependencies.
+		module.NewProvider(&fieldService{Plain: "plain"})
+		module.NewProvider(&fieldRepository{Name: "repo"})
+		module.NewProvider(core.ProviderOptions{
+			Name:  "config",
+			Value: "config",
+		})
+		return module
+	}
+
+	app := core.CreateFactory(appModule)
+	svc := core.Inject[fieldService](app.Module)
+	require.NotNil(t, svc)
+	require.Equal(t, "repo", svc.Repo.Name)
+	require.Equal(t, "config", svc.Config)
+	require.Equal(t, "plain", svc.Plain)
+}
+
+func Test_FieldInjection_Request(t *testing.T) {
+	tenant := func(module core.Module) core.Provider {
+		return module.NewProvider(core.ProviderOptions{
+			Name: "tenant",
+			Factory: func(param ...interface{}) interface{} {
+				return param[0].(*http.Request).Header.Get("x-tenant")
+			},
+			Inject: []core.Provide{core.REQUEST},
+		})
+	}
+	service := func(module core.Module) core.Provider {
+		return module.NewProvider(&fieldService{})
+	}
+	controllerProvider := func(module core.Module) core.Provider {
+		return module.NewProvider(&fieldController{})
+	}
+
+	controller := func(module core.Module) core.Controller {
+		ctrl := module.NewController("test")
+		ctrl.Get("", func(ctx core.Ctx) error {
+			c := ctrl.Ref("fieldController", ctx).(*fieldController)
+			return ctx.JSON(core.Map{
+				"tenant": c.Tenant,
+				"repo":   c.Service.Repo.Name,
+			})
+		})
+		return ctrl
+	}
+
+	appModule := func() core.Module {
+		module := core.NewModule(core.NewModuleOptions{
+			Providers: []core.Providers{
+				func(module core.Module) core.Provider {
+					return module.NewProvider(&fieldRepository{Name: "repo"})
+				},
+				func(module core.Module) core.Provider {
+					return module.NewProvider(core.ProviderOptions{Name: "config", Value: "config"})
+				},
+				tenant, service, controllerProvider,
+			},
+			Controllers: []core.Controllers{controller},
+		})
+		return module
+	}
+
+	app := core.CreateFactory(appModule)
+	testServer := httptest.NewServer(app.PrepareBeforeListen())
+	defer testServer.Close()
+
+	for _, id := range []string{"1", "2"} {
+		req, err := http.NewRequest("GET", testServer.URL+"/test", nil)
+		require.Nil(t, err)
+		req.Header.Set("x-tenant", id)
+		resp, err := testServer.Client().Do(req)
+		require.Nil(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var res core.Map
+		require.Nil(t, json.NewDecoder(resp.Body).Decode(&res))
+		require.Equal(t, id, res["tenant"])
+		require.Equal(t, "repo", res["repo"])
+	}
+}
+
+func Test_FieldInjection_Concurrent(t *testing.T) {
+	cases := map[string]core.Scope{
+		"inject request": "",
+		"module scope":   core.Request,
+	}
+	for name, scope := range cases {
+		t.Run(name, func(t *testing.T) {
+			var (
+				mu        sync.Mutex
+				instances = map[*fieldRequest]string{}
+			)
+			arrived := make(chan struct{})
+			release := make(chan struct{})
+
+			controller := func(module core.Module) core.Controller {
+				ctrl := module.NewController("test")
+				ctrl.Get("", func(ctx core.Ctx) error {
+					f := ctrl.Ref("fieldRequest", ctx).(*fieldRequest)
+					arrived <- struct{}{}
+					<-release
+					mu.Lock()
+					instances[f] = f.Req.Header.Get("x-id")
+					mu.Unlock()
+					return ctx.JSON(core.Map{"id": f.Req.Header.Get("x-id")})
+				})
+				return ctrl
+			}
+
+			appModule := func() core.Module {
+				return core.NewModule(core.NewModuleOptions{
+					Scope: scope,
+					Providers: []core.Providers{
+						func(module core.Module) core.Provider {
+							return module.NewProvider(&fieldRepository{Name: "repo"})
+						},
+						func(module core.Module) core.Provider {
+							return module.NewProvider(&fieldRequest{})
+						},
+					},
+					Controllers: []core.Controllers{controller},
+				})
+			}
+
+			app := core.CreateFactory(appModule)
+			testServer := httptest.NewServer(app.PrepareBeforeListen())
+			defer testServer.Close()
+
+			var wg sync.WaitGroup
+			for _, id := range []string{"1", "2"} {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					req, err := http.NewRequest("GET", testServer.URL+"/test", nil)
+					require.Nil(t, err)
+					req.Header.Set("x-id", id)
+					resp, err := testServer.Client().Do(req)
+					require.Nil(t, err)
+					defer resp.Body.Close()
+
+					var res core.Map
+					require.Nil(t, json.NewDecoder(resp.Body).Decode(&res))
+					require.Equal(t, id, res["id"])
+				}()
+			}
+			// Both requests hold their instance at the same time.
+			<-arrived
+			<-arrived
+			close(release)
+			wg.Wait()
+
+			// Every request has its own instance with its own request.
+			require.Len(t, instances, 2)
+			var ids []string
+			for _, id := range instances {
+				ids = append(ids, id)
+			}
+			require.ElementsMatch(t, []string{"1", "2"}, ids)
+		})
+	}
+}
+
+func Test_FieldInjection_Missing(t *testing.T) {
+	appModule := func() core.Module {
+		module := core.NewModule(core.NewModuleOptions{})
+		module.NewProvider(&fieldMissing{})
+		return module
+	}
+
+	require.PanicsWithError(t, "field fieldMissing.Repo in module AppModule injects repo which is not provided: register it in the module or import a module exporting it", func() {
+		_ = core.CreateFactory(appModule)
+	})
+
+	appModule = func() core.Module {
+		module := core.NewModule(core.NewModuleOptions{})
+		module.NewProvider(core.ProviderOptions{Name: "repo", Value: "not a repository"})
+		module.NewProvider(&fieldMissing{})
+		return module
+	}
+
+	require.PanicsWithError(t, "field fieldMissing.Repo in module AppModule injects repo: cannot use string as *core_test.fieldRepository", func() {
+		_ = core.CreateFactory(appModule)
+	})
+
+	require.PanicsWithValue(t, "field fieldUnexported.repo tagged with inject must be exported", func() {
+		module := core.NewModule(core.NewModuleOptions{})
+		module.NewProvider(&fieldUnexported{})
+	})
+}
+
+func Test_FieldInjection_DeclarationOrder(t *testing.T) {
+	controller := func(module core.Module) core.Controller {
+		ctrl := module.NewController("test")
+		ctrl.Get("", func(ctx core.Ctx) error {
+			c := ctrl.Ref("fieldController", ctx).(*fieldController)
+			return ctx.JSON(core.Map{"tenant": c.Tenant})
+		})
+		return ctrl
+	}
+
+	appModule := func() core.Module {
+		return core.NewModule(core.NewModuleOptions{
+			Providers: []core.Providers{
+				// Registered before the request-scoped provider it injects.
+				func(module core.Module) core.Provider {
+					return module.NewProvider(&fieldController{})
+				},
+				func(module core.Module) core.Provider {
+					return module.NewProvider(&fieldService{})
+				},
+				func(module core.Module) core.Provider {
+					return module.NewProvider(core.ProviderOptions{
+						Name: "tenant",
+						Factory: func(param ...interface{}) interface{} {
+							return param[0].(*http.Request).Header.Get("x-tenant")
+						},
+						Inject: []core.Provide{core.REQUEST},
+					})
+				},
+				func(module core.Module) core.Provider {
+					return module.NewProvider(&fieldRepository{Name: "repo"})
+				},
+				func(module core.Module) core.Provider {
+					return module.NewProvider(core.ProviderOptions{Name: "config", Value: "config"})
+				},
+			},
+			Controllers: []core.Controllers{controller},
+		})
+	}
+
+	app := core.CreateFactory(appModule)
+	testServer := httptest.NewServer(app.PrepareBeforeListen())
+	defer testServer.Close()
+
+	req, err := http.NewRequest("GET", testServer.URL+"/test", nil)
+	require.Nil(t, err)
+	req.Header.Set("x-tenant", "1")
+	resp, err := testServer.Client().Do(req)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var res core.Map
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&res))
+	require.Equal(t, "1", res["tenant"])
+}
+
+func Test_FieldInjection_ByType(t *testing.T) {
+	preset := &fieldRepository{Name: "preset"}
+	appModule := func() core.Module {
+		module := core.NewModule(core.NewModuleOptions{})
+		module.NewProvider(&fieldByType{Preset: preset})
+		module.NewProvider(&fieldRepository{Name: "repo"})
+		return module
+	}
+
+	app := core.CreateFactory(appModule)
+	svc := core.Inject[fieldByType](app.Module)
+	require.NotNil(t, svc)
+	require.Equal(t, "repo", svc.Repo.Name)
+	require.Same(t, preset, svc.Preset)
+	require.Nil(t, svc.Skipped)
+	require.Nil(t, svc.Missing)
+}
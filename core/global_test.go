@@ -0,0 +1,187 @@
+package core_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tinh-tinh/tinhtinh/v2/core"
+)
+
+func configModule(module core.Module) core.Module {
+	config := module.New(core.NewModuleOptions{Global: true})
+	config.NewProvider(core.ProviderOptions{
+		Name:  "config",
+		Value: "config",
+	})
+	config.Export("config")
+	return config
+}
+
+func userModule(module core.Module) core.Module {
+	user := module.New(core.NewModuleOptions{})
+	user.NewProvider(core.ProviderOptions{
+		Name: "user",
+		Factory: func(param ...interface{}) interface{} {
+			return "user:" + param[0].(string)
+		},
+		Inject: []core.Provide{"config"},
+	})
+	user.Export("user")
+	return user
+}
+
+func sharedModule(module core.Module) core.Module {
+	return module.New(core.NewModuleOptions{
+		Exports: []core.Modules{userModule},
+	})
+}
+
+func Test_GlobalModule(t *testing.T) {
+	nested := func(module core.Module) core.Module {
+		return module.New(core.NewModuleOptions{
+			Imports: []core.Modules{userModule},
+		})
+	}
+
+	appModule := func() core.Module {
+		return core.NewModule(core.NewModuleOptions{
+			// The global module is imported after the modules using it.
+			Imports: []core.Modules{nested, configModule},
+		})
+	}
+
+	app := core.CreateFactory(appModule)
+	require.Equal(t, "user:config", app.Module.Ref("user"))
+
+	tree := app.GetTree()
+	require.Len(t, tree.Imports, 2)
+	config := tree.Imports[1]
+	require.True(t, config.Global)
+	for _, p := range config.Providers {
+		switch p.Name {
+		case "config":
+			require.True(t, p.Global)
+			require.Equal(t, config.Name, p.Owner)
+			require.Len(t, p.VisibleIn, 4)
+		case "user":
+			// Inherited from the parent, but not exported globally.
+			require.False(t, p.Global)
+		}
+	}
+}
+
+func Test_GlobalModule_NotImported(t *testing.T) {
+	appModule := func() core.Module {
+		return core.NewModule(core.NewModuleOptions{
+			Imports: []core.Modules{userModule},
+		})
+	}
+
+	require.Panics(t, func() {
+		_ = core.CreateFactory(appModule)
+	})
+}
+
+func Test_ReExport(t *testing.T) {
+	appModule := func() core.Module {
+		return core.NewModule(core.NewModuleOptions{
+			Imports: []core.Modules{configModule, sharedModule},
+		})
+	}
+
+	app := core.CreateFactory(appModule)
+	require.Equal(t, "user:config", app.Module.Ref("user"))
+
+	tree := app.GetTree()
+	shared := tree.Imports[1]
+	require.Len(t, shared.Imports, 1)
+	require.Equal(t, []string{shared.Imports[0].Name}, shared.ReExports)
+
+	user := shared.Imports[0].Providers[0]
+	for _, p := range shared.Imports[0].Providers {
+		if p.Name == "user" {
+			user = p
+		}
+	}
+	require.Equal(t, shared.Imports[0].Name, user.Owner)
+	require.ElementsMatch(t, []string{"AppModule", shared.Name, shared.Imports[0].Name}, user.VisibleIn)
+}
+
+func Test_Encapsulated(t *testing.T) {
+	hidden := func(module core.Module) core.Module {
+		return module.New(core.NewModuleOptions{
+			Imports:      []core.Modules{userModule},
+			Encapsulated: true,
+		})
+	}
+
+	appModule := func() core.Module {
+		return core.NewModule(core.NewModuleOptions{
+			Imports: []core.Modules{configModule, hidden},
+		})
+	}
+
+	// The exports of a module imported by an encapsulated module without
+	// re-export are not visible to the modules importing the importer.
+	app := core.CreateFactory(appModule)
+	require.Nil(t, app.Module.Ref("user"))
+
+	injecting := func() core.Module {
+		module := core.NewModule(core.NewModuleOptions{
+			Imports: []core.Modules{configModule, hidden},
+		})
+		module.NewProvider(core.ProviderOptions{
+			Name: "profile",
+			Factory: func(param ...interface{}) interface{} {
+				return param[0]
+			},
+			Inject: []core.Provide{"user"},
+		})
+		return module
+	}
+	require.Panics(t, func() {
+		_ = core.CreateFactory(injecting)
+	})
+
+	// A provider of an imported module is exported again with Export.
+	exporting := func(module core.Module) core.Module {
+		mod := module.New(core.NewModuleOptions{
+			Imports:      []core.Modules{userModule},
+			Encapsulated: true,
+		})
+		mod.Export("user")
+		return mod
+	}
+	app = core.CreateFactory(func() core.Module {
+		return core.NewModule(core.NewModuleOptions{
+			Imports: []core.Modules{configModule, exporting},
+		})
+	})
+	require.Equal(t, "user:config", app.Module.Ref("user"))
+
+	reExporting := func(module core.Module) core.Module {
+		return module.New(core.NewModuleOptions{
+			Exports:      []core.Modules{userModule},
+			Encapsulated: true,
+		})
+	}
+	app = core.CreateFactory(func() core.Module {
+		return core.NewModule(core.NewModuleOptions{
+			Imports: []core.Modules{configModule, reExporting},
+		})
+	})
+	require.Equal(t, "user:config", app.Module.Ref("user"))
+
+	// By default, the exports of the imported modules are exported too.
+	transitive := func(module core.Module) core.Module {
+		return module.New(core.NewModuleOptions{
+			Imports: []core.Modules{userModule},
+		})
+	}
+	app = core.CreateFactory(func() core.Module {
+		return core.NewModule(core.NewModuleOptions{
+			Imports: []core.Modules{configModule, transitive},
+		})
+	})
+	require.Equal(t, "user:config", app.Module.Ref("user"))
+}
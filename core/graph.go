@@ -0,0 +1,203 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// The providers of a module are not created when they are registered. They
+// are kept pending with the module owning them, then resolved once the whole
+// module tree is built: each provider is created after the providers it
+// injects, so neither the declaration order nor the import order matters. A
+// provider referenced before the resolution phase is resolved on demand.
+//
+// The pending providers, the resolution stack used to detect cycles and the
+// order the providers were created in are kept on the root module. As lazy
+// modules register providers after startup, the maps read when referencing a
+// provider are guarded by graphMu.
+
+// deferProvider registers the provider to be resolved with the module.
+func (m *DynamicModule) deferProvider(provider Provider) {
+	root := m.root()
+	root.graphMu.Lock()
+	defer root.graphMu.Unlock()
+	if root.pending == nil {
+		root.pending = make(map[Provider]*DynamicModule)
+	}
+	root.pending[provider] = m
+	root.queue = append(root.queue, provider)
+}
+
+// created records that the value of the provider is available.
+func (m *DynamicModule) created(provider Provider) {
+	root := m.root()
+	root.graphMu.Lock()
+	defer root.graphMu.Unlock()
+	root.order = append(root.order, provider)
+}
+
+// resolveProviders resolves the pending providers of the module tree, in
+// registration order. It panics if a dependency is missing, private to
+// another module, or part of a cycle.
+func (m *DynamicModule) resolveProviders() {
+	root := m.root()
+	root.graphMu.Lock()
+	queue := root.queue
+	root.queue = nil
+	root.graphMu.Unlock()
+	for _, p := range queue {
+		m.resolvePending(p)
+	}
+}
+
+// lookup returns the provider with the given name visible from the module:
+// one of its own providers, an export of the modules it imports, or an export
+// of a global module.
+func (m *DynamicModule) lookup(name Provide) Provider {
+	if idx := m.findIdx(name); idx != -1 {
+		return m.DataProviders[idx]
+	}
+	root := m.root()
+	root.graphMu.RLock()
+	defer root.graphMu.RUnlock()
+	for _, p := range root.globals {
+		if p.GetName() == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// resolvePending resolves the provider in its owner module if it is pending.
+func (m *DynamicModule) resolvePending(provider Provider) {
+	root := m.root()
+	root.graphMu.RLock()
+	owner, ok := root.pending[provider]
+	root.graphMu.RUnlock()
+	if ok {
+		owner.resolveProvider(provider)
+	}
+}
+
+// donePending removes the provider from the pending providers.
+func (m *DynamicModule) donePending(provider Provider) {
+	root := m.root()
+	root.graphMu.Lock()
+	defer root.graphMu.Unlock()
+	delete(root.pending, provider)
+}
+
+// resolveProvider checks the dependencies of the provider and creates it if
+// it is a singleton. Request-scoped and transient providers are created later,
+// for each request or each reference.
+func (m *DynamicModule) resolveProvider(provider Provider) {
+	root := m.root()
+	if idx := slices.Index(root.resolving, provider); idx != -1 {
+		panic(cycleError(m, append(slices.Clone(root.resolving[idx:]), provider)))
+	}
+	root.resolving = append(root.resolving, provider)
+	defer func() {
+		root.resolving = root.resolving[:len(root.resolving)-1]
+	}()
+
+	fp := m.fieldProvider(provider)
+	if fp != nil {
+		fp.bind(m, provider)
+	}
+	for _, name := range provider.GetInject() {
+		if err := m.checkDependency(provider, name); err != nil {
+			panic(err)
+		}
+	}
+	// A field provider follows the scope of its request-scoped dependencies,
+	// now resolved.
+	if fp != nil && provider.GetScope() != Transient && m.injectsRequest(provider) {
+		provider.SetScope(Request)
+	}
+
+	if provider.GetScope() == Request || provider.GetScope() == Transient {
+		m.donePending(provider)
+		return
+	}
+
+	values := make([]interface{}, 0, len(provider.GetInject()))
+	for _, name := range provider.GetInject() {
+		values = append(values, m.Ref(name))
+	}
+	val := provider.GetFactory()(values...)
+	if val == nil {
+		panic(providerError(m, ProviderOptions{Name: provider.GetName(), Inject: provider.GetInject()}, errors.New("factory returned nil")))
+	}
+	provider.SetValue(val)
+	m.donePending(provider)
+	m.created(provider)
+}
+
+// checkDependency returns an error if the provider injects a name that the
+// module cannot resolve, or if a singleton injects a request-scoped provider.
+// Pending dependencies are resolved first.
+func (m *DynamicModule) checkDependency(provider Provider, name Provide) error {
+	if name == REQUEST || name == APP || name == DISCOVERY || name == LAZY_MODULE_LOADER {
+		return nil
+	}
+	if tag, ok := tagOf(name); ok {
+		for _, t := range m.root().taggedProviders() {
+			if slices.Contains(t.tags, tag) {
+				m.resolvePending(t.provider)
+			}
+		}
+		return nil
+	}
+
+	if dep := m.lookup(name); dep != nil {
+		m.resolvePending(dep)
+		// A field provider follows the scope of its dependencies instead.
+		singleton := provider.GetScope() != Request && provider.GetScope() != Transient
+		if singleton && dep.GetScope() == Request && m.fieldProvider(provider) == nil {
+			return fmt.Errorf("provider %s in module %s injects %s which is request-scoped: make %s request-scoped too",
+				provider.GetName(), moduleChain(m), name, provider.GetName())
+		}
+		return nil
+	}
+
+	subject := fmt.Sprintf("provider %s", provider.GetName())
+	if fp := m.fieldProvider(provider); fp != nil {
+		if field, ok := fp.described[name]; ok {
+			subject = fmt.Sprintf("field %s", field)
+		}
+	}
+	if owner := m.root().findOwner(name); owner != nil {
+		return fmt.Errorf("%s in module %s injects %s which is private to module %s: export it from %s and import %s",
+			subject, moduleChain(m), name, moduleChain(owner), owner.Name, owner.Name)
+	}
+	return fmt.Errorf("%s in module %s injects %s which is not provided: register it in the module or import a module exporting it",
+		subject, moduleChain(m), name)
+}
+
+// findOwner returns the module of the tree registering a provider with the
+// given name privately.
+func (m *DynamicModule) findOwner(name Provide) *DynamicModule {
+	for _, p := range m.DataProviders {
+		if p.GetName() == name && p.GetStatus() == PRIVATE {
+			return m
+		}
+	}
+	for _, sub := range m.SubModules {
+		if owner := sub.findOwner(name); owner != nil {
+			return owner
+		}
+	}
+	return nil
+}
+
+// cycleError describes the cycle formed by the providers, the last provider
+// being the first one of the cycle.
+func cycleError(m *DynamicModule, cycle []Provider) error {
+	names := make([]string, 0, len(cycle))
+	for _, p := range cycle {
+		names = append(names, string(p.GetName()))
+	}
+	return fmt.Errorf("circular dependency in module %s: %s", moduleChain(m), strings.Join(names, " -> "))
+}
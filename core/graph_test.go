@@ -0,0 +1,168 @@
+package core_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tinh-tinh/tinhtinh/v2/core"
+)
+
+func graphProvider(name core.Provide, inject ...core.Provide) core.Providers {
+	return func(module core.Module) core.Provider {
+		return module.NewProvider(core.ProviderOptions{
+			Name: name,
+			Factory: func(param ...interface{}) interface{} {
+				value := string(name)
+				for _, p := range param {
+					value += "(" + p.(string) + ")"
+				}
+				return value
+			},
+			Inject: inject,
+		})
+	}
+}
+
+func Test_Graph_Order(t *testing.T) {
+	appModule := func() core.Module {
+		return core.NewModule(core.NewModuleOptions{
+			Providers: []core.Providers{
+				graphProvider("controller", "service"),
+				graphProvider("service", "repo", "config"),
+				graphProvider("repo", "config"),
+				graphProvider("config"),
+			},
+		})
+	}
+
+	app := core.CreateFactory(appModule)
+	require.Equal(t, "controller(service(repo(config))(config))", app.Module.Ref("controller"))
+}
+
+func Test_Graph_Missing(t *testing.T) {
+	appModule := func() core.Module {
+		return core.NewModule(core.NewModuleOptions{
+			Providers: []core.Providers{
+				graphProvider("service", "repo"),
+			},
+		})
+	}
+
+	require.PanicsWithError(t, "provider service in module AppModule injects repo which is not provided: register it in the module or import a module exporting it", func() {
+		_ = core.CreateFactory(appModule)
+	})
+}
+
+func Test_Graph_Private(t *testing.T) {
+	repoModule := func(module core.Module) core.Module {
+		return module.New(core.NewModuleOptions{
+			Providers: []core.Providers{graphProvider("repo")},
+		})
+	}
+
+	serviceModule := func(module core.Module) core.Module {
+		return module.New(core.NewModuleOptions{
+			Providers: []core.Providers{graphProvider("service", "repo")},
+		})
+	}
+
+	appModule := func() core.Module {
+		return core.NewModule(core.NewModuleOptions{
+			Imports: []core.Modules{repoModule, serviceModule},
+		})
+	}
+
+	msg := panicMessage(func() {
+		_ = core.CreateFactory(appModule)
+	})
+	require.Contains(t, msg, "provider service in module AppModule -> ")
+	require.Contains(t, msg, "injects repo which is private to module AppModule -> ")
+	require.Contains(t, msg, "Test_Graph_Private.func1: export it from")
+}
+
+func Test_Graph_Request(t *testing.T) {
+	tenant := func(module core.Module) core.Provider {
+		return module.NewProvider(core.ProviderOptions{
+			Name: "tenant",
+			Factory: func(param ...interface{}) interface{} {
+				return "tenant"
+			},
+			Inject: []core.Provide{core.REQUEST},
+		})
+	}
+
+	appModule := func() core.Module {
+		return core.NewModule(core.NewModuleOptions{
+			Providers: []core.Providers{
+				graphProvider("service", "tenant"),
+				tenant,
+			},
+		})
+	}
+
+	require.PanicsWithError(t, "provider service in module AppModule injects tenant which is request-scoped: make service request-scoped too", func() {
+		_ = core.CreateFactory(appModule)
+	})
+}
+
+func Test_Graph_Cycle(t *testing.T) {
+	appModule := func() core.Module {
+		return core.NewModule(core.NewModuleOptions{
+			Providers: []core.Providers{
+				graphProvider("a", "b"),
+				graphProvider("b", "c"),
+				graphProvider("c", "a"),
+			},
+		})
+	}
+
+	require.PanicsWithError(t, "circular dependency in module AppModule: a -> b -> c -> a", func() {
+		_ = core.CreateFactory(appModule)
+	})
+
+	transient := func(module core.Module) core.Provider {
+		return module.NewProvider(core.ProviderOptions{
+			Name:  "transient",
+			Scope: core.Transient,
+			Factory: func(param ...interface{}) interface{} {
+				return "transient"
+			},
+			Inject: []core.Provide{"singleton"},
+		})
+	}
+
+	appModule = func() core.Module {
+		return core.NewModule(core.NewModuleOptions{
+			Providers: []core.Providers{
+				graphProvider("singleton", "transient"),
+				transient,
+			},
+		})
+	}
+
+	require.PanicsWithError(t, "circular dependency in module AppModule: singleton -> transient -> singleton", func() {
+		_ = core.CreateFactory(appModule)
+	})
+}
+
+func Test_Graph_Lazy(t *testing.T) {
+	appModule := func() core.Module {
+		module := core.NewModule(core.NewModuleOptions{})
+		module.NewProvider(core.ProviderOptions{
+			Name: "service",
+			Factory: func(param ...interface{}) interface{} {
+				return "service:" + param[0].(string)
+			},
+			Inject: []core.Provide{"config"},
+		})
+		module.NewProvider(core.ProviderOptions{
+			Name:  "config",
+			Value: "config",
+		})
+		require.Equal(t, "service:config", module.Ref("service"))
+		return module
+	}
+
+	app := core.CreateFactory(appModule)
+	require.Equal(t, "service:config", app.Module.Ref("service"))
+}
@@ -1,25 +1,62 @@
 package core
 
 import (
+	"errors"
+	"fmt"
+
 	"github.com/tinh-tinh/tinhtinh/v2/common"
 )
 
+// USER is the key under which the guards authenticating the request should
+// set the user with ctx.Set, so the request-scoped loggers can log it.
+const USER CtxKey = "USER"
+
+// UserID returns the user set under USER when it is an identifier: a string,
+// a fmt.Stringer or an integer. It returns an empty string otherwise.
+func UserID(ctx Ctx) string {
+	switch u := ctx.Get(USER).(type) {
+	case string:
+		return u
+	case fmt.Stringer:
+		return u.String()
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprint(u)
+	}
+	return ""
+}
+
 // Guard is a function that checks access permission for a controller
 type Guard func(ctx Ctx) bool
 
-// ParseGuard wraps a Guard function into a Middleware that checks access permission
-// for the given DynamicController. If the guard function returns false, it responds
-// with a forbidden error, otherwise it calls the next middleware in the chain.
-func (ctrl *DynamicController) ParseGuard(guard Guard) Middleware {
+// errAccessDenied is the error of a guard denying the access.
+var errAccessDenied = errors.New("you can not access")
+
+// guardMiddleware wraps a Guard function into a Middleware responding with a
+// forbidden error when the guard denies the access. The guard runs as the
+// guard stage of the request, named after the function.
+func guardMiddleware(guard Guard) Middleware {
+	name := common.GetFunctionName(guard)
 	return func(ctx Ctx) error {
-		isAccess := guard(ctx)
-		if !isAccess {
-			return common.ForbiddenException(ctx.Res(), "you can not access")
+		err := runStage(ctx, StageGuard, name, func() error {
+			if !guard(ctx) {
+				return errAccessDenied
+			}
+			return nil
+		})
+		if err != nil {
+			return common.ForbiddenException(ctx.Res(), err.Error())
 		}
 		return ctx.Next()
 	}
 }
 
+// ParseGuard wraps a Guard function into a Middleware that checks access permission
+// for the given DynamicController. If the guard function returns false, it responds
+// with a forbidden error, otherwise it calls the next middleware in the chain.
+func (ctrl *DynamicController) ParseGuard(guard Guard) Middleware {
+	return guardMiddleware(guard)
+}
+
 // Guard registers the given Guard functions with the controller. The Guard functions
 // are called in order, and if any of them return false, the request is rejected with a
 // forbidden error. Otherwise, the request is allowed. Guard functions are called
@@ -33,6 +70,7 @@ func (c *DynamicController) Guard(guards ...Guard) Controller {
 	for _, v := range guards {
 		mid := c.ParseGuard(v)
 		c.middlewares = append(c.middlewares, mid)
+		c.guards++
 	}
 	return c
 }
@@ -41,21 +79,17 @@ func (c *DynamicController) Guard(guards ...Guard) Controller {
 // for the given DynamicModule. If the guard function returns false, it responds
 // with a forbidden error, otherwise it calls the next middleware in the chain.
 func (module *DynamicModule) ParseGuard(guard Guard) Middleware {
-	return func(ctx Ctx) error {
-		isAccess := guard(ctx)
-		if !isAccess {
-			return common.ForbiddenException(ctx.Res(), "you can not access")
-		}
-		return ctx.Next()
-	}
+	return guardMiddleware(guard)
 }
 
 func (module *DynamicModule) Guard(guards ...Guard) Module {
 	for _, v := range guards {
 		mid := module.ParseGuard(v)
 		module.Middlewares = append(module.Middlewares, mid)
+		module.guards++
 		for _, router := range module.Routers {
 			router.Middlewares = append(router.Middlewares, mid)
+			router.guards++
 		}
 	}
 
@@ -0,0 +1,174 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"slices"
+	"sync"
+
+	"github.com/tinh-tinh/tinhtinh/v2/common"
+)
+
+// LAZY_MODULE_LOADER resolves to the LazyModuleLoader of the module tree. It
+// can be injected in any module.
+const LAZY_MODULE_LOADER Provide = "LAZY_MODULE_LOADER"
+
+// LazyModuleLoader initializes modules on demand, after the application has
+// started. A lazy module is imported by the root module: its providers can
+// inject the exports of the root module and of the global modules, which are
+// already initialized.
+//
+// A lazy module is loaded once, the following calls of Load return the same
+// module. Its controllers cannot be mounted once the server is built, so a
+// lazy module only provides services.
+type LazyModuleLoader struct {
+	mu      sync.Mutex
+	root    *DynamicModule
+	modules map[string]Module
+}
+
+// lazyLoader returns the loader of the lazy modules of the tree.
+func (m *DynamicModule) lazyLoader() *LazyModuleLoader {
+	root := m.root()
+	root.graphMu.Lock()
+	defer root.graphMu.Unlock()
+	if root.lazy == nil {
+		root.lazy = &LazyModuleLoader{root: root, modules: make(map[string]Module)}
+	}
+	return root.lazy
+}
+
+// Load initializes the module if it is not loaded yet and returns it. The
+// providers of the module are resolved and their OnModuleInit and
+// OnApplicationBootstrap hooks are called, without running the hooks of the
+// modules already initialized. Use Ref on the returned module to get its
+// providers.
+//
+// An error is returned if a provider cannot be resolved or created, if a hook
+// fails or if the module declares controllers. The providers already created
+// by a module failing to load are destroyed, and the module is not cached, so
+// Load can be called again.
+func (l *LazyModuleLoader) Load(module Modules) (Module, error) {
+	name := common.GetFunctionName(module)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if mod, ok := l.modules[name]; ok {
+		return mod, nil
+	}
+
+	mod, err := l.load(name, module)
+	if err != nil {
+		return nil, fmt.Errorf("lazy load module %s: %w", name, err)
+	}
+	l.modules[name] = mod
+	return mod, nil
+}
+
+// load builds the module as a sub-module of the root module and resolves
+// its providers.
+func (l *LazyModuleLoader) load(name string, module Modules) (mod Module, err error) {
+	root := l.root
+	root.graphMu.RLock()
+	mark := lazyMark{order: len(root.order), tagged: len(root.tagged), globals: len(root.globals)}
+	root.graphMu.RUnlock()
+
+	defer func() {
+		if r := recover(); r != nil {
+			root.discard(mark)
+			err = recoverError(r)
+		}
+	}()
+
+	root.importing = name
+	mod = module(root)
+	// A conditional module renames the module being imported.
+	modName := root.importing
+	root.importing = ""
+
+	dynMod, ok := mod.(*DynamicModule)
+	if !ok {
+		return nil, fmt.Errorf("module is not a *DynamicModule")
+	}
+	if dynMod.disabled {
+		return nil, fmt.Errorf("module is disabled")
+	}
+	if len(dynMod.Routers) > 0 {
+		root.discard(mark)
+		return nil, fmt.Errorf("module declares controllers, which cannot be mounted after startup")
+	}
+	dynMod.Name = modName
+
+	root.resolveProviders()
+	dynMod.init()
+
+	root.graphMu.RLock()
+	created := slices.Clone(root.order[mark.order:])
+	root.graphMu.RUnlock()
+
+	if err := initValues(singletonValues(created)); err != nil {
+		root.discard(mark)
+		return nil, err
+	}
+
+	root.graphMu.Lock()
+	root.SubModules = append(root.SubModules, dynMod)
+	root.graphMu.Unlock()
+	return mod, nil
+}
+
+// lazyMark records the number of created, tagged and global providers of the
+// tree before a lazy module is loaded.
+type lazyMark struct {
+	order   int
+	tagged  int
+	globals int
+}
+
+// discard drops the providers registered by a module that failed to load, so
+// they are neither resolved, referenced nor shut down later. The instances
+// already created are destroyed in reverse creation order. The providers of
+// the modules loaded at startup are all resolved, so the pending providers
+// left belong to the module.
+func (m *DynamicModule) discard(mark lazyMark) {
+	m.importing = ""
+	m.graphMu.Lock()
+	m.pending = nil
+	m.queue = nil
+	created := slices.Clone(m.order[mark.order:])
+	for _, p := range created {
+		delete(m.owners, p)
+	}
+	m.order = m.order[:mark.order]
+	m.tagged = m.tagged[:mark.tagged]
+	m.globals = m.globals[:mark.globals]
+	m.graphMu.Unlock()
+
+	discardValues(singletonValues(created))
+}
+
+// discardValues calls OnModuleDestroy, or Close if it does not implement it,
+// on the values in reverse order.
+func discardValues(values []interface{}) {
+	for i := len(values) - 1; i >= 0; i-- {
+		var err error
+		switch v := values[i].(type) {
+		case OnModuleDestroy:
+			err = v.OnModuleDestroy()
+		case io.Closer:
+			err = v.Close()
+		}
+		if err != nil {
+			log.Printf("error when discarding provider %T: %v", values[i], err)
+		}
+	}
+}
+
+// recoverError converts a recovered panic value to an error.
+func recoverError(r interface{}) error {
+	if err, ok := r.(error); ok {
+		return err
+	}
+	return fmt.Errorf("%v", r)
+}
@@ -0,0 +1,271 @@
+package core_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tinh-tinh/tinhtinh/v2/core"
+)
+
+type reportService struct {
+	db    string
+	ready bool
+}
+
+func (s *reportService) OnModuleInit() error {
+	s.ready = true
+	return nil
+}
+
+func Test_LazyModuleLoader(t *testing.T) {
+	var built int
+	reportModule := func(module core.Module) core.Module {
+		report := module.New(core.NewModuleOptions{})
+		report.NewProvider(core.ProviderOptions{
+			Name: "report",
+			Factory: func(param ...interface{}) interface{} {
+				built++
+				return &reportService{db: param[0].(string)}
+			},
+			Inject: []core.Provide{"db"},
+		})
+		report.Export("report")
+		return report
+	}
+
+	var rootInit int
+	appModule := func() core.Module {
+		module := core.NewModule(core.NewModuleOptions{})
+		module.NewProvider(core.ProviderOptions{
+			Name:   "db",
+			Status: core.PUBLIC,
+			Factory: func(param ...interface{}) interface{} {
+				rootInit++
+				return "postgres"
+			},
+		})
+
+		ctrl := module.NewController("reports")
+		ctrl.Get("", func(ctx core.Ctx) error {
+			loader := module.Ref(core.LAZY_MODULE_LOADER).(*core.LazyModuleLoader)
+			report, err := loader.Load(reportModule)
+			if err != nil {
+				return err
+			}
+			svc := report.Ref("report").(*reportService)
+			return ctx.JSON(core.Map{"db": svc.db, "ready": svc.ready})
+		})
+		return module
+	}
+
+	app := core.CreateFactory(appModule)
+	app.SetGlobalPrefix("api")
+	require.Equal(t, 0, built)
+	require.Equal(t, 1, rootInit)
+
+	testServer := httptest.NewServer(app.PrepareBeforeListen())
+	defer testServer.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := testServer.Client().Get(testServer.URL + "/api/reports")
+			require.Nil(t, err)
+			resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+		}()
+	}
+	wg.Wait()
+
+	require.Equal(t, 1, built)
+	require.Equal(t, 1, rootInit)
+
+	loader := app.Module.Ref(core.LAZY_MODULE_LOADER).(*core.LazyModuleLoader)
+	report, err := loader.Load(reportModule)
+	require.Nil(t, err)
+	svc := report.Ref("report").(*reportService)
+	require.Equal(t, "postgres", svc.db)
+	require.True(t, svc.ready)
+
+	discovery := app.Module.Ref(core.DISCOVERY).(*core.DiscoveryService)
+	var names []string
+	for _, m := range discovery.Modules() {
+		names = append(names, m.Name)
+	}
+	require.Contains(t, names, report.(*core.DynamicModule).Name)
+}
+
+func Test_LazyModuleLoader_Error(t *testing.T) {
+	var attempts int
+	failing := func(module core.Module) core.Module {
+		mod := module.New(core.NewModuleOptions{})
+		mod.NewProvider(core.ProviderOptions{
+			Name: "client",
+			FactoryErr: func(param ...interface{}) (interface{}, error) {
+				attempts++
+				if attempts == 1 {
+					return nil, errors.New("connection refused")
+				}
+				return "client", nil
+			},
+		})
+		return mod
+	}
+
+	missing := func(module core.Module) core.Module {
+		mod := module.New(core.NewModuleOptions{})
+		mod.NewProvider(core.ProviderOptions{
+			Name:    "ml",
+			Factory: func(param ...interface{}) interface{} { return "ml" },
+			Inject:  []core.Provide{"gpu"},
+		})
+		return mod
+	}
+
+	withController := func(module core.Module) core.Module {
+		mod := module.New(core.NewModuleOptions{
+			Controllers: []core.Controllers{func(module core.Module) core.Controller {
+				ctrl := module.NewController("lazy")
+				ctrl.Get("", func(ctx core.Ctx) error { return nil })
+				return ctrl
+			}},
+		})
+		return mod
+	}
+
+	app := core.CreateFactory(func() core.Module {
+		return core.NewModule(core.NewModuleOptions{})
+	})
+	loader := app.Module.Ref(core.LAZY_MODULE_LOADER).(*core.LazyModuleLoader)
+
+	_, err := loader.Load(failing)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "connection refused")
+
+	mod, err := loader.Load(failing)
+	require.Nil(t, err)
+	require.Equal(t, "client", mod.Ref("client"))
+
+	_, err = loader.Load(missing)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "injects gpu which is not provided")
+
+	_, err = loader.Load(withController)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "controllers")
+}
+
+type cacheService struct {
+	attempt   int
+	destroyed *[]int
+}
+
+func (s *cacheService) OnModuleInit() error {
+	if s.attempt == 1 {
+		return errors.New("cache unavailable")
+	}
+	return nil
+}
+
+func (s *cacheService) OnModuleDestroy() error {
+	*s.destroyed = append(*s.destroyed, s.attempt)
+	return nil
+}
+
+func Test_LazyModuleLoader_HookError(t *testing.T) {
+	var attempts int
+	var destroyed []int
+	cacheModule := func(module core.Module) core.Module {
+		mod := module.New(core.NewModuleOptions{})
+		mod.NewProvider(core.ProviderOptions{
+			Name: "cache",
+			Factory: func(param ...interface{}) interface{} {
+				attempts++
+				return &cacheService{attempt: attempts, destroyed: &destroyed}
+			},
+		})
+		return mod
+	}
+
+	app := core.CreateFactory(func() core.Module {
+		return core.NewModule(core.NewModuleOptions{})
+	})
+	loader := app.Module.Ref(core.LAZY_MODULE_LOADER).(*core.LazyModuleLoader)
+	discovery := app.Module.Ref(core.DISCOVERY).(*core.DiscoveryService)
+	modules := len(discovery.Modules())
+
+	_, err := loader.Load(cacheModule)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "cache unavailable")
+	require.Len(t, discovery.Modules(), modules)
+	// The instance of the failed load is destroyed with it.
+	require.Equal(t, []int{1}, destroyed)
+
+	mod, err := loader.Load(cacheModule)
+	require.Nil(t, err)
+	require.Equal(t, 2, mod.Ref("cache").(*cacheService).attempt)
+	require.Len(t, discovery.Modules(), modules+1)
+
+	// The instance of the failed load is not shut down with the application.
+	require.Nil(t, app.Shutdown("SIGTERM"))
+	require.Equal(t, []int{1, 2}, destroyed)
+}
+
+type lazyConn struct {
+	name   string
+	closed *[]string
+}
+
+func (c *lazyConn) Close() error {
+	*c.closed = append(*c.closed, c.name)
+	return nil
+}
+
+func Test_LazyModuleLoader_FactoryError(t *testing.T) {
+	var closed []string
+	conn := func(name string, inject ...core.Provide) core.Providers {
+		return func(module core.Module) core.Provider {
+			return module.NewProvider(core.ProviderOptions{
+				Name: core.Provide(name),
+				Factory: func(param ...interface{}) interface{} {
+					return &lazyConn{name: name, closed: &closed}
+				},
+				Inject: inject,
+			})
+		}
+	}
+	storeModule := func(module core.Module) core.Module {
+		return module.New(core.NewModuleOptions{
+			Providers: []core.Providers{
+				conn("db"),
+				conn("pool", "db"),
+				func(module core.Module) core.Provider {
+					return module.NewProvider(core.ProviderOptions{
+						Name: "store",
+						Factory: func(param ...interface{}) interface{} {
+							panic("store unavailable")
+						},
+						Inject: []core.Provide{"pool"},
+					})
+				},
+			},
+		})
+	}
+
+	app := core.CreateFactory(func() core.Module {
+		return core.NewModule(core.NewModuleOptions{})
+	})
+	loader := app.Module.Ref(core.LAZY_MODULE_LOADER).(*core.LazyModuleLoader)
+
+	_, err := loader.Load(storeModule)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "store unavailable")
+	// The connections created before the failure are closed in reverse order.
+	require.Equal(t, []string{"pool", "db"}, closed)
+}
@@ -0,0 +1,180 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"syscall"
+)
+
+// OnModuleInit is implemented by providers that need to run initialization
+// logic once every provider of the application has been created.
+type OnModuleInit interface {
+	OnModuleInit() error
+}
+
+// OnApplicationBootstrap is implemented by providers that need to run logic
+// once every module has been initialized.
+type OnApplicationBootstrap interface {
+	OnApplicationBootstrap() error
+}
+
+// OnModuleDestroy is implemented by providers that need to release resources
+// (connections, pools, files) when the application shuts down.
+type OnModuleDestroy interface {
+	OnModuleDestroy() error
+}
+
+// BeforeApplicationShutdown is implemented by providers that need to run logic
+// when a shutdown signal is received, before the server stops accepting
+// connections.
+type BeforeApplicationShutdown interface {
+	BeforeApplicationShutdown(signal string) error
+}
+
+// OnApplicationShutdown is implemented by providers that need to run logic
+// after the server has stopped and every module has been destroyed.
+type OnApplicationShutdown interface {
+	OnApplicationShutdown(signal string) error
+}
+
+// lifecycleValues returns the singleton values of the application in the
+// order they were created, so a provider comes after the providers it
+// injects. Request-scoped and transient providers are handled when their
+// instances are created.
+func (app *App) lifecycleValues() []interface{} {
+	dynMod, ok := app.Module.(*DynamicModule)
+	if !ok {
+		return nil
+	}
+	dynMod.graphMu.RLock()
+	order := slices.Clone(dynMod.order)
+	dynMod.graphMu.RUnlock()
+	return singletonValues(order)
+}
+
+// singletonValues returns the values of the singletons among the providers,
+// each value once.
+func singletonValues(providers []Provider) []interface{} {
+	seen := make(map[Provider]bool)
+	var values []interface{}
+	for _, p := range providers {
+		if seen[p] || p.GetScope() == Request || p.GetScope() == Transient || p.GetValue() == nil {
+			continue
+		}
+		seen[p] = true
+		values = append(values, p.GetValue())
+	}
+	return values
+}
+
+// initLifecycle calls OnModuleInit then OnApplicationBootstrap on every
+// provider implementing them. Errors of all providers are aggregated.
+func (app *App) initLifecycle() error {
+	return initValues(app.lifecycleValues())
+}
+
+// initValues calls OnModuleInit then OnApplicationBootstrap on the values
+// implementing them.
+func initValues(values []interface{}) error {
+	var errs []error
+	for _, v := range values {
+		if hook, ok := v.(OnModuleInit); ok {
+			if err := hook.OnModuleInit(); err != nil {
+				errs = append(errs, fmt.Errorf("OnModuleInit %T: %w", v, err))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	for _, v := range values {
+		if hook, ok := v.(OnApplicationBootstrap); ok {
+			if err := hook.OnApplicationBootstrap(); err != nil {
+				errs = append(errs, fmt.Errorf("OnApplicationBootstrap %T: %w", v, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// beforeShutdown calls BeforeApplicationShutdown on every provider in reverse
+// dependency order.
+func (app *App) beforeShutdown(signal string) error {
+	values := app.lifecycleValues()
+
+	var errs []error
+	for i := len(values) - 1; i >= 0; i-- {
+		if hook, ok := values[i].(BeforeApplicationShutdown); ok {
+			if err := hook.BeforeApplicationShutdown(signal); err != nil {
+				errs = append(errs, fmt.Errorf("BeforeApplicationShutdown %T: %w", values[i], err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Shutdown calls OnModuleDestroy then OnApplicationShutdown on every provider
+// in reverse dependency order, so a provider is destroyed before the
+// providers it depends on. Every hook is called even if a previous one
+// failed, and the errors are aggregated.
+//
+// Listen calls Shutdown once the server has stopped. It can be called directly
+// when the App is served by other means.
+func (app *App) Shutdown(signal string) error {
+	values := app.lifecycleValues()
+
+	var errs []error
+	for i := len(values) - 1; i >= 0; i-- {
+		if hook, ok := values[i].(OnModuleDestroy); ok {
+			if err := hook.OnModuleDestroy(); err != nil {
+				errs = append(errs, fmt.Errorf("OnModuleDestroy %T: %w", values[i], err))
+			}
+		}
+	}
+
+	for i := len(values) - 1; i >= 0; i-- {
+		if hook, ok := values[i].(OnApplicationShutdown); ok {
+			if err := hook.OnApplicationShutdown(signal); err != nil {
+				errs = append(errs, fmt.Errorf("OnApplicationShutdown %T: %w", values[i], err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// initInstance calls OnModuleInit on a request-scoped or transient instance
+// right after it is created.
+func initInstance(value interface{}) error {
+	if hook, ok := value.(OnModuleInit); ok {
+		return hook.OnModuleInit()
+	}
+	return nil
+}
+
+// destroyInstances calls OnModuleDestroy on request-scoped instances in
+// reverse creation order once the request is handled.
+func destroyInstances(values []interface{}) {
+	for i := len(values) - 1; i >= 0; i-- {
+		if hook, ok := values[i].(OnModuleDestroy); ok {
+			if err := hook.OnModuleDestroy(); err != nil {
+				log.Printf("error when destroying request provider %T: %v", values[i], err)
+			}
+		}
+	}
+}
+
+// signalName returns the conventional name of the signal (SIGINT, SIGTERM).
+func signalName(sig os.Signal) string {
+	switch sig {
+	case syscall.SIGINT:
+		return "SIGINT"
+	case syscall.SIGTERM:
+		return "SIGTERM"
+	default:
+		return sig.String()
+	}
+}
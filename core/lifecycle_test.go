@@ -0,0 +1,155 @@
+package core_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tinh-tinh/tinhtinh/v2/core"
+)
+
+type lifecycleService struct {
+	name    string
+	events  *[]string
+	failing map[string]bool
+}
+
+func (s *lifecycleService) OnModuleInit() error {
+	*s.events = append(*s.events, s.name+":init")
+	if s.failing[s.name] {
+		return errors.New(s.name + " init failed")
+	}
+	return nil
+}
+
+func (s *lifecycleService) OnApplicationBootstrap() error {
+	*s.events = append(*s.events, s.name+":bootstrap")
+	return nil
+}
+
+func (s *lifecycleService) OnModuleDestroy() error {
+	*s.events = append(*s.events, s.name+":destroy")
+	if s.failing[s.name] {
+		return errors.New(s.name + " destroy failed")
+	}
+	return nil
+}
+
+func (s *lifecycleService) OnApplicationShutdown(signal string) error {
+	*s.events = append(*s.events, s.name+":shutdown:"+signal)
+	return nil
+}
+
+func lifecycleApp(events *[]string, failing map[string]bool) func() core.Module {
+	dbModule := func(module core.Module) core.Module {
+		db := module.New(core.NewModuleOptions{})
+		db.NewProvider(core.ProviderOptions{
+			Name:  "db",
+			Value: &lifecycleService{name: "db", events: events, failing: failing},
+		})
+		db.Export("db")
+		return db
+	}
+
+	userModule := func(module core.Module) core.Module {
+		user := module.New(core.NewModuleOptions{
+			Imports: []core.Modules{dbModule},
+		})
+		user.NewProvider(core.ProviderOptions{
+			Name: "user",
+			Factory: func(param ...interface{}) interface{} {
+				return &lifecycleService{name: "user", events: events, failing: failing}
+			},
+			Inject: []core.Provide{"db"},
+		})
+		return user
+	}
+
+	return func() core.Module {
+		return core.NewModule(core.NewModuleOptions{
+			Imports: []core.Modules{userModule},
+		})
+	}
+}
+
+func Test_Lifecycle(t *testing.T) {
+	var events []string
+	app := core.CreateFactory(lifecycleApp(&events, nil))
+	require.Equal(t, []string{"db:init", "user:init", "db:bootstrap", "user:bootstrap"}, events)
+
+	events = nil
+	require.Nil(t, app.Shutdown("SIGTERM"))
+	require.Equal(t, []string{
+		"user:destroy", "db:destroy",
+		"user:shutdown:SIGTERM", "db:shutdown:SIGTERM",
+	}, events)
+}
+
+func Test_Lifecycle_InitError(t *testing.T) {
+	var events []string
+	require.PanicsWithError(t, "OnModuleInit *core_test.lifecycleService: db init failed\nOnModuleInit *core_test.lifecycleService: user init failed", func() {
+		_ = core.CreateFactory(lifecycleApp(&events, map[string]bool{"db": true, "user": true}))
+	})
+	require.NotContains(t, events, "db:bootstrap")
+}
+
+func Test_Lifecycle_ShutdownError(t *testing.T) {
+	var events []string
+	failing := map[string]bool{}
+	app := core.CreateFactory(lifecycleApp(&events, failing))
+
+	failing["db"] = true
+	failing["user"] = true
+	events = nil
+	err := app.Shutdown("SIGINT")
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "db destroy failed")
+	require.Contains(t, err.Error(), "user destroy failed")
+	require.Contains(t, events, "db:shutdown:SIGINT")
+}
+
+func Test_Lifecycle_Request(t *testing.T) {
+	var events []string
+	appModule := func() core.Module {
+		scoped := func(module core.Module) core.Provider {
+			return module.NewProvider(core.ProviderOptions{
+				Name:  "scoped",
+				Scope: core.Request,
+				Factory: func(param ...interface{}) interface{} {
+					return &lifecycleService{name: "scoped", events: &events}
+				},
+			})
+		}
+		module := core.NewModule(core.NewModuleOptions{
+			Providers: []core.Providers{scoped},
+		})
+		module.NewProvider(core.ProviderOptions{
+			Name:  "transient",
+			Scope: core.Transient,
+			Factory: func(param ...interface{}) interface{} {
+				return &lifecycleService{name: "transient", events: &events}
+			},
+		})
+
+		ctrl := module.NewController("test")
+		ctrl.Get("", func(ctx core.Ctx) error {
+			ctrl.Ref("transient")
+			events = append(events, "handler")
+			return ctx.JSON(core.Map{"data": "ok"})
+		})
+		return module
+	}
+
+	app := core.CreateFactory(appModule)
+	require.Empty(t, events)
+
+	testServer := httptest.NewServer(app.PrepareBeforeListen())
+	defer testServer.Close()
+
+	resp, err := testServer.Client().Get(testServer.URL + "/test")
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, []string{"scoped:init", "transient:init", "handler", "scoped:destroy"}, events)
+}
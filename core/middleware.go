@@ -3,6 +3,9 @@ package core
 import (
 	"fmt"
 	"net/http"
+	"reflect"
+
+	"github.com/tinh-tinh/tinhtinh/v2/common"
 )
 
 type (
@@ -17,7 +20,12 @@ type RefProvider interface {
 // ParseCtxMiddleware wraps a Middleware function and returns a middlewareRaw
 // that can be used by http server. It provides a Ctx instance to the wrapped
 // middleware function and automatically sets the handler of the Ctx instance.
+//
+// The middleware runs as a middleware stage of the request, named after the
+// function, unless it is built from a guard or a pipe.
 func ParseCtxMiddleware(app *App, ctxMid Middleware, router *Router) middlewareRaw {
+	name := common.GetFunctionName(ctxMid)
+	observed := !selfObserved[reflect.ValueOf(ctxMid).Pointer()]
 	return func(h http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			ctx := app.pool.Get().(*DefaultCtx)
@@ -32,7 +40,13 @@ func ParseCtxMiddleware(app *App, ctxMid Middleware, router *Router) middlewareR
 					app.errorHandler(err, ctx)
 				}
 			}()
-			err = ctxMid(ctx)
+			if observed {
+				err = runStage(ctx, StageMiddleware, name, func() error {
+					return ctxMid(ctx)
+				})
+			} else {
+				err = ctxMid(ctx)
+			}
 			if err != nil {
 				app.errorHandler(err, ctx)
 				return
@@ -10,9 +10,27 @@ import (
 	"github.com/tinh-tinh/tinhtinh/v2/common/color"
 )
 
+// DocRoute is a lightweight description of a registered route. The App keeps
+// one per route after the routers are freed so the API can still be
+// documented and inspected.
 type DocRoute struct {
-	Dto      []PipeDto
+	// Name of controller own the route
+	Name string
+	// Method of route
+	Method string
+	// Path of route as declared in the controller
+	Path string
+	// Pattern is the full pattern registered with the Mux, including the
+	// global prefix and the URI version.
+	Pattern string
+	// Version of route
+	Version string
+	// Dto of route
+	Dto []PipeDto
+	// Security schemes required by the route
 	Security []string
+	// Metadata of route
+	Metadata []*Metadata
 }
 
 type Scope string
@@ -30,6 +48,7 @@ type Module interface {
 	Providers(providers ...Providers) Module
 	Export(name Provide) Provider
 	Ref(name Provide, ctx ...Ctx) interface{}
+	RefTagged(tag string, ctx ...Ctx) []interface{}
 	findIdx(name Provide) int
 	init()
 	GetRouters() []*Router
@@ -57,6 +76,55 @@ type DynamicModule struct {
 	SubModules      []*DynamicModule
 	hooks           []HookModule
 	interceptor     Interceptor
+	// guards is the number of guards in Middlewares.
+	guards int
+	// parent is the module that created this module with New.
+	parent *DynamicModule
+	// app is the App created from the root module.
+	app *App
+	// importing is the name of the module being imported by this module.
+	importing string
+	// overrides are the provider overrides of the module tree, set on the
+	// root module when NewModule is called by CreateFactory.
+	overrides map[Provide]ProviderOverride
+	// pending maps the providers not resolved yet to their module, resolving
+	// is the stack of providers being resolved and order lists the providers
+	// in creation order. They are set on the root module.
+	pending   map[Provider]*DynamicModule
+	queue     []Provider
+	resolving []Provider
+	order     []Provider
+	// global reports whether the exports of the module are visible to every
+	// module of the tree. globals are the exports of the global modules, set
+	// on the root module.
+	global  bool
+	globals []Provider
+	// reExports are the names of the imported modules whose exports are
+	// exported again by the module, and reExported the providers exported
+	// again.
+	reExports  []string
+	reExported []Provider
+	// encapsulated reports whether the exports of the modules only imported
+	// are not exported by the module.
+	encapsulated bool
+	// disabled reports whether the module is a conditional module whose
+	// predicate returned false.
+	disabled bool
+	// owners maps each provider to the module registering it. It is set on
+	// the root module.
+	owners map[Provider]*DynamicModule
+	// multi marks the multi-providers and tagged lists the tagged providers
+	// with their tags, in registration order. They are set on the root module.
+	multi  map[Provider]bool
+	tagged []taggedProvider
+	// fields describes the providers registered from a struct with injected
+	// fields. It is set on the root module.
+	fields map[Provider]*fieldProvider
+	// graphMu guards the maps of the root module read when referencing a
+	// provider, and lazy is the loader of the lazy modules. They are set on
+	// the root module.
+	graphMu sync.RWMutex
+	lazy    *LazyModuleLoader
 }
 
 type (
@@ -73,6 +141,26 @@ type NewModuleOptions struct {
 	Guards      []Guard
 	Middlewares []Middleware
 	Interceptor Interceptor
+	// Global makes the exports of the module visible to every module of the
+	// application, whatever the import order. A global module only needs to be
+	// imported once, usually by the root module.
+	Global bool
+	// Exports re-exports whole imported modules: their exports become
+	// exports of this module, so the modules importing this one can inject
+	// them. A module listed here and not in Imports is imported too.
+	Exports []Modules
+	// Encapsulated stops the exports of the modules this module only imports
+	// at this module: it exports its own public providers and those of the
+	// modules it re-exports, with Exports or Export. By default, the exports
+	// of the imported modules are exported too.
+	//
+	// To migrate a module to Encapsulated, list in Exports the imported
+	// modules whose providers its importers inject.
+	Encapsulated bool
+	// ImportsFunc returns more modules to import. It is called when the
+	// module is initialized, after Imports, so the modules can be chosen from
+	// the environment or from the providers imported so far.
+	ImportsFunc func(module Module) []Modules
 }
 
 // NewModule creates a new module with the given options.
@@ -83,7 +171,7 @@ func NewModule(opt NewModuleOptions) Module {
 	if opt.Scope == "" {
 		opt.Scope = Global
 	}
-	module := &DynamicModule{isRoot: true, Name: "AppModule"}
+	module := &DynamicModule{isRoot: true, Name: "AppModule", overrides: buildOverrides()}
 	initModule(module, opt)
 
 	return module
@@ -100,9 +188,10 @@ func (m *DynamicModule) New(opt NewModuleOptions) Module {
 	if opt.Scope == "" {
 		opt.Scope = Global
 	}
-	newMod := &DynamicModule{isRoot: false}
-	newMod.DataProviders = append(newMod.DataProviders, m.GetExports()...)
+	newMod := &DynamicModule{isRoot: false, parent: m, Name: m.importing}
+	newMod.DataProviders = append(newMod.DataProviders, m.visibleProviders()...)
 	newMod.Middlewares = append(newMod.Middlewares, m.Middlewares...)
+	newMod.guards = m.guards
 	if newMod.interceptor == nil {
 		newMod.interceptor = m.interceptor
 	}
@@ -123,6 +212,7 @@ func (m *DynamicModule) New(opt NewModuleOptions) Module {
 // the providers that are injected with the request to nil.
 func initModule(module *DynamicModule, opt NewModuleOptions) {
 	module.Scope = opt.Scope
+	module.encapsulated = opt.Encapsulated
 	// Parse middleware
 	module.Middlewares = append(module.Middlewares, opt.Middlewares...)
 
@@ -133,6 +223,7 @@ func initModule(module *DynamicModule, opt NewModuleOptions) {
 		}
 		mid := module.ParseGuard(g)
 		module.Middlewares = append(module.Middlewares, mid)
+		module.guards++
 	}
 
 	// Parse interceptor
@@ -140,30 +231,40 @@ func initModule(module *DynamicModule, opt NewModuleOptions) {
 		module.interceptor = opt.Interceptor
 	}
 
+	module.global = opt.Global
+
 	// Imports
 	for _, m := range opt.Imports {
 		if m == nil {
 			continue
 		}
-		name := common.GetFunctionName(m)
-		mod := m(module)
-		fmt.Printf("%s %s %s %s\n",
-			color.Green("[TT]"),
-			color.White(time.Now().Format("2006-01-02 15:04:05")),
-			color.Yellow("[Module Initializer]"),
-			color.Green(name),
-		)
-		if dynMod, ok := mod.(*DynamicModule); ok {
-			dynMod.Name = name
-			// Snapshot own routers before free() clears them.
-			dynMod.SnapshotRouters = append([]*Router(nil), dynMod.Routers...)
-			module.SubModules = append(module.SubModules, dynMod)
+		module.importModule(m)
+	}
+	if opt.ImportsFunc != nil {
+		for _, m := range opt.ImportsFunc(module) {
+			if m == nil {
+				continue
+			}
+			module.importModule(m)
 		}
+	}
 
-		mod.init()
-		module.Routers = append(module.Routers, mod.GetRouters()...)
-		module.appendProvider(mod.GetExports()...)
-		mod.free()
+	// Re-exports
+	for _, m := range opt.Exports {
+		if m == nil {
+			continue
+		}
+		name := common.GetFunctionName(m)
+		isImported := func(sub *DynamicModule) bool {
+			return sub.Name == name
+		}
+		if !slices.ContainsFunc(module.SubModules, isImported) {
+			module.importModule(m)
+		}
+		if idx := slices.IndexFunc(module.SubModules, isImported); idx != -1 {
+			module.reExported = append(module.reExported, module.SubModules[idx].GetExports()...)
+		}
+		module.reExports = append(module.reExports, name)
 	}
 
 	// Providers
@@ -174,8 +275,9 @@ func initModule(module *DynamicModule, opt NewModuleOptions) {
 		p(module)
 	}
 
+	// A field provider may become request-scoped once resolved.
 	isRequest := slices.ContainsFunc(module.DataProviders, func(e Provider) bool {
-		return e.GetScope() == Request
+		return e.GetScope() == Request || module.fieldProvider(e) != nil
 	})
 
 	if module.Scope == Request || isRequest {
@@ -191,6 +293,55 @@ func initModule(module *DynamicModule, opt NewModuleOptions) {
 	}
 }
 
+// importModule builds the imported module as a sub-module of the module and
+// makes its routers and exports available to the module. The exports of a
+// global module are made available to the whole tree. A disabled conditional
+// module is only added to the sub-modules.
+func (module *DynamicModule) importModule(m Modules) {
+	module.importing = common.GetFunctionName(m)
+	mod := m(module)
+	// A conditional module renames the module being imported.
+	name := module.importing
+	module.importing = ""
+	if dynMod, ok := mod.(*DynamicModule); ok && dynMod.disabled {
+		fmt.Printf("%s %s %s %s\n",
+			color.Green("[TT]"),
+			color.White(time.Now().Format("2006-01-02 15:04:05")),
+			color.Yellow("[Module Initializer]"),
+			color.Gray(name+" (disabled)"),
+		)
+		module.SubModules = append(module.SubModules, dynMod)
+		return
+	}
+	fmt.Printf("%s %s %s %s\n",
+		color.Green("[TT]"),
+		color.White(time.Now().Format("2006-01-02 15:04:05")),
+		color.Yellow("[Module Initializer]"),
+		color.Green(name),
+	)
+	if dynMod, ok := mod.(*DynamicModule); ok {
+		dynMod.Name = name
+		// Snapshot own routers before free() clears them.
+		dynMod.SnapshotRouters = append([]*Router(nil), dynMod.Routers...)
+		module.SubModules = append(module.SubModules, dynMod)
+		if dynMod.global {
+			root := module.root()
+			for _, p := range dynMod.GetExports() {
+				if root.ownerOf(p, nil) == dynMod {
+					root.graphMu.Lock()
+					root.globals = append(root.globals, p)
+					root.graphMu.Unlock()
+				}
+			}
+		}
+	}
+
+	mod.init()
+	module.Routers = append(module.Routers, mod.GetRouters()...)
+	module.appendProvider(mod.GetExports()...)
+	mod.free()
+}
+
 // Controllers registers the given controllers with the module.
 // The controllers are registered in the order they are given.
 func (m *DynamicModule) Controllers(controllers ...Controllers) Module {
@@ -216,14 +367,36 @@ func (m *DynamicModule) Ref(name Provide, ctx ...Ctx) interface{} {
 	if name == REQUEST {
 		return ctx[0].Req()
 	}
-	idx := slices.IndexFunc(m.DataProviders, func(e Provider) bool {
-		return e.GetName() == name
-	})
-	if idx == -1 {
+	if name == APP {
+		if app := m.root().app; app != nil {
+			return app
+		}
+		return nil
+	}
+	if name == DISCOVERY {
+		return &DiscoveryService{root: m.root()}
+	}
+	if name == LAZY_MODULE_LOADER {
+		return m.root().lazyLoader()
+	}
+	if tag, ok := tagOf(name); ok {
+		return m.RefTagged(tag, ctx...)
+	}
+	prd := m.lookup(name)
+	if prd == nil {
 		return nil
 	}
+	if m.isMulti(prd) {
+		return m.refMulti(name, ctx...)
+	}
+	return m.refProvider(prd, ctx...)
+}
 
-	prd := m.DataProviders[idx]
+// refProvider returns the value of the provider visible from the module,
+// creating a new instance for transient providers.
+func (m *DynamicModule) refProvider(prd Provider, ctx ...Ctx) interface{} {
+	name := prd.GetName()
+	m.resolvePending(prd)
 	if prd.GetScope() == Request {
 		if len(ctx) == 0 {
 			panic("request provider need ctx as parameters")
@@ -234,11 +407,23 @@ func (m *DynamicModule) Ref(name Provide, ctx ...Ctx) interface{} {
 		for _, p := range prd.GetInject() {
 			values = append(values, m.Ref(p))
 		}
-		return prd.GetFactory()(values...)
+		value := prd.GetFactory()(values...)
+		if err := initInstance(value); err != nil {
+			panic(fmt.Sprintf("init transient provider %s: %v", name, err))
+		}
+		return value
 	}
 	return prd.GetValue()
 }
 
+// root returns the root module of the tree the module belongs to.
+func (m *DynamicModule) root() *DynamicModule {
+	for m.parent != nil {
+		m = m.parent
+	}
+	return m
+}
+
 func (m *DynamicModule) findIdx(name Provide) int {
 	idx := slices.IndexFunc(m.DataProviders, func(e Provider) bool {
 		return e.GetName() == name
@@ -247,13 +432,32 @@ func (m *DynamicModule) findIdx(name Provide) int {
 }
 
 // Export sets the status of the provider with the given name to PUBLIC and returns
-// the provider.
+// the provider. For multi-providers, every provider of the collection
+// registered by the module is exported.
 func (m *DynamicModule) Export(name Provide) Provider {
 	idx := slices.IndexFunc(m.DataProviders, func(e Provider) bool {
 		return e.GetName() == name
 	})
-	m.DataProviders[idx].SetStatus(PUBLIC)
-	return m.DataProviders[idx]
+	root := m.root()
+	if root.isMulti(m.DataProviders[idx]) {
+		var exported Provider
+		for _, p := range m.DataProviders {
+			if p.GetName() == name && root.ownerOf(p, nil) == m {
+				p.SetStatus(PUBLIC)
+				exported = p
+			}
+		}
+		if exported != nil {
+			return exported
+		}
+	}
+	provider := m.DataProviders[idx]
+	if owner := root.ownerOf(provider, m); owner != m && !slices.Contains(m.reExported, provider) {
+		// A provider of an imported module is exported again.
+		m.reExported = append(m.reExported, provider)
+	}
+	provider.SetStatus(PUBLIC)
+	return provider
 }
 
 func (m *DynamicModule) GetRouters() []*Router {
@@ -266,16 +470,40 @@ func (m *DynamicModule) free() {
 
 func requestMiddleware(module *DynamicModule) Middleware {
 	return func(ctx Ctx) error {
-		for _, p := range module.getRequest() {
-			if p.GetValue() == nil {
-				var values []interface{}
-				for _, p := range p.GetInject() {
-					values = append(values, module.Ref(p, ctx))
+		var instances []interface{}
+		defer func() { destroyInstances(instances) }()
+
+		// The request-scoped dependencies of a provider are created before
+		// it, whatever their declaration order.
+		created := make(map[Provider]bool)
+		var create func(p Provider) error
+		create = func(p Provider) error {
+			if created[p] || p.GetValue() != nil {
+				return nil
+			}
+			created[p] = true
+			var values []interface{}
+			for _, name := range p.GetInject() {
+				if dep := module.lookup(name); dep != nil && dep.GetScope() == Request {
+					if err := create(dep); err != nil {
+						return err
+					}
 				}
+				values = append(values, module.Ref(name, ctx))
+			}
 
-				factory := p.GetFactory()
-				value := factory(values...)
-				ctx.Set(p.GetName(), value)
+			factory := p.GetFactory()
+			value := factory(values...)
+			instances = append(instances, value)
+			if err := initInstance(value); err != nil {
+				return err
+			}
+			ctx.Set(p.GetName(), value)
+			return nil
+		}
+		for _, p := range module.getRequest() {
+			if err := create(p); err != nil {
+				return err
 			}
 		}
 		return ctx.Next()
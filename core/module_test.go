@@ -376,3 +376,25 @@ func Test_PassMiddlewareModule(t *testing.T) {
 	require.Nil(t, err)
 	require.Equal(t, "1", res.Data)
 }
+
+func Test_Ref_App(t *testing.T) {
+	childModule := func(module core.Module) core.Module {
+		return module.New(core.NewModuleOptions{})
+	}
+
+	var child core.Module
+	appModule := func() core.Module {
+		module := core.NewModule(core.NewModuleOptions{
+			Imports: []core.Modules{func(module core.Module) core.Module {
+				child = childModule(module)
+				return child
+			}},
+		})
+		require.Nil(t, module.Ref(core.APP))
+		return module
+	}
+
+	app := core.CreateFactory(appModule)
+	require.Equal(t, app, app.Module.Ref(core.APP))
+	require.Equal(t, app, child.Ref(core.APP))
+}
@@ -0,0 +1,124 @@
+package core
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// tagPrefix prefixes the tokens returned by Tagged.
+const tagPrefix = "#tag:"
+
+type taggedProvider struct {
+	provider Provider
+	tags     []string
+}
+
+// Tagged returns a token resolving to the values of every provider with the
+// tag, so they can be injected in a factory:
+//
+//	module.NewProvider(core.ProviderOptions{
+//		Name:    "registry",
+//		Factory: func(param ...interface{}) interface{} {
+//			return NewRegistry(param[0].([]interface{}))
+//		},
+//		Inject: []core.Provide{core.Tagged("listener")},
+//	})
+func Tagged(tag string) Provide {
+	return Provide(tagPrefix + tag)
+}
+
+// tagOf returns the tag of a token created by Tagged.
+func tagOf(name Provide) (string, bool) {
+	return strings.CutPrefix(string(name), tagPrefix)
+}
+
+// registerMeta records the multi-provider and tags options of the provider.
+func registerMeta(module Module, provider Provider, opt ProviderOptions) {
+	dynMod, ok := module.(*DynamicModule)
+	if !ok || (!opt.Multi && len(opt.Tags) == 0) {
+		return
+	}
+	root := dynMod.root()
+	root.graphMu.Lock()
+	defer root.graphMu.Unlock()
+	if opt.Multi {
+		if root.multi == nil {
+			root.multi = make(map[Provider]bool)
+		}
+		root.multi[provider] = true
+	}
+	if len(opt.Tags) > 0 {
+		root.tagged = append(root.tagged, taggedProvider{provider: provider, tags: opt.Tags})
+	}
+}
+
+// refMulti returns the values of the multi-providers registered under the
+// name and visible from the module, in registration order.
+func (m *DynamicModule) refMulti(name Provide, ctx ...Ctx) []interface{} {
+	root := m.root()
+	var providers []Provider
+	root.graphMu.RLock()
+	globals := slices.Clone(root.globals)
+	root.graphMu.RUnlock()
+	for _, p := range append(slices.Clone(m.DataProviders), globals...) {
+		if p.GetName() == name && root.isMulti(p) && !slices.Contains(providers, p) {
+			providers = append(providers, p)
+		}
+	}
+
+	values := make([]interface{}, 0, len(providers))
+	for _, p := range providers {
+		values = append(values, root.ownerOf(p, m).refProvider(p, ctx...))
+	}
+	return values
+}
+
+// RefTagged returns the values of the providers with the tag, across the
+// whole application and in registration order. Like Ref, request-scoped
+// providers need the ctx of the request.
+func (m *DynamicModule) RefTagged(tag string, ctx ...Ctx) []interface{} {
+	root := m.root()
+	values := []interface{}{}
+	for _, t := range root.taggedProviders() {
+		if slices.Contains(t.tags, tag) {
+			values = append(values, root.ownerOf(t.provider, m).refProvider(t.provider, ctx...))
+		}
+	}
+	return values
+}
+
+// ownerOf returns the module registering the provider, or the fallback if
+// it is unknown.
+func (m *DynamicModule) ownerOf(provider Provider, fallback *DynamicModule) *DynamicModule {
+	m.graphMu.RLock()
+	defer m.graphMu.RUnlock()
+	if owner := m.owners[provider]; owner != nil {
+		return owner
+	}
+	return fallback
+}
+
+// isMulti reports whether the provider is a multi-provider.
+func (m *DynamicModule) isMulti(provider Provider) bool {
+	root := m.root()
+	root.graphMu.RLock()
+	defer root.graphMu.RUnlock()
+	return root.multi[provider]
+}
+
+// taggedProviders returns a copy of the tagged providers of the tree.
+func (m *DynamicModule) taggedProviders() []taggedProvider {
+	root := m.root()
+	root.graphMu.RLock()
+	defer root.graphMu.RUnlock()
+	return slices.Clone(root.tagged)
+}
+
+// checkMulti panics if the multi-provider is request-scoped, as the values of
+// the request are stored by name.
+func checkMulti(provider Provider, opt ProviderOptions) {
+	if opt.Multi && provider.GetScope() == Request {
+		panic(fmt.Sprintf("multi provider %s cannot be request-scoped", opt.Name))
+	}
+}
@@ -0,0 +1,103 @@
+package core_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tinh-tinh/tinhtinh/v2/core"
+)
+
+func listenerModule(name string) core.Modules {
+	return func(module core.Module) core.Module {
+		listener := module.New(core.NewModuleOptions{})
+		listener.NewProvider(core.ProviderOptions{
+			Name:  "listeners",
+			Multi: true,
+			Tags:  []string{"plugin"},
+			Value: name,
+		})
+		listener.Export("listeners")
+		return listener
+	}
+}
+
+func Test_MultiProvider(t *testing.T) {
+	appModule := func() core.Module {
+		module := core.NewModule(core.NewModuleOptions{
+			Imports: []core.Modules{listenerModule("a"), listenerModule("b")},
+		})
+		module.NewProvider(core.ProviderOptions{
+			Name:  "listeners",
+			Multi: true,
+			Factory: func(param ...interface{}) interface{} {
+				return "c"
+			},
+		})
+		module.NewProvider(core.ProviderOptions{
+			Name: "bus",
+			Factory: func(param ...interface{}) interface{} {
+				return param[0]
+			},
+			Inject: []core.Provide{"listeners"},
+		})
+		return module
+	}
+
+	app := core.CreateFactory(appModule)
+	require.Equal(t, []interface{}{"a", "b", "c"}, app.Module.Ref("listeners"))
+	require.Equal(t, []interface{}{"a", "b", "c"}, app.Module.Ref("bus"))
+}
+
+func Test_MultiProvider_Request(t *testing.T) {
+	require.PanicsWithValue(t, "multi provider tenant cannot be request-scoped", func() {
+		module := core.NewModule(core.NewModuleOptions{})
+		module.NewProvider(core.ProviderOptions{
+			Name:  "tenant",
+			Multi: true,
+			Scope: core.Request,
+			Factory: func(param ...interface{}) interface{} {
+				return "tenant"
+			},
+		})
+	})
+}
+
+func Test_TaggedProviders(t *testing.T) {
+	private := func(module core.Module) core.Module {
+		mod := module.New(core.NewModuleOptions{})
+		mod.NewProvider(core.ProviderOptions{
+			Name:  "private",
+			Tags:  []string{"plugin", "other"},
+			Value: "private",
+		})
+		return mod
+	}
+
+	appModule := func() core.Module {
+		module := core.NewModule(core.NewModuleOptions{
+			Imports: []core.Modules{listenerModule("a"), private},
+		})
+		// Declared before the tagged providers it collects.
+		module.NewProvider(core.ProviderOptions{
+			Name: "registry",
+			Factory: func(param ...interface{}) interface{} {
+				return param[0]
+			},
+			Inject: []core.Provide{core.Tagged("plugin")},
+		})
+		module.NewProvider(core.ProviderOptions{
+			Name: "late",
+			Tags: []string{"plugin"},
+			Factory: func(param ...interface{}) interface{} {
+				return "late"
+			},
+		})
+		return module
+	}
+
+	app := core.CreateFactory(appModule)
+	require.Equal(t, []interface{}{"a", "private", "late"}, app.Module.Ref("registry"))
+	require.Equal(t, []interface{}{"private"}, app.Module.RefTagged("other"))
+	require.Equal(t, []interface{}{}, app.Module.RefTagged("unknown"))
+	require.Equal(t, []interface{}{"a", "private", "late"}, app.Module.Ref(core.Tagged("plugin")))
+}
@@ -0,0 +1,243 @@
+package core
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/tinh-tinh/tinhtinh/v2/common/openapi"
+)
+
+// Metadata keys read by OpenAPI to enrich the operation of a route. They can
+// be set per route with Metadata, or for every route of a controller with
+// Metadata followed by Registry.
+const (
+	// DOC_SECURITY holds the security scheme names ([]string) required by the route.
+	DOC_SECURITY = "DOC_SECURITY"
+	// DOC_SUMMARY holds the summary (string) of the operation.
+	DOC_SUMMARY = "DOC_SUMMARY"
+	// DOC_DESCRIPTION holds the description (string) of the operation.
+	DOC_DESCRIPTION = "DOC_DESCRIPTION"
+	// DOC_DEPRECATED marks the operation as deprecated (bool).
+	DOC_DEPRECATED = "DOC_DEPRECATED"
+	// DOC_RESPONSE documents a response (DocResponse). It can be set several
+	// times on the same route.
+	DOC_RESPONSE = "DOC_RESPONSE"
+	// DOC_EXCLUDE hides the route from the document (bool).
+	DOC_EXCLUDE = "DOC_EXCLUDE"
+)
+
+// DocResponse documents a response of a route for the DOC_RESPONSE metadata.
+type DocResponse struct {
+	Status      int
+	Description string
+	// Example is an example body. Its type is reflected into the response schema.
+	Example interface{}
+}
+
+var wildcardParam = regexp.MustCompile(`\{([^{}]+)\.\.\.\}`)
+
+// OpenAPI builds an OpenAPI 3.1 document from the routes registered in the
+// module tree.
+//
+// Each route becomes an operation tagged with its controller name. The body,
+// query and path dtos registered with Pipe are reflected into JSON Schema
+// using their json, query and path tags, and their validate rules are mapped
+// to the matching keywords (required, minLength, format, ...). URI versions
+// are part of the documented paths, while header versions are documented as a
+// required header parameter.
+//
+// OpenAPI can be called before or after the routes are registered.
+func (app *App) OpenAPI(opts ...openapi.Options) *openapi.Document {
+	var opt openapi.Options
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	doc := openapi.New(opt)
+
+	for _, route := range app.Routes() {
+		if route.Method == "" || isExcluded(route) {
+			continue
+		}
+		r := ParseRoute(route.Pattern)
+		path := wildcardParam.ReplaceAllString(r.Path, "{$1}")
+		if path == "" {
+			path = "/"
+		}
+		method := strings.ToLower(route.Method)
+
+		op := &openapi.Operation{
+			OperationID: operationID(route),
+			Responses:   map[string]*openapi.Response{},
+		}
+		if route.Name != "" {
+			op.Tags = []string{route.Name}
+			doc.AddTag(route.Name)
+		}
+		if len(route.Security) > 0 {
+			op.Security = openapi.Requirements(route.Security)
+		}
+		applyDocMetadata(doc, op, route.Metadata)
+		if len(op.Responses) == 0 {
+			op.Responses["200"] = &openapi.Response{Description: "OK"}
+		}
+
+		for _, name := range pathParams(path) {
+			op.Parameters = append(op.Parameters, &openapi.Parameter{
+				Name:     name,
+				In:       "path",
+				Required: true,
+				Schema:   &openapi.Schema{Type: "string"},
+			})
+		}
+
+		for _, dto := range route.Dto {
+			t := reflect.TypeOf(dto.GetValue())
+			switch dto.GetLocation() {
+			case InBody:
+				op.RequestBody = &openapi.RequestBody{
+					Required: true,
+					Content: map[string]*openapi.MediaType{
+						"application/json": {Schema: doc.SchemaOf(t)},
+					},
+				}
+			case InQuery:
+				op.Parameters = mergeParams(op.Parameters, doc.ParametersOf(t, "query"))
+			case InPath:
+				op.Parameters = mergeParams(op.Parameters, doc.ParametersOf(t, "path"))
+			}
+		}
+
+		if route.Version != "" && app.version != nil {
+			switch app.version.Type {
+			case URIVersion:
+				// The version is already part of the path.
+			case HeaderVersion:
+				op.Parameters = append(op.Parameters, &openapi.Parameter{
+					Name:     app.version.Header,
+					In:       "header",
+					Required: true,
+					Schema:   &openapi.Schema{Type: "string", Enum: []interface{}{route.Version}},
+				})
+			default:
+				op.XVersion = route.Version
+			}
+		}
+
+		existing := doc.AddOperation(path, method, op)
+		if existing != op {
+			mergeVersion(existing, app.version, route.Version)
+		}
+	}
+
+	return doc
+}
+
+// isExcluded reports whether the route is hidden with DOC_EXCLUDE.
+func isExcluded(route DocRoute) bool {
+	for _, m := range route.Metadata {
+		if m.Key == DOC_EXCLUDE {
+			if excluded, ok := m.Value.(bool); ok && excluded {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// applyDocMetadata enriches the operation with the documentation metadata of
+// the route.
+func applyDocMetadata(doc *openapi.Document, op *openapi.Operation, metadata []*Metadata) {
+	for _, m := range metadata {
+		switch m.Key {
+		case DOC_SUMMARY:
+			op.Summary, _ = m.Value.(string)
+		case DOC_DESCRIPTION:
+			op.Description, _ = m.Value.(string)
+		case DOC_DEPRECATED:
+			op.Deprecated, _ = m.Value.(bool)
+		case DOC_RESPONSE:
+			res, ok := m.Value.(DocResponse)
+			if !ok {
+				continue
+			}
+			if res.Status == 0 {
+				res.Status = http.StatusOK
+			}
+			if res.Description == "" {
+				res.Description = http.StatusText(res.Status)
+			}
+			response := &openapi.Response{Description: res.Description}
+			if res.Example != nil {
+				response.Content = map[string]*openapi.MediaType{
+					"application/json": {
+						Schema:  doc.SchemaOf(reflect.TypeOf(res.Example)),
+						Example: res.Example,
+					},
+				}
+			}
+			op.Responses[strconv.Itoa(res.Status)] = response
+		}
+	}
+}
+
+// operationID returns a stable identifier for the route made of the method,
+// the controller name, the path and the version.
+func operationID(route DocRoute) string {
+	parts := []string{strings.ToLower(route.Method)}
+	for _, s := range strings.FieldsFunc(route.Name+"/"+route.Path, func(r rune) bool {
+		return r == '/' || r == '{' || r == '}' || r == '.' || r == '-'
+	}) {
+		parts = append(parts, s)
+	}
+	if route.Version != "" {
+		parts = append(parts, "v"+route.Version)
+	}
+	return strings.Join(parts, "_")
+}
+
+// pathParams returns the names of the parameters of a documented path.
+func pathParams(path string) []string {
+	var names []string
+	for _, seg := range strings.Split(path, "/") {
+		if strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}") {
+			names = append(names, strings.Trim(seg, "{}"))
+		}
+	}
+	return names
+}
+
+// mergeParams adds the params to the list, replacing the ones with the same
+// name and location.
+func mergeParams(list []*openapi.Parameter, params []*openapi.Parameter) []*openapi.Parameter {
+	for _, p := range params {
+		replaced := false
+		for i, existing := range list {
+			if existing.Name == p.Name && existing.In == p.In {
+				list[i] = p
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			list = append(list, p)
+		}
+	}
+	return list
+}
+
+// mergeVersion documents another version of an operation served at the same
+// path, which happens with header versioning.
+func mergeVersion(op *openapi.Operation, version *Version, v string) {
+	if version == nil || version.Type != HeaderVersion || v == "" {
+		return
+	}
+	for _, p := range op.Parameters {
+		if p.In == "header" && p.Name == version.Header && p.Schema != nil {
+			p.Schema.Enum = append(p.Schema.Enum, v)
+			return
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package core_test
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tinh-tinh/tinhtinh/v2/common/openapi"
+	"github.com/tinh-tinh/tinhtinh/v2/core"
+)
+
+type CreateUserDto struct {
+	Name  string `json:"name" validate:"required,minLength=3"`
+	Email string `json:"email" validate:"required,isEmail"`
+	Age   int    `json:"age" validate:"isInt"`
+}
+
+type FilterUserDto struct {
+	Page  int    `query:"page" validate:"isInt"`
+	Name  string `query:"name"`
+	Token string
+}
+
+type UserIdDto struct {
+	ID int `path:"id" validate:"required,isInt"`
+}
+
+func openAPIModule() core.Module {
+	userController := func(module core.Module) core.Controller {
+		ctrl := module.NewController("users")
+
+		ctrl.Pipe(core.QueryParser[FilterUserDto]{}).Get("", func(ctx core.Ctx) error {
+			return ctx.JSON(core.Map{"data": "list"})
+		})
+
+		ctrl.Metadata(core.SetMetadata(core.DOC_SECURITY, []string{"bearerAuth"})).
+			Pipe(core.BodyParser[CreateUserDto]{}).
+			Post("", func(ctx core.Ctx) error {
+				return ctx.JSON(core.Map{"data": "created"})
+			})
+
+		ctrl.Pipe(core.PathParser[UserIdDto]{}).Get("{id}", func(ctx core.Ctx) error {
+			return ctx.JSON(core.Map{"data": "one"})
+		})
+
+		return ctrl
+	}
+
+	return core.NewModule(core.NewModuleOptions{
+		Controllers: []core.Controllers{userController},
+	})
+}
+
+func Test_OpenAPI(t *testing.T) {
+	app := core.CreateFactory(openAPIModule)
+	app.SetGlobalPrefix("/api")
+
+	doc := app.OpenAPI(openapi.Options{
+		Title: "Users",
+		SecuritySchemes: map[string]*openapi.SecurityScheme{
+			"bearerAuth": {Type: "http", Scheme: "bearer"},
+		},
+	})
+	require.Equal(t, openapi.Version, doc.OpenAPI)
+	require.Equal(t, "Users", doc.Info.Title)
+	require.Equal(t, []openapi.Tag{{Name: "users"}}, doc.Tags)
+
+	list := (*doc.Paths["/api/users"])["get"]
+	require.NotNil(t, list)
+	require.Equal(t, []string{"users"}, list.Tags)
+	require.Len(t, list.Parameters, 2)
+	require.Equal(t, "page", list.Parameters[0].Name)
+	require.Equal(t, "query", list.Parameters[0].In)
+	require.Equal(t, "integer", list.Parameters[0].Schema.Type)
+	require.Nil(t, list.Security)
+
+	create := (*doc.Paths["/api/users"])["post"]
+	require.NotNil(t, create)
+	require.Equal(t, []openapi.SecurityRequirement{{"bearerAuth": {}}}, create.Security)
+	schema := create.RequestBody.Content["application/json"].Schema
+	require.Equal(t, "#/components/schemas/CreateUserDto", schema.Ref)
+
+	dto := doc.Components.Schemas["CreateUserDto"]
+	require.Equal(t, []string{"name", "email"}, dto.Required)
+	require.Equal(t, 3, *dto.Properties["name"].MinLength)
+	require.Equal(t, "email", dto.Properties["email"].Format)
+	require.Equal(t, "integer", dto.Properties["age"].Type)
+
+	one := (*doc.Paths["/api/users/{id}"])["get"]
+	require.NotNil(t, one)
+	require.Len(t, one.Parameters, 1)
+	require.Equal(t, "id", one.Parameters[0].Name)
+	require.True(t, one.Parameters[0].Required)
+	require.Equal(t, "integer", one.Parameters[0].Schema.Type)
+
+	_, err := json.Marshal(doc)
+	require.Nil(t, err)
+}
+
+func Test_OpenAPI_AfterListen(t *testing.T) {
+	app := core.CreateFactory(openAPIModule)
+	app.SetGlobalPrefix("/api")
+
+	testServer := httptest.NewServer(app.PrepareBeforeListen())
+	defer testServer.Close()
+
+	doc := app.OpenAPI()
+	require.Len(t, doc.Paths, 2)
+	require.NotNil(t, (*doc.Paths["/api/users/{id}"])["get"])
+}
+
+func Test_OpenAPI_Version(t *testing.T) {
+	app := core.CreateFactory(AppVersionModule())
+	app.EnableVersioning(core.VersionOptions{
+		Type: core.URIVersion,
+	})
+
+	doc := app.OpenAPI()
+	require.NotNil(t, (*doc.Paths["/test/v1"])["get"])
+	require.NotNil(t, (*doc.Paths["/test/v2"])["get"])
+
+	app = core.CreateFactory(AppVersionModule())
+	app.EnableVersioning(core.VersionOptions{
+		Type:   core.HeaderVersion,
+		Header: "X-Version",
+	})
+
+	doc = app.OpenAPI()
+	op := (*doc.Paths["/test"])["get"]
+	require.NotNil(t, op)
+	require.Len(t, op.Parameters, 1)
+	require.Equal(t, "X-Version", op.Parameters[0].Name)
+	require.Equal(t, "header", op.Parameters[0].In)
+	require.Equal(t, []interface{}{"1", "2"}, op.Parameters[0].Schema.Enum)
+}
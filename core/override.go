@@ -0,0 +1,120 @@
+package core
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+	"sync"
+)
+
+// ProviderOverride replaces a provider of the module tree when it is built.
+// It is mostly used by tests to swap real providers for mocks.
+//
+// If Value is set, the provider resolves to Value. Otherwise Factory is called
+// with the providers listed in Inject.
+type ProviderOverride struct {
+	Name    Provide
+	Value   interface{}
+	Factory Factory
+	Inject  []Provide
+}
+
+var (
+	buildMu sync.Mutex
+	// builds holds the overrides of the module trees being built, by the
+	// goroutine building them.
+	builds = make(map[uint64]map[Provide]ProviderOverride)
+)
+
+// buildWithOverrides calls the module function with the overrides installed
+// for the goroutine calling it, so the root module created by NewModule keeps
+// them and every provider of the tree, including those resolved while the
+// tree is built, is overridden. The other trees built at the same time do not
+// see them.
+func buildWithOverrides(module ModuleParam, overrides []ProviderOverride) Module {
+	if len(overrides) == 0 {
+		return module()
+	}
+
+	mapOverrides := make(map[Provide]ProviderOverride, len(overrides))
+	for _, o := range overrides {
+		mapOverrides[o.Name] = o
+	}
+
+	id := goroutineID()
+	buildMu.Lock()
+	prev, nested := builds[id]
+	builds[id] = mapOverrides
+	buildMu.Unlock()
+	defer func() {
+		buildMu.Lock()
+		defer buildMu.Unlock()
+		if nested {
+			builds[id] = prev
+		} else {
+			delete(builds, id)
+		}
+	}()
+
+	return module()
+}
+
+// buildOverrides returns the overrides of the module tree built by the
+// current goroutine, nil when it builds none.
+func buildOverrides() map[Provide]ProviderOverride {
+	buildMu.Lock()
+	defer buildMu.Unlock()
+	if len(builds) == 0 {
+		return nil
+	}
+	return builds[goroutineID()]
+}
+
+// goroutineID returns the id of the current goroutine, read from the header
+// of its stack trace, "goroutine 42 [running]:".
+func goroutineID() uint64 {
+	var buf [64]byte
+	n := runtime.Stack(buf[:], false)
+	field := bytes.TrimPrefix(buf[:n], []byte("goroutine "))
+	if i := bytes.IndexByte(field, ' '); i >= 0 {
+		field = field[:i]
+	}
+	id, _ := strconv.ParseUint(string(field), 10, 64)
+	return id
+}
+
+// overrideFactory returns the factory and the injected providers of the
+// override.
+func overrideFactory(override ProviderOverride) (Factory, []Provide) {
+	if override.Value != nil {
+		value := override.Value
+		return func(param ...interface{}) interface{} {
+			return value
+		}, nil
+	}
+	return override.Factory, override.Inject
+}
+
+// applyOverride returns the options of the provider with the override of the
+// module tree applied, if any.
+func applyOverride(module Module, opt ProviderOptions) ProviderOptions {
+	dynMod, ok := module.(*DynamicModule)
+	if !ok {
+		return opt
+	}
+	overrides := dynMod.root().overrides
+	if overrides == nil {
+		return opt
+	}
+
+	override, ok := overrides[opt.Name]
+	if !ok {
+		return opt
+	}
+	opt.Factory, opt.Inject = overrideFactory(override)
+	opt.Value = nil
+	opt.FactoryErr = nil
+	opt.FactoryCtx = nil
+
+	return opt
+}
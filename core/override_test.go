@@ -0,0 +1,135 @@
+package core_test
+
+import (
+	"io"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tinh-tinh/tinhtinh/v2/core"
+)
+
+func Test_Overrides(t *testing.T) {
+	appModule := func() core.Module {
+		module := core.NewModule(core.NewModuleOptions{})
+		module.NewProvider(core.ProviderOptions{
+			Name:  "config",
+			Value: "real",
+		})
+		module.NewProvider(core.ProviderOptions{
+			Name: "service",
+			Factory: func(param ...interface{}) interface{} {
+				return "service:" + param[0].(string)
+			},
+			Inject: []core.Provide{"config"},
+		})
+		module.NewProvider(core.ProviderOptions{
+			Name:  "transient",
+			Scope: core.Transient,
+			Factory: func(param ...interface{}) interface{} {
+				return "real"
+			},
+		})
+		return module
+	}
+
+	app := core.CreateFactory(appModule, core.AppOptions{
+		Overrides: []core.ProviderOverride{
+			{Name: "config", Value: "mock"},
+			{Name: "transient", Value: "mock"},
+		},
+	})
+	require.Equal(t, "mock", app.Module.Ref("config"))
+	require.Equal(t, "service:mock", app.Module.Ref("service"))
+	require.Equal(t, "mock", app.Module.Ref("transient"))
+
+	app = core.CreateFactory(appModule, core.AppOptions{
+		Overrides: []core.ProviderOverride{
+			{Name: "service", Factory: func(param ...interface{}) interface{} {
+				return "factory:" + param[0].(string)
+			}, Inject: []core.Provide{"config"}},
+		},
+	})
+	require.Equal(t, "factory:real", app.Module.Ref("service"))
+
+	app = core.CreateFactory(appModule)
+	require.Equal(t, "real", app.Module.Ref("config"))
+}
+
+func Test_Overrides_Concurrent(t *testing.T) {
+	appModule := func() core.Module {
+		module := core.NewModule(core.NewModuleOptions{})
+		module.NewProvider(core.ProviderOptions{
+			Name:  "config",
+			Value: "real",
+		})
+		return module
+	}
+
+	// The overrides of a module tree are not seen by the trees built at the
+	// same time.
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			app := core.CreateFactory(appModule, core.AppOptions{
+				Overrides: []core.ProviderOverride{{Name: "config", Value: "mock"}},
+			})
+			require.Equal(t, "mock", app.Module.Ref("config"))
+		}()
+		go func() {
+			defer wg.Done()
+			app := core.CreateFactory(appModule)
+			require.Equal(t, "real", app.Module.Ref("config"))
+		}()
+	}
+	wg.Wait()
+}
+
+func Test_Overrides_BuildTime(t *testing.T) {
+	controller := func(module core.Module) core.Controller {
+		ctrl := module.NewController("test")
+		// The service is resolved while the tree is built.
+		svc := module.Ref("service").(string)
+		ctrl.Get("", func(ctx core.Ctx) error {
+			return ctx.SendString(svc)
+		})
+		return ctrl
+	}
+
+	appModule := func() core.Module {
+		module := core.NewModule(core.NewModuleOptions{})
+		module.NewProvider(core.ProviderOptions{
+			Name:  "repo",
+			Value: "real",
+		})
+		module.NewProvider(core.ProviderOptions{
+			Name: "service",
+			Factory: func(param ...interface{}) interface{} {
+				return "svc:" + param[0].(string)
+			},
+			Inject: []core.Provide{"repo"},
+		})
+		module.Controllers(controller)
+		return module
+	}
+
+	app := core.CreateFactory(appModule, core.AppOptions{
+		Overrides: []core.ProviderOverride{{Name: "repo", Value: "mock"}},
+	})
+	require.Equal(t, "svc:mock", app.Module.Ref("service"))
+
+	testServer := httptest.NewServer(app.PrepareBeforeListen())
+	defer testServer.Close()
+	resp, err := testServer.Client().Get(testServer.URL + "/test")
+	require.Nil(t, err)
+	data, err := io.ReadAll(resp.Body)
+	require.Nil(t, err)
+	require.Equal(t, "svc:mock", string(data))
+
+	// The overrides are limited to the build they are given to.
+	app = core.CreateFactory(appModule)
+	require.Equal(t, "svc:real", app.Module.Ref("service"))
+}
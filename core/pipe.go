@@ -24,6 +24,9 @@ type PipeDto interface {
 	GetValue() interface{}
 }
 
+// PipeMiddleware returns a Middleware parsing and validating the dtos of the
+// pipes, then storing them in the context by location. Each pipe runs as a
+// pipe stage of the request, named after its location and dto.
 func PipeMiddleware(pipes ...PipeDto) Middleware {
 	return func(ctx Ctx) error {
 		for _, pipe := range pipes {
@@ -31,25 +34,10 @@ func PipeMiddleware(pipes ...PipeDto) Middleware {
 			// Clear old value in dto
 			// p := reflect.ValueOf(dto).Elem()
 			// p.Set(reflect.Zero(p.Type()))
-			switch pipe.GetLocation() {
-			case InBody:
-				err := ctx.BodyParser(dto)
-				if err != nil {
-					return common.BadRequestException(ctx.Res(), err.Error())
-				}
-			case InQuery:
-				err := ctx.QueryParser(dto)
-				if err != nil {
-					return common.BadRequestException(ctx.Res(), err.Error())
-				}
-			case InPath:
-				err := ctx.PathParser(dto)
-				if err != nil {
-					return common.BadRequestException(ctx.Res(), err.Error())
-				}
-			}
-
-			err := ctx.Scan(dto)
+			name := string(pipe.GetLocation()) + " " + common.GetStructName(dto)
+			err := runStage(ctx, StagePipe, name, func() error {
+				return parsePipe(ctx, pipe.GetLocation(), dto)
+			})
 			if err != nil {
 				return common.BadRequestException(ctx.Res(), err.Error())
 			}
@@ -59,6 +47,24 @@ func PipeMiddleware(pipes ...PipeDto) Middleware {
 	}
 }
 
+// parsePipe parses the dto from its location in the request then validates
+// it.
+func parsePipe(ctx Ctx, location CtxKey, dto any) error {
+	var err error
+	switch location {
+	case InBody:
+		err = ctx.BodyParser(dto)
+	case InQuery:
+		err = ctx.QueryParser(dto)
+	case InPath:
+		err = ctx.PathParser(dto)
+	}
+	if err != nil {
+		return err
+	}
+	return ctx.Scan(dto)
+}
+
 type BodyParser[P any] struct{}
 
 func (b BodyParser[P]) GetValue() any {
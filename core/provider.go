@@ -4,6 +4,7 @@ import (
 	"reflect"
 	"slices"
 	"sync"
+	"time"
 
 	"github.com/tinh-tinh/tinhtinh/v2/common"
 )
@@ -15,6 +16,11 @@ type Provide string
 
 const REQUEST Provide = "REQUEST"
 
+// APP resolves to the *App owning the module tree. It is only available once
+// CreateFactory has returned, so it should be referenced lazily (for example
+// inside a handler) rather than injected into a singleton factory.
+const APP Provide = "APP"
+
 type ProvideStatus string
 
 const (
@@ -117,6 +123,21 @@ type ProviderOptions struct {
 	Inject []Provide
 	// Status of the provider. Default is PRIVATE.
 	Status ProvideStatus
+	// FactoryErr is used instead of Factory when creating the provider can
+	// fail. An error aborts CreateFactory.
+	FactoryErr FactoryErr
+	// FactoryCtx is used instead of Factory when the provider is initialized
+	// asynchronously. An error or a timeout aborts CreateFactory.
+	FactoryCtx FactoryCtx
+	// Timeout of FactoryCtx. Default is DefaultFactoryTimeout.
+	Timeout time.Duration
+	// Multi adds the provider to the collection of providers registered under
+	// the same name instead of replacing it. Ref returns the values of the
+	// collection as a []interface{} in registration order.
+	Multi bool
+	// Tags of the provider. RefTagged returns the values of the providers
+	// with a tag, across the whole application.
+	Tags []string
 }
 
 type ProviderParams interface {
@@ -129,6 +150,9 @@ func (module *DynamicModule) NewProvider(param ProviderParams) Provider {
 		return InitProviders(module, providerOptions)
 	}
 	nameProvide := getProvideName(param)
+	if fields, byType := injectFields(param); len(fields) > 0 || len(byType) > 0 {
+		return module.newFieldProvider(Provide(nameProvide), param, fields, byType)
+	}
 	options := ProviderOptions{
 		Name:  Provide(nameProvide),
 		Value: param,
@@ -136,12 +160,18 @@ func (module *DynamicModule) NewProvider(param ProviderParams) Provider {
 	return InitProviders(module, options)
 }
 
-// getExports returns a list of providers that are exported by the module.
-// The exported providers are the providers that have the status PUBLIC.
+// GetExports returns a list of providers that are exported by the module to
+// the modules importing it: the providers with the status PUBLIC, those of
+// the imported modules included. An encapsulated module only exports its own
+// providers and those of the modules it re-exports.
 func (module *DynamicModule) GetExports() []Provider {
 	exports := make([]Provider, 0)
+	root := module.root()
 	for _, v := range module.DataProviders {
-		if v.GetStatus() == PUBLIC {
+		if v.GetStatus() != PUBLIC {
+			continue
+		}
+		if !module.encapsulated || root.ownerOf(v, module) == module || slices.Contains(module.reExported, v) {
 			exports = append(exports, v)
 		}
 	}
@@ -149,6 +179,20 @@ func (module *DynamicModule) GetExports() []Provider {
 	return exports
 }
 
+// visibleProviders returns the providers with the status PUBLIC visible in
+// the module, its own and those of the modules it imports. They are
+// inherited by the modules it creates with New.
+func (module *DynamicModule) visibleProviders() []Provider {
+	visible := make([]Provider, 0)
+	for _, v := range module.DataProviders {
+		if v.GetStatus() == PUBLIC {
+			visible = append(visible, v)
+		}
+	}
+
+	return visible
+}
+
 // getRequest returns a list of providers that have the scope Request.
 // The providers are the providers that will be injected with the request.
 func (module *DynamicModule) getRequest() []Provider {
@@ -163,9 +207,15 @@ func (module *DynamicModule) getRequest() []Provider {
 
 // appendProvider appends the given providers to the module's list of providers.
 // If the provider already exists with the same name, it will override the existing
-// provider.
+// provider, unless it is a multi-provider which is added to the collection.
 func (module *DynamicModule) appendProvider(providers ...Provider) {
 	for _, provider := range providers {
+		if module.isMulti(provider) {
+			if !slices.Contains(module.DataProviders, provider) {
+				module.DataProviders = append(module.DataProviders, provider)
+			}
+			continue
+		}
 		idx := module.findIdx(provider.GetName())
 		if idx == -1 {
 			module.DataProviders = append(module.DataProviders, provider)
@@ -176,8 +226,13 @@ func (module *DynamicModule) appendProvider(providers ...Provider) {
 }
 
 func InitProviders(module Module, opt ProviderOptions) Provider {
+	// Replace the provider when it is overridden in the module tree.
+	opt = applyOverride(module, opt)
+	opt = wrapFactory(module, opt)
+
 	// Retrieve existing provider or create a new one.
 	provider := getOrCreateProvider(module, opt)
+	registerMeta(module, provider, opt)
 
 	// Apply defaults for scope and status.
 	if provider.GetScope() == "" {
@@ -191,6 +246,7 @@ func InitProviders(module Module, opt ProviderOptions) Provider {
 	if provider.GetScope() == Transient {
 		provider.SetInject(opt.Inject)
 		provider.SetFactory(opt.Factory)
+		deferCheck(module, provider)
 		return provider
 	}
 
@@ -201,14 +257,27 @@ func InitProviders(module Module, opt ProviderOptions) Provider {
 
 	// Handle request scope: store factory/inject for per-request resolution.
 	if provider.GetScope() == Request {
+		checkMulti(provider, opt)
 		provider.SetInject(opt.Inject)
 		provider.SetFactory(opt.Factory)
 		provider.SetValue(opt.Value)
+		deferCheck(module, provider)
 		return provider
 	}
 
-	// Handle singleton scope: resolve and cache value immediately.
+	// Handle singleton scope: the value is created once its dependencies are
+	// resolved.
 	provider.SetValue(opt.Value)
+	if dynMod, ok := module.(*DynamicModule); ok {
+		if opt.Value == nil {
+			provider.SetInject(opt.Inject)
+			provider.SetFactory(opt.Factory)
+			dynMod.deferProvider(provider)
+		} else {
+			dynMod.created(provider)
+		}
+		return provider
+	}
 	if opt.Value == nil {
 		values := make([]interface{}, 0, len(opt.Inject))
 		for _, p := range opt.Inject {
@@ -224,10 +293,18 @@ func InitProviders(module Module, opt ProviderOptions) Provider {
 	return provider
 }
 
+// deferCheck registers the request-scoped or transient provider so its
+// dependencies are checked when the module is resolved.
+func deferCheck(module Module, provider Provider) {
+	if dynMod, ok := module.(*DynamicModule); ok {
+		dynMod.deferProvider(provider)
+	}
+}
+
 // getOrCreateProvider retrieves an existing provider by name, or registers a
 // new one with the module if it does not yet exist.
 func getOrCreateProvider(module Module, opt ProviderOptions) Provider {
-	if idx := module.findIdx(opt.Name); idx != -1 {
+	if idx := module.findIdx(opt.Name); idx != -1 && !opt.Multi {
 		return module.GetDataProviders()[idx]
 	}
 	p := &DynamicProvider{
@@ -236,6 +313,15 @@ func getOrCreateProvider(module Module, opt ProviderOptions) Provider {
 		Scope:  opt.Scope,
 	}
 	module.AppendDataProviders(p)
+	if dynMod, ok := module.(*DynamicModule); ok {
+		root := dynMod.root()
+		root.graphMu.Lock()
+		if root.owners == nil {
+			root.owners = make(map[Provider]*DynamicModule)
+		}
+		root.owners[p] = dynMod
+		root.graphMu.Unlock()
+	}
 	return p
 }
 
@@ -27,6 +27,8 @@ type Router struct {
 	Dtos []PipeDto
 	// Version of route
 	Version string
+	// Number of guards protecting the route
+	guards int
 	// Raw http handler
 	httpHandler http.Handler
 	// Interceptor
@@ -80,14 +82,13 @@ func (app *App) registerRoutes() {
 	routes := make(map[string][]*Router)
 
 	for _, r := range app.Module.GetRouters() {
-		route := ParseRoute(r.Method + " " + r.Path)
-		if app.version != nil && app.version.Type == URIVersion && r.Version != "" {
-			route.SetPrefix("v" + r.Version)
-		}
-		route.SetPrefix(r.Name)
-		if app.Prefix != "" {
-			route.SetPrefix(app.Prefix)
+		route := app.parseRouter(r)
+		if app.patterns == nil {
+			app.patterns = make(map[string][]int)
 		}
+		app.patterns[route.GetPath()] = append(app.patterns[route.GetPath()], len(app.routes))
+		app.routes = append(app.routes, r.doc(route.GetPath()))
+		app.routers = append(app.routers, r)
 		fmt.Printf("%s %s %s %s\n",
 			color.Green("[TT]"),
 			color.White(time.Now().Format("2006-01-02 15:04:05")),
@@ -109,6 +110,82 @@ func (app *App) registerRoutes() {
 	app.free()
 }
 
+// parseRouter returns the route the router is served at, taking the URI
+// version, the controller name and the global prefix into account.
+func (app *App) parseRouter(r *Router) Route {
+	route := ParseRoute(r.Method + " " + r.Path)
+	if app.version != nil && app.version.Type == URIVersion && r.Version != "" {
+		route.SetPrefix("v" + r.Version)
+	}
+	route.SetPrefix(r.Name)
+	if app.Prefix != "" {
+		route.SetPrefix(app.Prefix)
+	}
+	return route
+}
+
+// doc returns the DocRoute describing the router served at the given pattern.
+func (r *Router) doc(pattern string) DocRoute {
+	var security []string
+	for _, m := range r.Metadata {
+		if m.Key == DOC_SECURITY {
+			if names, ok := m.Value.([]string); ok {
+				security = append(security, names...)
+			}
+		}
+	}
+
+	return DocRoute{
+		Name:     r.Name,
+		Method:   r.Method,
+		Path:     r.Path,
+		Pattern:  pattern,
+		Version:  r.Version,
+		Dto:      r.Dtos,
+		Security: security,
+		Metadata: r.Metadata,
+	}
+}
+
+// Routes returns the description of every route of the App. Before the routes
+// are registered it reads the live routers of the module tree, afterwards it
+// returns the snapshot taken by registerRoutes.
+func (app *App) Routes() []DocRoute {
+	if len(app.routes) > 0 {
+		return app.routes
+	}
+	routes := []DocRoute{}
+	for _, r := range app.Module.GetRouters() {
+		route := app.parseRouter(r)
+		routes = append(routes, r.doc(route.GetPath()))
+	}
+	return routes
+}
+
+// MatchRoute returns the route the request is dispatched to, selecting the
+// version of the request when several routes share its pattern. It reports
+// false when no route of the App matches the request. The routes must be
+// registered, so MatchRoute is meant to be called while serving requests,
+// for example by a middleware labelling requests with their route.
+func (app *App) MatchRoute(r *http.Request) (DocRoute, bool) {
+	_, pattern := app.Mux.Handler(r)
+	indexes := app.patterns[pattern]
+	if len(indexes) == 0 {
+		return DocRoute{}, false
+	}
+	if len(indexes) > 1 && app.version != nil {
+		if version := app.version.Get(r); version != "" {
+			for _, i := range indexes {
+				if app.routes[i].Version == version {
+					return app.routes[i], true
+				}
+			}
+			return DocRoute{}, false
+		}
+	}
+	return app.routes[indexes[0]], true
+}
+
 type Route struct {
 	Method string
 	Path   string
@@ -170,3 +170,48 @@ func Test_Slash(t *testing.T) {
 	require.Nil(t, err)
 	require.Equal(t, http.StatusNotFound, resp.StatusCode)
 }
+
+func Test_MatchRoute(t *testing.T) {
+	userController := func(module core.Module) core.Controller {
+		ctrl := module.NewController("users")
+		ctrl.Get("{id}", func(ctx core.Ctx) error {
+			return ctx.JSON(core.Map{"data": ctx.Path("id")})
+		})
+		ctrl.Version("2").Get("{id}", func(ctx core.Ctx) error {
+			return ctx.JSON(core.Map{"data": "v2"})
+		})
+		return ctrl
+	}
+
+	appModule := func() core.Module {
+		return core.NewModule(core.NewModuleOptions{
+			Controllers: []core.Controllers{userController},
+		})
+	}
+
+	app := core.CreateFactory(appModule)
+	app.SetGlobalPrefix("api")
+	app.EnableVersioning(core.VersionOptions{
+		Type:   core.HeaderVersion,
+		Header: "X-Version",
+	})
+	app.PrepareBeforeListen()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/users/42", nil)
+	route, ok := app.MatchRoute(req)
+	require.True(t, ok)
+	require.Equal(t, "GET /api/users/{id}", route.Pattern)
+	require.Equal(t, "", route.Version)
+
+	req.Header.Set("X-Version", "2")
+	route, ok = app.MatchRoute(req)
+	require.True(t, ok)
+	require.Equal(t, "2", route.Version)
+
+	req.Header.Set("X-Version", "3")
+	_, ok = app.MatchRoute(req)
+	require.False(t, ok)
+
+	_, ok = app.MatchRoute(httptest.NewRequest(http.MethodPost, "/api/users/42", nil))
+	require.False(t, ok)
+}
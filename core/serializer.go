@@ -0,0 +1,327 @@
+package core
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tinh-tinh/tinhtinh/v2/common/exception"
+	"github.com/tinh-tinh/tinhtinh/v2/common/msgpack"
+)
+
+// PRODUCES holds the media types a route responds with through Ctx.Send.
+const PRODUCES = "PRODUCES"
+
+// The media types of the default serializers.
+const (
+	MIMEApplicationJSON    = "application/json"
+	MIMEApplicationXML     = "application/xml"
+	MIMETextCSV            = "text/csv"
+	MIMEApplicationMsgpack = "application/msgpack"
+	MIMETextPlain          = "text/plain"
+)
+
+// Serializer serializes the responses of Ctx.Send and deserializes the
+// request bodies of Ctx.BodyParser for a media type.
+//
+// Encode and Decode return an error wrapping errors.ErrUnsupported for the
+// values they cannot represent, then Ctx.Send tries the next serializer
+// accepted by the client. One of them is nil when the media type is only
+// produced or only consumed.
+type Serializer struct {
+	// MediaType is the media type of the serialized data, such as
+	// "application/json".
+	MediaType string
+	Encode    Encode
+	Decode    Decode
+}
+
+// Produces restricts the media types a route responds with through Ctx.Send
+// to those given, in order of preference.
+//
+//	ctrl.Metadata(core.Produces(core.MIMEApplicationJSON, core.MIMETextCSV)).Get("", handler)
+func Produces(mediaTypes ...string) *Metadata {
+	return SetMetadata(PRODUCES, mediaTypes)
+}
+
+// defaultSerializers returns the serializers of the App, in order of
+// preference: JSON with the encoder and decoder of the App, XML, CSV,
+// MessagePack and plain text.
+func defaultSerializers(encoder Encode, decoder Decode) []Serializer {
+	return []Serializer{
+		{MediaType: MIMEApplicationJSON, Encode: encoder, Decode: decoder},
+		{MediaType: MIMEApplicationXML, Encode: encodeXML, Decode: xml.Unmarshal},
+		{MediaType: MIMETextCSV, Encode: encodeCSV},
+		{MediaType: MIMEApplicationMsgpack, Encode: msgpack.Marshal, Decode: msgpack.Unmarshal},
+		{MediaType: MIMETextPlain, Encode: encodeText, Decode: decodeText},
+	}
+}
+
+// setSerializer replaces the serializer of the same media type, or appends
+// it.
+func setSerializer(serializers []Serializer, s Serializer) []Serializer {
+	s.MediaType = strings.ToLower(s.MediaType)
+	for i := range serializers {
+		if serializers[i].MediaType == s.MediaType {
+			serializers[i] = s
+			return serializers
+		}
+	}
+	return append(serializers, s)
+}
+
+func encodeXML(v any) ([]byte, error) {
+	data, err := xml.MarshalIndent(v, "", "  ")
+	var unsupported *xml.UnsupportedTypeError
+	if errors.As(err, &unsupported) {
+		return nil, fmt.Errorf("%w: %w", errors.ErrUnsupported, err)
+	}
+	return data, err
+}
+
+// encodeCSV encodes a slice of structs with ParseCsv. The headers are the csv
+// tags of the fields, or their names.
+func encodeCSV(v any) ([]byte, error) {
+	t := reflect.TypeOf(v)
+	if t == nil || (t.Kind() != reflect.Slice && t.Kind() != reflect.Array) {
+		return nil, fmt.Errorf("%w: csv encodes slices of structs", errors.ErrUnsupported)
+	}
+	elem := t.Elem()
+	if elem.Kind() == reflect.Pointer {
+		elem = elem.Elem()
+	}
+	if elem.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%w: csv encodes slices of structs", errors.ErrUnsupported)
+	}
+
+	headers := []string{}
+	for i := 0; i < elem.NumField(); i++ {
+		field := elem.Field(i)
+		name := field.Tag.Get("csv")
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		headers = append(headers, name)
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	if err := writer.WriteAll(ParseCsv(v, headers)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeText encodes the strings, the bytes, the errors, the fmt.Stringer and
+// the scalars.
+func encodeText(v any) ([]byte, error) {
+	switch val := v.(type) {
+	case string:
+		return []byte(val), nil
+	case []byte:
+		return val, nil
+	case error:
+		return []byte(val.Error()), nil
+	case fmt.Stringer:
+		return []byte(val.String()), nil
+	}
+	switch reflect.ValueOf(v).Kind() {
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return []byte(fmt.Sprint(v)), nil
+	}
+	return nil, fmt.Errorf("%w: cannot encode %T as text", errors.ErrUnsupported, v)
+}
+
+// decodeText decodes the body into a *string or a *[]byte.
+func decodeText(data []byte, v any) error {
+	switch val := v.(type) {
+	case *string:
+		*val = string(data)
+	case *[]byte:
+		*val = append((*val)[:0], data...)
+	default:
+		return fmt.Errorf("%w: cannot decode text into %T", errors.ErrUnsupported, v)
+	}
+	return nil
+}
+
+// Send writes the data with the serializer of the media type preferred by
+// the Accept header of the request, among those produced by the route (see
+// Produces). When a serializer cannot represent the data, such as CSV for a
+// map, the next acceptable one is used. It returns a 406 Not Acceptable error
+// when no serializer is acceptable.
+//
+// The response has the Content-Type of the serializer and varies on Accept.
+func (ctx *DefaultCtx) Send(data any) error {
+	if ctx.callHandler != nil {
+		data = ctx.callHandler(data)
+	}
+
+	header := ctx.w.Header()
+	if !varies(header, "Accept") {
+		header.Add("Vary", "Accept")
+	}
+	for _, s := range ctx.negotiate() {
+		res, err := s.Encode(data)
+		if errors.Is(err, errors.ErrUnsupported) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		contentType := s.MediaType
+		if strings.HasPrefix(contentType, "text/") {
+			contentType += "; charset=utf-8"
+		}
+		header.Set("Content-Type", contentType)
+		return ctx.write(res)
+	}
+	return exception.NotAcceptable("no acceptable representation of the response")
+}
+
+// negotiate returns the serializers producing the media types accepted by the
+// request, by decreasing quality. The serializers of the same quality keep
+// their order.
+func (ctx *DefaultCtx) negotiate() []Serializer {
+	produces, _ := ctx.GetMetadata(PRODUCES).([]string)
+	var candidates []Serializer
+	if len(produces) > 0 {
+		for _, mediaType := range produces {
+			if s, ok := ctx.app.serializer(mediaType); ok && s.Encode != nil {
+				candidates = append(candidates, s)
+			}
+		}
+	} else {
+		for _, s := range ctx.app.serializers {
+			if s.Encode != nil {
+				candidates = append(candidates, s)
+			}
+		}
+	}
+
+	accept := strings.Join(ctx.r.Header.Values("Accept"), ",")
+	if strings.TrimSpace(accept) == "" {
+		return candidates
+	}
+	ranges := parseAccept(accept)
+	qualities := make(map[string]float64, len(candidates))
+	acceptable := candidates[:0]
+	for _, s := range candidates {
+		if q := acceptQuality(ranges, s.MediaType); q > 0 {
+			qualities[s.MediaType] = q
+			acceptable = append(acceptable, s)
+		}
+	}
+	sort.SliceStable(acceptable, func(i, j int) bool {
+		return qualities[acceptable[i].MediaType] > qualities[acceptable[j].MediaType]
+	})
+	return acceptable
+}
+
+// serializer returns the serializer of the media type.
+func (app *App) serializer(mediaType string) (Serializer, bool) {
+	mediaType = strings.ToLower(mediaType)
+	for _, s := range app.serializers {
+		if s.MediaType == mediaType {
+			return s, true
+		}
+	}
+	return Serializer{}, false
+}
+
+// decoderOf returns the decoder of the Content-Type of the request. The
+// structured syntax suffixes, such as application/problem+json, use the
+// decoder of their base type. A missing or unknown Content-Type uses the
+// Decoder of the App.
+func (app *App) decoderOf(contentType string) (Decode, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return app.decoder, nil
+	}
+	s, ok := app.serializer(mediaType)
+	if !ok {
+		if i := strings.LastIndex(mediaType, "+"); i >= 0 {
+			s, ok = app.serializer("application/" + mediaType[i+1:])
+		}
+	}
+	if !ok {
+		return app.decoder, nil
+	}
+	if s.Decode == nil {
+		return nil, exception.UnsupportedMediaType("unsupported content type " + mediaType)
+	}
+	return s.Decode, nil
+}
+
+type acceptRange struct {
+	mediaType string
+	q         float64
+}
+
+// parseAccept parses the media ranges of an Accept header with their quality.
+func parseAccept(accept string) []acceptRange {
+	var ranges []acceptRange
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				q = min(max(parsed, 0), 1)
+			}
+		}
+		ranges = append(ranges, acceptRange{mediaType: mediaType, q: q})
+	}
+	return ranges
+}
+
+// acceptQuality returns the quality of the media type given by the most
+// specific of the ranges matching it, 0 when none matches.
+func acceptQuality(ranges []acceptRange, mediaType string) float64 {
+	typ, _, _ := strings.Cut(mediaType, "/")
+	best, q := -1, 0.0
+	for _, r := range ranges {
+		specificity := -1
+		switch r.mediaType {
+		case mediaType:
+			specificity = 2
+		case typ + "/*":
+			specificity = 1
+		case "*/*":
+			specificity = 0
+		}
+		if specificity > best {
+			best, q = specificity, r.q
+		}
+	}
+	return q
+}
+
+// varies reports whether the Vary header of the response lists the header.
+func varies(header http.Header, name string) bool {
+	for _, value := range header.Values("Vary") {
+		for _, field := range strings.Split(value, ",") {
+			field = strings.TrimSpace(field)
+			if field == "*" || strings.EqualFold(field, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,215 @@
+package core_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tinh-tinh/tinhtinh/v2/common/msgpack"
+	"github.com/tinh-tinh/tinhtinh/v2/core"
+)
+
+type sendUser struct {
+	ID   int    `json:"id" xml:"id" csv:"id"`
+	Name string `json:"name" xml:"name" csv:"name"`
+	note string
+}
+
+type sendStringer func()
+
+func (sendStringer) String() string { return "stringer" }
+
+func Test_Send(t *testing.T) {
+	users := []sendUser{{ID: 1, Name: "john"}, {ID: 2, Name: "jane", note: "hidden"}}
+
+	controller := func(module core.Module) core.Controller {
+		ctrl := module.NewController("test")
+
+		ctrl.Get("users", func(ctx core.Ctx) error {
+			return ctx.Send(users)
+		})
+
+		ctrl.Get("map", func(ctx core.Ctx) error {
+			return ctx.Send(core.Map{"data": "ok"})
+		})
+
+		ctrl.Get("text", func(ctx core.Ctx) error {
+			return ctx.Send("hello")
+		})
+
+		ctrl.Get("stringer", func(ctx core.Ctx) error {
+			return ctx.Send(sendStringer(func() {}))
+		})
+
+		ctrl.Metadata(core.Produces(core.MIMETextCSV, core.MIMEApplicationJSON)).Get("export", func(ctx core.Ctx) error {
+			return ctx.Send(users)
+		})
+
+		return ctrl
+	}
+
+	app := core.CreateFactory(func() core.Module {
+		return core.NewModule(core.NewModuleOptions{
+			Controllers: []core.Controllers{controller},
+		})
+	})
+	app.SetGlobalPrefix("/api")
+
+	testServer := httptest.NewServer(app.PrepareBeforeListen())
+	defer testServer.Close()
+	testClient := testServer.Client()
+
+	get := func(path string, accept string) (*http.Response, []byte) {
+		req, err := http.NewRequest(http.MethodGet, testServer.URL+path, nil)
+		require.Nil(t, err)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		resp, err := testClient.Do(req)
+		require.Nil(t, err)
+		data, err := io.ReadAll(resp.Body)
+		require.Nil(t, err)
+		return resp, data
+	}
+
+	resp, data := get("/api/test/users", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	require.Equal(t, "Accept", resp.Header.Get("Vary"))
+	require.Equal(t, `[{"id":1,"name":"john"},{"id":2,"name":"jane"}]`, string(data))
+
+	resp, data = get("/api/test/users", "text/csv")
+	require.Equal(t, "text/csv; charset=utf-8", resp.Header.Get("Content-Type"))
+	require.Equal(t, "id,name\n1,john\n2,jane\n", string(data))
+
+	resp, data = get("/api/test/users", "application/xml;q=0.5, text/html, application/msgpack")
+	require.Equal(t, "application/msgpack", resp.Header.Get("Content-Type"))
+	var decoded []sendUser
+	require.Nil(t, msgpack.Unmarshal(data, &decoded))
+	require.Equal(t, []sendUser{{ID: 1, Name: "john"}, {ID: 2, Name: "jane"}}, decoded)
+
+	resp, data = get("/api/test/users", "application/*, application/json;q=0.1")
+	require.Equal(t, "application/xml", resp.Header.Get("Content-Type"))
+	require.Contains(t, string(data), "<name>john</name>")
+
+	// The map cannot be written as CSV, XML or text.
+	resp, _ = get("/api/test/map", "text/csv, application/xml;q=0.9, */*;q=0.1")
+	require.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	resp, _ = get("/api/test/map", "text/csv")
+	require.Equal(t, http.StatusNotAcceptable, resp.StatusCode)
+
+	resp, data = get("/api/test/text", "text/plain")
+	require.Equal(t, "text/plain; charset=utf-8", resp.Header.Get("Content-Type"))
+	require.Equal(t, "hello", string(data))
+
+	resp, _ = get("/api/test/users", "text/html")
+	require.Equal(t, http.StatusNotAcceptable, resp.StatusCode)
+
+	// A function cannot be written as MessagePack.
+	resp, data = get("/api/test/stringer", "application/msgpack, text/plain;q=0.5")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "text/plain; charset=utf-8", resp.Header.Get("Content-Type"))
+	require.Equal(t, "stringer", string(data))
+
+	resp, _ = get("/api/test/export", "")
+	require.Equal(t, "text/csv; charset=utf-8", resp.Header.Get("Content-Type"))
+	resp, _ = get("/api/test/export", "application/json")
+	require.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	resp, _ = get("/api/test/export", "application/xml")
+	require.Equal(t, http.StatusNotAcceptable, resp.StatusCode)
+}
+
+func Test_BodyParser_ContentType(t *testing.T) {
+	controller := func(module core.Module) core.Controller {
+		ctrl := module.NewController("test")
+
+		ctrl.Post("", func(ctx core.Ctx) error {
+			var user sendUser
+			if err := ctx.BodyParser(&user); err != nil {
+				return err
+			}
+			return ctx.JSON(user)
+		})
+
+		ctrl.Post("text", func(ctx core.Ctx) error {
+			var text string
+			if err := ctx.BodyParser(&text); err != nil {
+				return err
+			}
+			return ctx.SendString(text)
+		})
+
+		return ctrl
+	}
+
+	app := core.CreateFactory(func() core.Module {
+		return core.NewModule(core.NewModuleOptions{
+			Controllers: []core.Controllers{controller},
+		})
+	}, core.AppOptions{
+		Serializers: []core.Serializer{{
+			MediaType: "application/x-user",
+			Decode: func(data []byte, v interface{}) error {
+				id, name, _ := strings.Cut(string(data), ":")
+				return json.Unmarshal([]byte(`{"id":`+id+`,"name":"`+name+`"}`), v)
+			},
+		}},
+	})
+	app.SetGlobalPrefix("/api")
+
+	testServer := httptest.NewServer(app.PrepareBeforeListen())
+	defer testServer.Close()
+	testClient := testServer.Client()
+
+	post := func(path string, contentType string, body []byte) (*http.Response, string) {
+		resp, err := testClient.Post(testServer.URL+path, contentType, strings.NewReader(string(body)))
+		require.Nil(t, err)
+		data, err := io.ReadAll(resp.Body)
+		require.Nil(t, err)
+		return resp, string(data)
+	}
+
+	user := `{"id":1,"name":"john"}`
+	_, body := post("/api/test", "application/json; charset=utf-8", []byte(user))
+	require.Equal(t, user, body)
+
+	// Without a known content type, the Decoder of the App is used.
+	_, body = post("/api/test", "", []byte(user))
+	require.Equal(t, user, body)
+
+	_, body = post("/api/test", "application/xml", []byte(`<sendUser><id>1</id><name>john</name></sendUser>`))
+	require.Equal(t, user, body)
+
+	_, body = post("/api/test", "application/problem+json", []byte(user))
+	require.Equal(t, user, body)
+
+	data, err := msgpack.Marshal(sendUser{ID: 1, Name: "john"})
+	require.Nil(t, err)
+	_, body = post("/api/test", "application/msgpack", data)
+	require.Equal(t, user, body)
+
+	_, body = post("/api/test", "application/x-user", []byte("1:john"))
+	require.Equal(t, user, body)
+
+	_, body = post("/api/test/text", "text/plain", []byte("hello"))
+	require.Equal(t, "hello", body)
+
+	// A struct is decoded from text with the Decoder of the App.
+	_, body = post("/api/test", "text/plain", []byte(user))
+	require.Equal(t, user, body)
+
+	resp, _ := post("/api/test", "text/plain", []byte("hello"))
+	require.Equal(t, http.StatusUnsupportedMediaType, resp.StatusCode)
+
+	resp, _ = post("/api/test", "text/csv", []byte("id,name\n1,john\n"))
+	require.Equal(t, http.StatusUnsupportedMediaType, resp.StatusCode)
+
+	// A deeply nested body is rejected instead of exhausting the stack.
+	resp, _ = post("/api/test", "application/msgpack", bytes.Repeat([]byte{0x91}, 1<<20))
+	require.GreaterOrEqual(t, resp.StatusCode, http.StatusBadRequest)
+}
@@ -0,0 +1,74 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// Stage is a step of the pipeline serving a route.
+type Stage string
+
+const (
+	StageMiddleware Stage = "middleware"
+	StageGuard      Stage = "guard"
+	StagePipe       Stage = "pipe"
+	StageHandler    Stage = "handler"
+)
+
+// StageObserver is called when a stage of a route starts, with the context of
+// the request. It returns the context the stage runs with and a function
+// called with the error of the stage once it returned. The context returned
+// for a middleware or a handler is passed to the next stages; guards and pipes
+// do not run other stages, so their context is dropped when they return.
+type StageObserver func(ctx context.Context, stage Stage, name string) (context.Context, func(err error))
+
+// ObserveStages registers an observer of the stages of the routes, used for
+// example to trace the requests. It must be called before the App serves
+// requests.
+func (app *App) ObserveStages(observer StageObserver) {
+	app.stageObservers = append(app.stageObservers, observer)
+}
+
+// selfObserved holds the code of the middlewares built from the guards and
+// the pipes. They observe their own stage, so ParseCtxMiddleware does not
+// report them as middlewares.
+var selfObserved = map[uintptr]bool{
+	reflect.ValueOf(guardMiddleware(nil)).Pointer(): true,
+	reflect.ValueOf(PipeMiddleware()).Pointer():     true,
+}
+
+// runStage runs fnc as the stage of the request of ctx, notifying the
+// observers of the App. A panic of fnc is reported to the observers then
+// propagated.
+func runStage(ctx Ctx, stage Stage, name string, fnc func() error) (err error) {
+	c, ok := ctx.(*DefaultCtx)
+	if !ok || c.app == nil || len(c.app.stageObservers) == 0 {
+		return fnc()
+	}
+
+	reqCtx := c.r.Context()
+	done := make([]func(err error), 0, len(c.app.stageObservers))
+	for _, observer := range c.app.stageObservers {
+		var d func(err error)
+		reqCtx, d = observer(reqCtx, stage, name)
+		done = append(done, d)
+	}
+	if stage == StageMiddleware || stage == StageHandler {
+		c.r = c.r.WithContext(reqCtx)
+	}
+
+	defer func() {
+		r := recover()
+		if r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+		for i := len(done) - 1; i >= 0; i-- {
+			done[i](err)
+		}
+		if r != nil {
+			panic(r)
+		}
+	}()
+	return fnc()
+}
@@ -0,0 +1,115 @@
+package core_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tinh-tinh/tinhtinh/v2/core"
+)
+
+type stageKey struct{}
+
+type OrderDto struct {
+	Name string `validate:"required"`
+}
+
+func stageMiddleware(ctx core.Ctx) error {
+	return ctx.Next()
+}
+
+func stageGuard(ctx core.Ctx) bool {
+	return ctx.Query("denied") == ""
+}
+
+func Test_ObserveStages(t *testing.T) {
+	orderController := func(module core.Module) core.Controller {
+		ctrl := module.NewController("orders")
+		ctrl.Use(stageMiddleware).Guard(stageGuard).Pipe(core.BodyParser[OrderDto]{}).Post("", func(ctx core.Ctx) error {
+			return ctx.JSON(core.Map{"parent": ctx.Req().Context().Value(stageKey{})})
+		})
+		ctrl.Get("panic", func(ctx core.Ctx) error {
+			panic("boom")
+		})
+		return ctrl
+	}
+
+	appModule := func() core.Module {
+		return core.NewModule(core.NewModuleOptions{
+			Controllers: []core.Controllers{orderController},
+		})
+	}
+
+	var mu sync.Mutex
+	var events []string
+	app := core.CreateFactory(appModule)
+	app.SetGlobalPrefix("api")
+	app.ObserveStages(func(ctx context.Context, stage core.Stage, name string) (context.Context, func(err error)) {
+		name = strings.TrimPrefix(name, "github.com/tinh-tinh/tinhtinh/v2/core_test.")
+		parent, _ := ctx.Value(stageKey{}).(string)
+		mu.Lock()
+		events = append(events, "start "+string(stage)+" "+name+" in "+parent)
+		mu.Unlock()
+		return context.WithValue(ctx, stageKey{}, string(stage)), func(err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				events = append(events, "end "+string(stage)+": "+err.Error())
+				return
+			}
+			events = append(events, "end "+string(stage))
+		}
+	})
+
+	testServer := httptest.NewServer(app.PrepareBeforeListen())
+	defer testServer.Close()
+
+	resp, err := http.Post(testServer.URL+"/api/orders", "application/json", strings.NewReader(`{"name":"book"}`))
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	data, err := io.ReadAll(resp.Body)
+	require.Nil(t, err)
+	require.Equal(t, `{"parent":"handler"}`, strings.TrimSpace(string(data)))
+	require.Equal(t, []string{
+		"start middleware stageMiddleware in ",
+		"start guard stageGuard in middleware",
+		"end guard",
+		"start pipe body OrderDto in middleware",
+		"end pipe",
+		"start handler POST /api/orders in middleware",
+		"end handler",
+		"end middleware",
+	}, events)
+
+	events = nil
+	resp, err = http.Post(testServer.URL+"/api/orders?denied=true", "application/json", strings.NewReader(`{"name":"book"}`))
+	require.Nil(t, err)
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	require.Equal(t, []string{
+		"start middleware stageMiddleware in ",
+		"start guard stageGuard in middleware",
+		"end guard: you can not access",
+		"end middleware",
+	}, events)
+
+	events = nil
+	resp, err = http.Post(testServer.URL+"/api/orders", "application/json", strings.NewReader(`{}`))
+	require.Nil(t, err)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	require.Len(t, events, 6)
+	require.True(t, strings.HasPrefix(events[4], "end pipe: "))
+
+	events = nil
+	resp, err = http.Get(testServer.URL + "/api/orders/panic")
+	require.Nil(t, err)
+	require.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	require.Equal(t, []string{
+		"start handler GET /api/orders/panic in ",
+		"end handler: boom",
+	}, events)
+}
@@ -1,11 +1,16 @@
 package core
 
 import (
+	"bytes"
 	"fmt"
 	"io"
+	"io/fs"
 	"mime"
+	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
+	"time"
 
 	"github.com/tinh-tinh/tinhtinh/v2/common"
 )
@@ -15,43 +20,120 @@ type StreamableFileOptions struct {
 	FilePath string `json:"file_path" yaml:"file_path"`
 	// Download indicates whether the file should be downloaded or displayed in the browser.
 	Download bool `json:"download" yaml:"download"`
+	// FS is the file system the file is opened from. Default is the file
+	// system of the operating system.
+	FS fs.FS `json:"-" yaml:"-"`
+	// ModTime is the Last-Modified header of the response. Default is the
+	// modification time of the file.
+	ModTime time.Time `json:"-" yaml:"-"`
+	// CacheControl is the Cache-Control header of the response, for example
+	// "no-cache". It takes precedence over MaxAge.
+	CacheControl string `json:"cache_control" yaml:"cache_control"`
+	// MaxAge sets the Cache-Control header to "public, max-age=" followed by
+	// the duration in seconds.
+	MaxAge time.Duration `json:"max_age" yaml:"max_age"`
+	// Immutable adds "immutable" to the Cache-Control header set from MaxAge.
+	Immutable bool `json:"immutable" yaml:"immutable"`
 }
 
+// StreamableFile streams the file at the given path, opened from the FS of
+// the options when it is set.
+//
+// The response has the Content-Length, Last-Modified and Accept-Ranges
+// headers. The range requests, single or multiple, are answered 206 Partial
+// Content, with a multipart/byteranges body for multiple ranges, and the
+// If-Range, If-Modified-Since and If-None-Match headers are honored. A HEAD
+// request gets the headers only. The file is closed once streamed.
 func (ctx *DefaultCtx) StreamableFile(filePath string, opts ...StreamableFileOptions) error {
-	file, err := os.Open(filePath)
+	option := mergeStreamableOptions(opts)
+
+	var (
+		file fs.File
+		err  error
+	)
+	if option.FS != nil {
+		file, err = option.FS.Open(filePath)
+	} else {
+		file, err = os.Open(filePath)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to get file %s: %w", filePath, err)
 	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to get file %s: %w", filePath, err)
+	}
+	if info.IsDir() {
+		return fmt.Errorf("failed to get file %s: is a directory", filePath)
+	}
+	if option.ModTime.IsZero() {
+		option.ModTime = info.ModTime()
+	}
+
+	content, ok := file.(io.ReadSeeker)
+	if !ok {
+		// The files of some file systems cannot seek, they are read in
+		// memory to serve the ranges.
+		data, err := io.ReadAll(file)
+		if err != nil {
+			return fmt.Errorf("failed to stream file %s: %w", filePath, err)
+		}
+		content = bytes.NewReader(data)
+	}
+
+	ctx.serveContent(filePath, content, option)
+	return nil
+}
+
+// StreamableContent streams the content like StreamableFile, from any
+// io.ReadSeeker such as a bytes.Reader. The name gives the Content-Type and
+// the default file name of the Content-Disposition header. The content is
+// not closed.
+func (ctx *DefaultCtx) StreamableContent(name string, content io.ReadSeeker, opts ...StreamableFileOptions) error {
+	ctx.serveContent(name, content, mergeStreamableOptions(opts))
+	return nil
+}
+
+func mergeStreamableOptions(opts []StreamableFileOptions) StreamableFileOptions {
+	if len(opts) == 0 {
+		return StreamableFileOptions{}
+	}
+	return common.MergeStruct(opts...)
+}
+
+// serveContent sets the headers of the options then serves the content with
+// http.ServeContent, which handles the ranges and the conditional requests.
+func (ctx *DefaultCtx) serveContent(name string, content io.ReadSeeker, option StreamableFileOptions) {
+	header := ctx.w.Header()
 
 	// Detect MIME type based on file extension
-	ext := filepath.Ext(filePath)
-	mimeType := mime.TypeByExtension(ext)
+	mimeType := mime.TypeByExtension(filepath.Ext(name))
 	if mimeType == "" {
 		mimeType = "application/octet-stream" // Default fallback
 	}
-	ctx.w.Header().Set("Content-Type", mimeType)
-
-	var option StreamableFileOptions
-	if len(opts) > 0 {
-		option = common.MergeStruct(opts...)
-	}
+	header.Set("Content-Type", mimeType)
 
 	if option.FilePath == "" {
-		option.FilePath = filePath
+		option.FilePath = name
 	}
-
 	// Set Content-Disposition: attachment for download, otherwise inline
 	dispositionType := "inline"
 	if option.Download {
 		dispositionType = "attachment"
 	}
+	header.Set("Content-Disposition", dispositionType+"; filename=\""+filepath.Base(option.FilePath)+"\"")
 
-	ctx.w.Header().Set("Content-Disposition", dispositionType+"; filename=\""+filepath.Base(option.FilePath)+"\"")
-
-	_, err = io.Copy(ctx.w, file)
-	if err != nil {
-		return fmt.Errorf("failed to stream file %s: %w", filePath, err)
+	if option.CacheControl != "" {
+		header.Set("Cache-Control", option.CacheControl)
+	} else if option.MaxAge > 0 {
+		cacheControl := "public, max-age=" + strconv.Itoa(int(option.MaxAge.Seconds()))
+		if option.Immutable {
+			cacheControl += ", immutable"
+		}
+		header.Set("Cache-Control", cacheControl)
 	}
 
-	return nil
+	http.ServeContent(ctx.w, ctx.r, name, option.ModTime, content)
 }
@@ -0,0 +1,162 @@
+package core_test
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tinh-tinh/tinhtinh/v2/core"
+)
+
+func Test_StreamableFile_Range(t *testing.T) {
+	content := []byte("0123456789abcdefghijklmnopqrstuvwxyz")
+	modified := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "video.mp4")
+	require.Nil(t, os.WriteFile(path, content, 0o644))
+	require.Nil(t, os.Chtimes(path, modified, modified))
+
+	fsys := fstest.MapFS{
+		"assets/report.txt": &fstest.MapFile{Data: content, ModTime: modified},
+	}
+
+	controller := func(module core.Module) core.Controller {
+		ctrl := module.NewController("test")
+
+		ctrl.Get("file", func(ctx core.Ctx) error {
+			return ctx.StreamableFile(path, core.StreamableFileOptions{
+				MaxAge:    time.Hour,
+				Immutable: true,
+			})
+		})
+
+		ctrl.Get("fs", func(ctx core.Ctx) error {
+			return ctx.StreamableFile("assets/report.txt", core.StreamableFileOptions{
+				FS:           fsys,
+				Download:     true,
+				CacheControl: "no-cache",
+			})
+		})
+
+		ctrl.Get("dir", func(ctx core.Ctx) error {
+			return ctx.StreamableFile("assets", core.StreamableFileOptions{FS: fsys})
+		})
+
+		ctrl.Get("content", func(ctx core.Ctx) error {
+			return ctx.StreamableContent("export.csv", bytes.NewReader(content), core.StreamableFileOptions{
+				ModTime: modified,
+			})
+		})
+
+		return ctrl
+	}
+
+	app := core.CreateFactory(func() core.Module {
+		return core.NewModule(core.NewModuleOptions{
+			Controllers: []core.Controllers{controller},
+		})
+	})
+	app.SetGlobalPrefix("/api")
+
+	testServer := httptest.NewServer(app.PrepareBeforeListen())
+	defer testServer.Close()
+
+	do := func(method string, path string, header ...string) (*http.Response, []byte) {
+		req, err := http.NewRequest(method, testServer.URL+path, nil)
+		require.Nil(t, err)
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		resp, err := testServer.Client().Do(req)
+		require.Nil(t, err)
+		data, err := io.ReadAll(resp.Body)
+		require.Nil(t, err)
+		return resp, data
+	}
+
+	resp, data := do(http.MethodGet, "/api/test/file")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, content, data)
+	require.Equal(t, "36", resp.Header.Get("Content-Length"))
+	require.Equal(t, "bytes", resp.Header.Get("Accept-Ranges"))
+	require.Equal(t, modified.Format(http.TimeFormat), resp.Header.Get("Last-Modified"))
+	require.Equal(t, "video/mp4", resp.Header.Get("Content-Type"))
+	require.Equal(t, "public, max-age=3600, immutable", resp.Header.Get("Cache-Control"))
+
+	// Single range
+	resp, data = do(http.MethodGet, "/api/test/file", "Range", "bytes=10-15")
+	require.Equal(t, http.StatusPartialContent, resp.StatusCode)
+	require.Equal(t, "abcdef", string(data))
+	require.Equal(t, "bytes 10-15/36", resp.Header.Get("Content-Range"))
+	require.Equal(t, "6", resp.Header.Get("Content-Length"))
+
+	// Resume a download
+	resp, data = do(http.MethodGet, "/api/test/file", "Range", "bytes=30-")
+	require.Equal(t, http.StatusPartialContent, resp.StatusCode)
+	require.Equal(t, "uvwxyz", string(data))
+
+	// Multiple ranges
+	resp, data = do(http.MethodGet, "/api/test/file", "Range", "bytes=0-1,34-35")
+	require.Equal(t, http.StatusPartialContent, resp.StatusCode)
+	mediaType, params, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	require.Nil(t, err)
+	require.Equal(t, "multipart/byteranges", mediaType)
+	reader := multipart.NewReader(bytes.NewReader(data), params["boundary"])
+	var parts []string
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		require.Nil(t, err)
+		body, err := io.ReadAll(part)
+		require.Nil(t, err)
+		parts = append(parts, part.Header.Get("Content-Range")+" "+string(body))
+	}
+	require.Equal(t, []string{"bytes 0-1/36 01", "bytes 34-35/36 yz"}, parts)
+
+	resp, _ = do(http.MethodGet, "/api/test/file", "Range", "bytes=100-200")
+	require.Equal(t, http.StatusRequestedRangeNotSatisfiable, resp.StatusCode)
+
+	// If-Range sends the range only when the file did not change.
+	resp, data = do(http.MethodGet, "/api/test/file", "Range", "bytes=0-1", "If-Range", modified.Format(http.TimeFormat))
+	require.Equal(t, http.StatusPartialContent, resp.StatusCode)
+	require.Equal(t, "01", string(data))
+	resp, data = do(http.MethodGet, "/api/test/file", "Range", "bytes=0-1", "If-Range", modified.Add(-time.Hour).Format(http.TimeFormat))
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, content, data)
+
+	resp, _ = do(http.MethodGet, "/api/test/file", "If-Modified-Since", modified.Format(http.TimeFormat))
+	require.Equal(t, http.StatusNotModified, resp.StatusCode)
+
+	resp, data = do(http.MethodHead, "/api/test/file")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "36", resp.Header.Get("Content-Length"))
+	require.Empty(t, data)
+
+	resp, data = do(http.MethodGet, "/api/test/fs", "Range", "bytes=0-3")
+	require.Equal(t, http.StatusPartialContent, resp.StatusCode)
+	require.Equal(t, "0123", string(data))
+	require.Equal(t, "no-cache", resp.Header.Get("Cache-Control"))
+	require.Equal(t, `attachment; filename="report.txt"`, resp.Header.Get("Content-Disposition"))
+	require.Equal(t, modified.Format(http.TimeFormat), resp.Header.Get("Last-Modified"))
+
+	resp, _ = do(http.MethodGet, "/api/test/dir")
+	require.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+
+	resp, data = do(http.MethodGet, "/api/test/content", "Range", "bytes=-3")
+	require.Equal(t, http.StatusPartialContent, resp.StatusCode)
+	require.Equal(t, "xyz", string(data))
+	require.Equal(t, `inline; filename="export.csv"`, resp.Header.Get("Content-Disposition"))
+	require.Equal(t, modified.Format(http.TimeFormat), resp.Header.Get("Last-Modified"))
+}
@@ -23,16 +23,26 @@ type ControllerNode struct {
 }
 
 // ProviderNode represents a provider registered in a module.
+//
+// Owner is the module registering the provider and VisibleIn lists the
+// modules that can inject it. Global is set for the exports of global
+// modules, which are visible in every module.
 type ProviderNode struct {
-	Name   string        `json:"name"`
-	Scope  Scope         `json:"scope"`
-	Status ProvideStatus `json:"status"`
+	Name      string        `json:"name"`
+	Scope     Scope         `json:"scope"`
+	Status    ProvideStatus `json:"status"`
+	Owner     string        `json:"owner,omitempty"`
+	Global    bool          `json:"global,omitempty"`
+	VisibleIn []string      `json:"visibleIn,omitempty"`
 }
 
 // ModuleNode is a recursive description of the module hierarchy.
 type ModuleNode struct {
 	Name        string           `json:"name"`
 	Scope       Scope            `json:"scope"`
+	Global      bool             `json:"global,omitempty"`
+	Disabled    bool             `json:"disabled,omitempty"`
+	ReExports   []string         `json:"reExports,omitempty"`
 	Controllers []ControllerNode `json:"controllers,omitempty"`
 	Providers   []ProviderNode   `json:"providers,omitempty"`
 	Imports     []*ModuleNode    `json:"imports,omitempty"`
@@ -46,10 +56,13 @@ type ModuleNode struct {
 // It groups Router entries by controller name to reconstruct controllers,
 // and reads DataProviders that are PRIVATE (own providers, not re-exported
 // imports) directly.
-func buildModuleNode(module *DynamicModule) *ModuleNode {
+func buildModuleNode(module *DynamicModule, vis *visibility) *ModuleNode {
 	node := &ModuleNode{
-		Name:  module.Name,
-		Scope: module.Scope,
+		Name:      module.Name,
+		Scope:     module.Scope,
+		Global:    module.global,
+		Disabled:  module.disabled,
+		ReExports: module.reExports,
 	}
 
 	// Use SnapshotRouters when available (set before free() clears Routers),
@@ -76,21 +89,68 @@ func buildModuleNode(module *DynamicModule) *ModuleNode {
 
 	// --- Collect own providers ---
 	for _, p := range module.DataProviders {
-		node.Providers = append(node.Providers, ProviderNode{
+		pn := ProviderNode{
 			Name:   string(p.GetName()),
 			Scope:  p.GetScope(),
 			Status: p.GetStatus(),
-		})
+		}
+		if owner := vis.owners[p]; owner != nil {
+			pn.Owner = owner.Name
+		}
+		if vis.globals[p] {
+			pn.Global = true
+			pn.VisibleIn = vis.modules
+		} else {
+			pn.VisibleIn = vis.visibleIn[p]
+		}
+		node.Providers = append(node.Providers, pn)
 	}
 
 	// --- Recurse into sub-modules ---
 	for _, sub := range module.SubModules {
-		node.Imports = append(node.Imports, buildModuleNode(sub))
+		node.Imports = append(node.Imports, buildModuleNode(sub, vis))
 	}
 
 	return node
 }
 
+// visibility records which modules can inject each provider of the tree.
+type visibility struct {
+	owners    map[Provider]*DynamicModule
+	globals   map[Provider]bool
+	visibleIn map[Provider][]string
+	modules   []string
+}
+
+// buildVisibility walks the module tree rooted at the module.
+func buildVisibility(root *DynamicModule) *visibility {
+	vis := &visibility{
+		owners:    root.owners,
+		globals:   make(map[Provider]bool),
+		visibleIn: make(map[Provider][]string),
+	}
+	for _, p := range root.globals {
+		vis.globals[p] = true
+	}
+
+	var walk func(module *DynamicModule)
+	walk = func(module *DynamicModule) {
+		if module.disabled {
+			return
+		}
+		vis.modules = append(vis.modules, module.Name)
+		for _, p := range module.DataProviders {
+			vis.visibleIn[p] = append(vis.visibleIn[p], module.Name)
+		}
+		for _, sub := range module.SubModules {
+			walk(sub)
+		}
+	}
+	walk(root)
+
+	return vis
+}
+
 // ---------------------------------------------------------------------------
 // App methods
 // ---------------------------------------------------------------------------
@@ -102,7 +162,7 @@ func (app *App) GetTree() *ModuleNode {
 	if !ok {
 		return nil
 	}
-	return buildModuleNode(dynMod)
+	return buildModuleNode(dynMod, buildVisibility(dynMod))
 }
 
 // ---------------------------------------------------------------------------
@@ -403,6 +463,7 @@ const visualizeTemplate = `<!DOCTYPE html>
   <span class="subtitle">Module Tree Visualizer</span>
   <div class="legend">
     <div class="legend-item"><div class="legend-dot" style="background:#6366f1"></div> Module</div>
+    <div class="legend-item"><div class="legend-dot" style="background:#6b7280"></div> Module (disabled)</div>
     <div class="legend-item"><div class="legend-dot" style="background:#22d3ee"></div> Controller</div>
     <div class="legend-item"><div class="legend-dot" style="background:#34d399"></div> Provider (private)</div>
     <div class="legend-item"><div class="legend-dot" style="background:#a78bfa"></div> Provider (public)</div>
@@ -442,6 +503,9 @@ function buildHierarchy(mod, depth) {
     name: mod.name || 'AppModule',
     kind: 'module',
     scope: mod.scope,
+    global: mod.global,
+    disabled: mod.disabled,
+    reExports: mod.reExports || [],
     _controllers: mod.controllers || [],
     _providers: mod.providers || [],
     children: [],
@@ -468,6 +532,9 @@ function buildHierarchy(mod, depth) {
       kind: 'provider',
       scope: prov.scope,
       status: prov.status,
+      owner: prov.owner,
+      global: prov.global,
+      visibleIn: prov.visibleIn || [],
       children: [],
       _collapsed: false,
     });
@@ -490,13 +557,14 @@ const NODE_SEP_Y = 52;
 // ── Colors ────────────────────────────────────────────────────────────────
 const COLOR = {
   module:     '#6366f1',
+  module_disabled: '#6b7280',
   controller: '#22d3ee',
   provider_private: '#34d399',
   provider_public:  '#a78bfa',
 };
 
 function nodeColor(d) {
-  if (d.data.kind === 'module')     return COLOR.module;
+  if (d.data.kind === 'module')     return d.data.disabled ? COLOR.module_disabled : COLOR.module;
   if (d.data.kind === 'controller') return COLOR.controller;
   if (d.data.kind === 'provider')
     return d.data.status === 'public' ? COLOR.provider_public : COLOR.provider_private;
@@ -523,7 +591,15 @@ function showTooltip(event, d) {
   if (data.kind === 'module') {
     html = '<h3>' + escHtml(data.name) + ' <span class="badge badge-module">Module</span></h3>';
     html += '<div class="section-title">Scope</div>';
-    html += '<ul><li>' + escHtml(data.scope || 'global') + '</li></ul>';
+    html += '<ul><li>' + escHtml(data.scope || 'global') + '</li>';
+    if (data.global) html += '<li>🌐 Global module</li>';
+    if (data.disabled) html += '<li>⏸ Disabled: condition not met</li>';
+    html += '</ul>';
+    if (data.reExports && data.reExports.length) {
+      html += '<div class="section-title">Re-exports</div><ul>';
+      data.reExports.forEach(m => { html += '<li>↪ ' + escHtml(m) + '</li>'; });
+      html += '</ul>';
+    }
     if (data._controllers && data._controllers.length) {
       html += '<div class="section-title">Controllers (' + data._controllers.length + ')</div><ul>';
       data._controllers.forEach(c => {
@@ -559,7 +635,14 @@ function showTooltip(event, d) {
     html += '<div class="section-title">Details</div><ul>';
     html += '<li>Scope: ' + escHtml(data.scope || 'global') + '</li>';
     html += '<li>Status: ' + escHtml(data.status || 'private') + '</li>';
+    if (data.owner) html += '<li>Owner: ' + escHtml(data.owner) + '</li>';
+    if (data.global) html += '<li>🌐 Global</li>';
     html += '</ul>';
+    if (data.visibleIn && data.visibleIn.length) {
+      html += '<div class="section-title">Visible in (' + data.visibleIn.length + ')</div><ul>';
+      data.visibleIn.forEach(m => { html += '<li>' + escHtml(m) + '</li>'; });
+      html += '</ul>';
+    }
   }
 
   tooltip.innerHTML = html;
@@ -0,0 +1,13 @@
+//go:build !linux && !darwin && !freebsd
+
+package health
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// diskUsage is not supported on this platform.
+func diskUsage(path string) (total uint64, free uint64, err error) {
+	return 0, 0, fmt.Errorf("disk indicator is not supported on %s", runtime.GOOS)
+}
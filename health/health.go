@@ -0,0 +1,227 @@
+// Package health serves the liveness and readiness endpoints used by
+// orchestrators such as Kubernetes.
+//
+// Each endpoint runs its indicators concurrently and answers with a report
+// giving the status and the duration of every check: 200 when all of them are
+// up, 503 otherwise. Once the application receives a shutdown signal, the
+// readiness endpoint fails so the load balancer stops routing traffic to the
+// instance before the server stops.
+package health
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/tinh-tinh/tinhtinh/v2/core"
+)
+
+// SERVICE is the name of the provider of the *Service of the module.
+const SERVICE core.Provide = "HEALTH_SERVICE"
+
+// DefaultPath is the path the endpoints are served at when none is given.
+const DefaultPath = "health"
+
+// DefaultTimeout is the time an indicator has to complete its check.
+const DefaultTimeout = 5 * time.Second
+
+// DefaultDrainDelay is the time the shutdown waits once the readiness fails
+// when no DrainDelay is given, a few periods of a usual readiness probe.
+const DefaultDrainDelay = 5 * time.Second
+
+type Status string
+
+const (
+	UP   Status = "up"
+	DOWN Status = "down"
+)
+
+type Options struct {
+	// Path the endpoints are served at, below the global prefix. Default is
+	// "health".
+	Path string
+	// Live are the indicators of GET /{path}/live. They should only fail
+	// when the process must be restarted.
+	Live []Indicator
+	// Ready are the indicators of GET /{path}/ready, usually the
+	// dependencies needed to serve requests.
+	Ready []Indicator
+	// Timeout of each indicator. Default is DefaultTimeout.
+	Timeout time.Duration
+	// DrainDelay is the time the shutdown waits once the readiness fails, so
+	// the load balancer notices it before the server stops. Default is
+	// DefaultDrainDelay, a negative delay does not wait.
+	DrainDelay time.Duration
+}
+
+// CheckResult is the result of an indicator.
+type CheckResult struct {
+	Name     string  `json:"name"`
+	Status   Status  `json:"status"`
+	Duration string  `json:"duration"`
+	Details  Details `json:"details,omitempty"`
+	Error    string  `json:"error,omitempty"`
+}
+
+// Report is the response of the endpoints. Status is DOWN if any check is.
+type Report struct {
+	Status Status        `json:"status"`
+	Checks []CheckResult `json:"checks"`
+}
+
+// Service runs the indicators of the module.
+type Service struct {
+	opt          Options
+	shuttingDown atomic.Bool
+}
+
+// Live runs the liveness indicators.
+func (s *Service) Live(ctx context.Context) Report {
+	return s.run(ctx, s.opt.Live)
+}
+
+// Ready runs the readiness indicators. The report is DOWN once the
+// application is shutting down.
+func (s *Service) Ready(ctx context.Context) Report {
+	report := s.run(ctx, s.opt.Ready)
+	if s.shuttingDown.Load() {
+		report.Status = DOWN
+		report.Checks = append(report.Checks, CheckResult{
+			Name:     "shutdown",
+			Status:   DOWN,
+			Duration: "0s",
+			Error:    "application is shutting down",
+		})
+	}
+	return report
+}
+
+// BeforeApplicationShutdown makes the readiness fail then waits for the
+// drain delay.
+func (s *Service) BeforeApplicationShutdown(signal string) error {
+	s.shuttingDown.Store(true)
+	if s.opt.DrainDelay > 0 {
+		time.Sleep(s.opt.DrainDelay)
+	}
+	return nil
+}
+
+// run checks the indicators concurrently, each one with the timeout.
+func (s *Service) run(ctx context.Context, indicators []Indicator) Report {
+	report := Report{Status: UP, Checks: make([]CheckResult, len(indicators))}
+
+	var wg sync.WaitGroup
+	for i, indicator := range indicators {
+		wg.Add(1)
+		go func(i int, indicator Indicator) {
+			defer wg.Done()
+			report.Checks[i] = s.check(ctx, indicator)
+		}(i, indicator)
+	}
+	wg.Wait()
+
+	for _, check := range report.Checks {
+		if check.Status == DOWN {
+			report.Status = DOWN
+		}
+	}
+	return report
+}
+
+// check runs the indicator. A check not completed within the timeout is down,
+// even if the indicator ignores the context.
+func (s *Service) check(ctx context.Context, indicator Indicator) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, s.opt.Timeout)
+	defer cancel()
+
+	type outcome struct {
+		details Details
+		err     error
+	}
+	done := make(chan outcome, 1)
+	start := time.Now()
+	go func() {
+		details, err := indicator.Check(ctx)
+		done <- outcome{details, err}
+	}()
+
+	var res outcome
+	select {
+	case res = <-done:
+	case <-ctx.Done():
+		res.err = ctx.Err()
+	}
+
+	result := CheckResult{
+		Name:     indicator.Name(),
+		Status:   UP,
+		Duration: time.Since(start).String(),
+		Details:  res.details,
+	}
+	if res.err != nil {
+		result.Status = DOWN
+		result.Error = res.err.Error()
+	}
+	return result
+}
+
+// Module creates a module serving the health endpoints:
+//
+//   - GET /{path}/live: the liveness indicators
+//   - GET /{path}/ready: the readiness indicators
+//
+// The routes are excluded from the API documentation.
+func Module(opt Options) core.Modules {
+	if opt.Path == "" {
+		opt.Path = DefaultPath
+	}
+	if opt.Timeout <= 0 {
+		opt.Timeout = DefaultTimeout
+	}
+	if opt.DrainDelay == 0 {
+		opt.DrainDelay = DefaultDrainDelay
+	}
+
+	controller := func(module core.Module) core.Controller {
+		ctrl := module.NewController(opt.Path)
+		ctrl.Metadata(core.SetMetadata(core.DOC_EXCLUDE, true)).Registry()
+
+		ctrl.Get("live", func(ctx core.Ctx) error {
+			svc := module.Ref(SERVICE).(*Service)
+			return sendReport(ctx, svc.Live(ctx.Req().Context()))
+		})
+
+		ctrl.Get("ready", func(ctx core.Ctx) error {
+			svc := module.Ref(SERVICE).(*Service)
+			return sendReport(ctx, svc.Ready(ctx.Req().Context()))
+		})
+
+		return ctrl
+	}
+
+	return func(module core.Module) core.Module {
+		healthModule := module.New(core.NewModuleOptions{})
+		svc := &Service{opt: opt}
+		svc.opt.Live = bindAll(healthModule, opt.Live)
+		svc.opt.Ready = bindAll(healthModule, opt.Ready)
+
+		healthModule.NewProvider(core.ProviderOptions{
+			Name:  SERVICE,
+			Value: svc,
+		})
+		healthModule.Export(SERVICE)
+		healthModule.Controllers(controller)
+		return healthModule
+	}
+}
+
+// sendReport writes the report with 200 if it is up, 503 otherwise.
+func sendReport(ctx core.Ctx, report Report) error {
+	status := http.StatusOK
+	if report.Status == DOWN {
+		status = http.StatusServiceUnavailable
+	}
+	return ctx.Status(status).JSON(report)
+}
@@ -0,0 +1,175 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tinh-tinh/tinhtinh/v2/core"
+	"github.com/tinh-tinh/tinhtinh/v2/health"
+)
+
+type database struct {
+	err error
+}
+
+func (d *database) HealthCheck(ctx context.Context) error {
+	return d.err
+}
+
+func getReport(t *testing.T, url string) (int, health.Report) {
+	resp, err := http.Get(url)
+	require.Nil(t, err)
+	defer resp.Body.Close()
+
+	var report health.Report
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&report))
+	return resp.StatusCode, report
+}
+
+func Test_Health(t *testing.T) {
+	db := &database{}
+	appModule := func() core.Module {
+		module := core.NewModule(core.NewModuleOptions{
+			Imports: []core.Modules{health.Module(health.Options{
+				Live: []health.Indicator{health.Memory(1 << 40)},
+				Ready: []health.Indicator{
+					health.Provider("db"),
+					health.Disk(t.TempDir(), 1),
+					health.Check("cache", func(ctx context.Context) error { return nil }),
+				},
+			})},
+		})
+		module.NewProvider(core.ProviderOptions{
+			Name:  "db",
+			Value: db,
+		})
+		return module
+	}
+
+	app := core.CreateFactory(appModule)
+	app.SetGlobalPrefix("api")
+
+	testServer := httptest.NewServer(app.PrepareBeforeListen())
+	defer testServer.Close()
+
+	status, report := getReport(t, testServer.URL+"/api/health/live")
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, health.UP, report.Status)
+	require.Len(t, report.Checks, 1)
+	require.Equal(t, "memory", report.Checks[0].Name)
+	require.NotEmpty(t, report.Checks[0].Duration)
+	require.Contains(t, report.Checks[0].Details, "heapAlloc")
+
+	status, report = getReport(t, testServer.URL+"/api/health/ready")
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, health.UP, report.Status)
+	require.Len(t, report.Checks, 3)
+	require.Equal(t, "db", report.Checks[0].Name)
+	require.Equal(t, "disk", report.Checks[1].Name)
+	require.Equal(t, "cache", report.Checks[2].Name)
+
+	db.err = errors.New("connection refused")
+	status, report = getReport(t, testServer.URL+"/api/health/ready")
+	require.Equal(t, http.StatusServiceUnavailable, status)
+	require.Equal(t, health.DOWN, report.Status)
+	require.Equal(t, health.DOWN, report.Checks[0].Status)
+	require.Equal(t, "connection refused", report.Checks[0].Error)
+	require.Equal(t, health.UP, report.Checks[2].Status)
+}
+
+func Test_Health_Shutdown(t *testing.T) {
+	appModule := func() core.Module {
+		return core.NewModule(core.NewModuleOptions{
+			Imports: []core.Modules{health.Module(health.Options{
+				Path:       "status",
+				DrainDelay: 200 * time.Millisecond,
+			})},
+		})
+	}
+
+	app := core.CreateFactory(appModule)
+	testServer := httptest.NewServer(app.PrepareBeforeListen())
+	defer testServer.Close()
+
+	status, _ := getReport(t, testServer.URL+"/status/ready")
+	require.Equal(t, http.StatusOK, status)
+
+	svc := app.Module.Ref(health.SERVICE).(*health.Service)
+	drained := make(chan error, 1)
+	go func() {
+		drained <- svc.BeforeApplicationShutdown("SIGTERM")
+	}()
+
+	// The readiness fails while the shutdown waits for the drain delay.
+	require.Eventually(t, func() bool {
+		status, _ := getReport(t, testServer.URL+"/status/ready")
+		return status == http.StatusServiceUnavailable
+	}, 100*time.Millisecond, 5*time.Millisecond)
+	require.Empty(t, drained)
+
+	status, report := getReport(t, testServer.URL+"/status/ready")
+	require.Equal(t, http.StatusServiceUnavailable, status)
+	require.Equal(t, "shutdown", report.Checks[0].Name)
+
+	status, _ = getReport(t, testServer.URL+"/status/live")
+	require.Equal(t, http.StatusOK, status)
+
+	select {
+	case err := <-drained:
+		require.Nil(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("the shutdown does not end after the drain delay")
+	}
+}
+
+func Test_Health_NoDrain(t *testing.T) {
+	appModule := func() core.Module {
+		return core.NewModule(core.NewModuleOptions{
+			Imports: []core.Modules{health.Module(health.Options{DrainDelay: -1})},
+		})
+	}
+
+	app := core.CreateFactory(appModule)
+	svc := app.Module.Ref(health.SERVICE).(*health.Service)
+
+	start := time.Now()
+	require.Nil(t, svc.BeforeApplicationShutdown("SIGTERM"))
+	require.Less(t, time.Since(start), health.DefaultDrainDelay)
+	require.Equal(t, health.DOWN, svc.Ready(context.Background()).Status)
+}
+
+func Test_Health_Timeout(t *testing.T) {
+	appModule := func() core.Module {
+		return core.NewModule(core.NewModuleOptions{
+			Imports: []core.Modules{health.Module(health.Options{
+				Timeout: 10 * time.Millisecond,
+				Ready: []health.Indicator{
+					health.Check("slow", func(ctx context.Context) error {
+						time.Sleep(time.Second)
+						return nil
+					}),
+					health.Memory(1),
+					health.Provider("missing"),
+				},
+			})},
+		})
+	}
+
+	app := core.CreateFactory(appModule)
+	testServer := httptest.NewServer(app.PrepareBeforeListen())
+	defer testServer.Close()
+
+	start := time.Now()
+	status, report := getReport(t, testServer.URL+"/health/ready")
+	require.Less(t, time.Since(start), time.Second)
+	require.Equal(t, http.StatusServiceUnavailable, status)
+	require.Equal(t, context.DeadlineExceeded.Error(), report.Checks[0].Error)
+	require.Contains(t, report.Checks[1].Error, "exceeds")
+	require.Contains(t, report.Checks[2].Error, "not found")
+}
@@ -0,0 +1,133 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+
+	"github.com/tinh-tinh/tinhtinh/v2/core"
+)
+
+// Details are the values reported by an indicator, such as the usage it
+// measured.
+type Details map[string]interface{}
+
+// Indicator checks a part of the application. Check returns an error when the
+// part is down; it should stop when the context is done.
+type Indicator interface {
+	Name() string
+	Check(ctx context.Context) (Details, error)
+}
+
+// Checker is implemented by the providers checked by the Provider indicator.
+type Checker interface {
+	HealthCheck(ctx context.Context) error
+}
+
+// binder is implemented by the indicators needing the health module.
+type binder interface {
+	bind(module core.Module) Indicator
+}
+
+// bindAll binds the indicators needing it to the module.
+func bindAll(module core.Module, indicators []Indicator) []Indicator {
+	bound := make([]Indicator, 0, len(indicators))
+	for _, indicator := range indicators {
+		if b, ok := indicator.(binder); ok {
+			indicator = b.bind(module)
+		}
+		bound = append(bound, indicator)
+	}
+	return bound
+}
+
+type checkIndicator struct {
+	name string
+	fnc  func(ctx context.Context) (Details, error)
+}
+
+func (c *checkIndicator) Name() string {
+	return c.name
+}
+
+func (c *checkIndicator) Check(ctx context.Context) (Details, error) {
+	return c.fnc(ctx)
+}
+
+// Check creates an indicator from a function.
+func Check(name string, fnc func(ctx context.Context) error) Indicator {
+	return &checkIndicator{name: name, fnc: func(ctx context.Context) (Details, error) {
+		return nil, fnc(ctx)
+	}}
+}
+
+// Memory creates an indicator failing when the heap of the process exceeds
+// maxHeap bytes.
+func Memory(maxHeap uint64) Indicator {
+	return &checkIndicator{name: "memory", fnc: func(ctx context.Context) (Details, error) {
+		var stats runtime.MemStats
+		runtime.ReadMemStats(&stats)
+		details := Details{"heapAlloc": stats.HeapAlloc, "maxHeap": maxHeap}
+		if stats.HeapAlloc > maxHeap {
+			return details, fmt.Errorf("heap %d bytes exceeds %d bytes", stats.HeapAlloc, maxHeap)
+		}
+		return details, nil
+	}}
+}
+
+// Disk creates an indicator failing when the used space of the file system
+// holding the path exceeds maxUsed, a ratio between 0 and 1.
+func Disk(path string, maxUsed float64) Indicator {
+	return &checkIndicator{name: "disk", fnc: func(ctx context.Context) (Details, error) {
+		total, free, err := diskUsage(path)
+		if err != nil {
+			return nil, err
+		}
+		used := 0.0
+		if total > 0 {
+			used = float64(total-free) / float64(total)
+		}
+		details := Details{"path": path, "total": total, "free": free, "used": used}
+		if used > maxUsed {
+			return details, fmt.Errorf("disk usage %.2f exceeds %.2f", used, maxUsed)
+		}
+		return details, nil
+	}}
+}
+
+type providerIndicator struct {
+	name   core.Provide
+	module core.Module
+}
+
+// Provider creates an indicator calling the HealthCheck method of the
+// provider. The provider is looked up in the health module, then in the root
+// module, so it can be registered by the root module or exported to it.
+func Provider(name core.Provide) Indicator {
+	return &providerIndicator{name: name}
+}
+
+func (p *providerIndicator) Name() string {
+	return string(p.name)
+}
+
+func (p *providerIndicator) bind(module core.Module) Indicator {
+	return &providerIndicator{name: p.name, module: module}
+}
+
+func (p *providerIndicator) Check(ctx context.Context) (Details, error) {
+	if p.module == nil {
+		return nil, fmt.Errorf("provider %s is not bound to the health module", p.name)
+	}
+	value := p.module.Ref(p.name)
+	if value == nil {
+		if app, ok := p.module.Ref(core.APP).(*core.App); ok {
+			value = app.Module.Ref(p.name)
+		}
+	}
+	checker, ok := value.(Checker)
+	if !ok {
+		return nil, fmt.Errorf("provider %s not found or does not implement health.Checker", p.name)
+	}
+	return nil, checker.HealthCheck(ctx)
+}
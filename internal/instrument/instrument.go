@@ -0,0 +1,82 @@
+// Package instrument holds the code shared by the modules instrumenting the
+// application, such as the metrics and tracing modules.
+package instrument
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/tinh-tinh/tinhtinh/v2/core"
+)
+
+// Bootstrap installs an instrumentation once the application is bootstrapped:
+// use is called with the App the module belongs to, and observe with the
+// value of every provider of the application, to observe the ones running
+// handlers outside of the HTTP routes.
+func Bootstrap(module core.Module, use func(app *core.App), observe func(value interface{})) {
+	if app, ok := module.Ref(core.APP).(*core.App); ok {
+		use(app)
+	}
+	if discovery, ok := module.Ref(core.DISCOVERY).(*core.DiscoveryService); ok {
+		for _, p := range discovery.Providers() {
+			observe(p.Value)
+		}
+	}
+}
+
+// Route returns the path pattern and the version of the route of the App
+// matching the request.
+func Route(app *core.App, r *http.Request) (path string, version string, ok bool) {
+	route, ok := app.MatchRoute(r)
+	if !ok {
+		return "", "", false
+	}
+	path = route.Pattern
+	if _, p, found := strings.Cut(route.Pattern, " "); found {
+		path = p
+	}
+	return path, route.Version, true
+}
+
+// StatusRecorder records the status code written to the response.
+type StatusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+// NewStatusRecorder wraps the response writer.
+func NewStatusRecorder(w http.ResponseWriter) *StatusRecorder {
+	return &StatusRecorder{ResponseWriter: w}
+}
+
+// Status returns the status code written, 200 if the handler wrote none.
+func (r *StatusRecorder) Status() int {
+	if r.status == 0 {
+		return http.StatusOK
+	}
+	return r.status
+}
+
+func (r *StatusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *StatusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *StatusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (r *StatusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,169 @@
+// Package metrics records the metrics of the application and exposes them in
+// the Prometheus text exposition format.
+//
+// The module instruments every HTTP request, labelled by the pattern of the
+// route rather than the raw URL, and the handlers of the microservices. The
+// providers register their own metrics on the Registry:
+//
+//	func NewOrderService(module core.Module) core.Provider {
+//		registry := module.Ref(metrics.REGISTRY).(*metrics.Registry)
+//		created := registry.NewCounter("orders_created_total", "Orders created.", "channel")
+//		...
+//	}
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/tinh-tinh/tinhtinh/v2/core"
+	"github.com/tinh-tinh/tinhtinh/v2/internal/instrument"
+)
+
+// REGISTRY is the name of the provider of the *Registry. The metrics module
+// is global, so the registry can be injected in every module.
+const REGISTRY core.Provide = "METRICS_REGISTRY"
+
+// instrumentation is the name of the provider instrumenting the application.
+const instrumentation core.Provide = "METRICS_INSTRUMENTATION"
+
+// DefaultPath is the path the metrics are served at when none is given.
+const DefaultPath = "metrics"
+
+// unmatched labels the requests matching no route.
+const unmatched = "unmatched"
+
+type Options struct {
+	// Path the metrics are served at, below the global prefix. Default is
+	// "metrics".
+	Path string
+	// Buckets of the latency histograms, in seconds. Default is
+	// DefaultBuckets.
+	Buckets []float64
+	// Registry holding the metrics. Default is a new registry.
+	Registry *Registry
+}
+
+// HandlerObservable is implemented by the providers running handlers outside
+// of the HTTP routes, such as the store of the microservices package. The
+// observer is called when a handler starts and the function it returns when
+// the handler returned.
+type HandlerObservable interface {
+	AddHandlerObserver(observer func(kind string, name string) func(err error))
+}
+
+// Module creates a global module recording the metrics of the application
+// and serving them at GET /{path}. The routes are excluded from the API
+// documentation.
+//
+// The following metrics are recorded:
+//
+//   - http_requests_total and http_request_duration_seconds, labelled by
+//     method, route, status and version
+//   - microservice_handled_total and microservice_handle_duration_seconds,
+//     labelled by kind (event or rpc), name and status (success or failure)
+func Module(opt Options) core.Modules {
+	if opt.Path == "" {
+		opt.Path = DefaultPath
+	}
+
+	controller := func(module core.Module) core.Controller {
+		ctrl := module.NewController(opt.Path)
+		ctrl.Metadata(core.SetMetadata(core.DOC_EXCLUDE, true)).Registry()
+
+		ctrl.Get("", func(ctx core.Ctx) error {
+			registry := module.Ref(REGISTRY).(*Registry)
+			ctx.Res().Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+			_, err := registry.WriteTo(ctx.Res())
+			return err
+		})
+
+		return ctrl
+	}
+
+	return func(module core.Module) core.Module {
+		registry := opt.Registry
+		if registry == nil {
+			registry = NewRegistry()
+		}
+
+		metricsModule := module.New(core.NewModuleOptions{Global: true})
+		metricsModule.NewProvider(core.ProviderOptions{
+			Name:  REGISTRY,
+			Value: registry,
+		})
+		metricsModule.Export(REGISTRY)
+		metricsModule.NewProvider(core.ProviderOptions{
+			Name:  instrumentation,
+			Value: newInstrumenter(metricsModule, registry, opt.Buckets),
+		})
+		metricsModule.Controllers(controller)
+		return metricsModule
+	}
+}
+
+// instrumenter records the metrics of the HTTP requests and of the handlers.
+type instrumenter struct {
+	module          core.Module
+	requests        *Counter
+	requestDuration *Histogram
+	handled         *Counter
+	handleDuration  *Histogram
+}
+
+func newInstrumenter(module core.Module, registry *Registry, buckets []float64) *instrumenter {
+	return &instrumenter{
+		module:          module,
+		requests:        registry.NewCounter("http_requests_total", "Number of HTTP requests.", "method", "route", "status", "version"),
+		requestDuration: registry.NewHistogram("http_request_duration_seconds", "Duration of HTTP requests in seconds.", buckets, "method", "route", "status", "version"),
+		handled:         registry.NewCounter("microservice_handled_total", "Number of handled microservice messages.", "kind", "name", "status"),
+		handleDuration:  registry.NewHistogram("microservice_handle_duration_seconds", "Duration of microservice handlers in seconds.", buckets, "kind", "name", "status"),
+	}
+}
+
+// OnApplicationBootstrap installs the HTTP middleware on the App and the
+// observer on the providers running handlers.
+func (i *instrumenter) OnApplicationBootstrap() error {
+	instrument.Bootstrap(i.module, func(app *core.App) {
+		app.Use(i.middleware(app))
+	}, func(value interface{}) {
+		if observable, ok := value.(HandlerObservable); ok {
+			observable.AddHandlerObserver(i.observeHandler)
+		}
+	})
+	return nil
+}
+
+// middleware records the requests served by the App.
+func (i *instrumenter) middleware(app *core.App) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rec := instrument.NewStatusRecorder(w)
+			next.ServeHTTP(rec, r)
+
+			route, version, ok := instrument.Route(app, r)
+			if !ok {
+				route = unmatched
+			}
+
+			labels := []string{r.Method, route, strconv.Itoa(rec.Status()), version}
+			i.requests.Inc(labels...)
+			i.requestDuration.Observe(time.Since(start).Seconds(), labels...)
+		})
+	}
+}
+
+// observeHandler records a handler of the microservices.
+func (i *instrumenter) observeHandler(kind string, name string) func(err error) {
+	start := time.Now()
+	return func(err error) {
+		status := "success"
+		if err != nil {
+			status = "failure"
+		}
+		i.handled.Inc(kind, name, status)
+		i.handleDuration.Observe(time.Since(start).Seconds(), kind, name, status)
+	}
+}
@@ -0,0 +1,116 @@
+package metrics_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tinh-tinh/tinhtinh/v2/core"
+	"github.com/tinh-tinh/tinhtinh/v2/metrics"
+)
+
+const ORDER_SERVICE core.Provide = "ORDER_SERVICE"
+
+type orderService struct {
+	created *metrics.Counter
+}
+
+func orderModule(module core.Module) core.Module {
+	mod := module.New(core.NewModuleOptions{})
+	mod.NewProvider(core.ProviderOptions{
+		Name: ORDER_SERVICE,
+		Factory: func(param ...interface{}) interface{} {
+			registry := param[0].(*metrics.Registry)
+			return &orderService{created: registry.NewCounter("orders_created_total", "Orders created.", "channel")}
+		},
+		Inject: []core.Provide{metrics.REGISTRY},
+	})
+
+	mod.Controllers(func(module core.Module) core.Controller {
+		ctrl := module.NewController("orders")
+		ctrl.Get("{id}", func(ctx core.Ctx) error {
+			return ctx.JSON(core.Map{"data": ctx.Path("id")})
+		})
+		ctrl.Post("", func(ctx core.Ctx) error {
+			svc := module.Ref(ORDER_SERVICE).(*orderService)
+			svc.created.Inc("web")
+			return ctx.Status(http.StatusCreated).JSON(core.Map{"data": "created"})
+		})
+		return ctrl
+	})
+	return mod
+}
+
+func scrape(t *testing.T, url string) string {
+	resp, err := http.Get(url)
+	require.Nil(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Contains(t, resp.Header.Get("Content-Type"), "text/plain")
+
+	body, err := io.ReadAll(resp.Body)
+	require.Nil(t, err)
+	return string(body)
+}
+
+func Test_Module(t *testing.T) {
+	appModule := func() core.Module {
+		return core.NewModule(core.NewModuleOptions{
+			Imports: []core.Modules{
+				metrics.Module(metrics.Options{Buckets: []float64{1}}),
+				orderModule,
+			},
+		})
+	}
+
+	app := core.CreateFactory(appModule)
+	app.SetGlobalPrefix("api")
+
+	testServer := httptest.NewServer(app.PrepareBeforeListen())
+	defer testServer.Close()
+
+	for _, id := range []string{"1", "2"} {
+		resp, err := http.Get(testServer.URL + "/api/orders/" + id)
+		require.Nil(t, err)
+		resp.Body.Close()
+	}
+	resp, err := http.Post(testServer.URL+"/api/orders", "application/json", nil)
+	require.Nil(t, err)
+	resp.Body.Close()
+	resp, err = http.Get(testServer.URL + "/api/unknown")
+	require.Nil(t, err)
+	resp.Body.Close()
+
+	body := scrape(t, testServer.URL+"/api/metrics")
+	require.Contains(t, body, "# TYPE http_requests_total counter\n")
+	require.Contains(t, body, `http_requests_total{method="GET",route="/api/orders/{id}",status="200",version=""} 2`)
+	require.Contains(t, body, `http_requests_total{method="POST",route="/api/orders",status="201",version=""} 1`)
+	require.Contains(t, body, `http_requests_total{method="GET",route="unmatched",status="404",version=""} 1`)
+	require.Contains(t, body, `http_request_duration_seconds_bucket{method="GET",route="/api/orders/{id}",status="200",version="",le="1"} 2`)
+	require.Contains(t, body, `http_request_duration_seconds_count{method="GET",route="/api/orders/{id}",status="200",version=""} 2`)
+	require.Contains(t, body, `orders_created_total{channel="web"} 1`)
+}
+
+func Test_Module_Options(t *testing.T) {
+	registry := metrics.NewRegistry()
+	registry.NewGauge("build_info", "Build information.", "version").Set(1, "1.0.0")
+
+	appModule := func() core.Module {
+		return core.NewModule(core.NewModuleOptions{
+			Imports: []core.Modules{metrics.Module(metrics.Options{
+				Path:     "prometheus",
+				Registry: registry,
+			})},
+		})
+	}
+
+	app := core.CreateFactory(appModule)
+	testServer := httptest.NewServer(app.PrepareBeforeListen())
+	defer testServer.Close()
+
+	body := scrape(t, testServer.URL+"/prometheus")
+	require.Contains(t, body, `build_info{version="1.0.0"} 1`)
+	require.Same(t, registry, app.Module.Ref(metrics.REGISTRY))
+}
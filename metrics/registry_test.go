@@ -0,0 +1,59 @@
+package metrics_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tinh-tinh/tinhtinh/v2/metrics"
+)
+
+func Test_Registry(t *testing.T) {
+	registry := metrics.NewRegistry()
+
+	counter := registry.NewCounter("jobs_total", "Jobs processed.", "queue")
+	counter.Inc("email")
+	counter.Add(2, "email")
+	counter.Inc("sms\"x\"")
+	registry.NewCounter("jobs_total", "Jobs processed.", "queue").Inc("email")
+
+	gauge := registry.NewGauge("connections", "Open\nconnections.")
+	gauge.Set(3)
+	gauge.Dec()
+
+	histogram := registry.NewHistogram("latency_seconds", "", []float64{1, 0.1}, "op")
+	histogram.Observe(0.05, "read")
+	histogram.Observe(0.5, "read")
+	histogram.Observe(2, "read")
+
+	var b strings.Builder
+	n, err := registry.WriteTo(&b)
+	require.Nil(t, err)
+	require.Equal(t, int64(b.Len()), n)
+	require.Equal(t, `# HELP jobs_total Jobs processed.
+# TYPE jobs_total counter
+jobs_total{queue="email"} 4
+jobs_total{queue="sms\"x\""} 1
+# HELP connections Open\nconnections.
+# TYPE connections gauge
+connections 2
+# TYPE latency_seconds histogram
+latency_seconds_bucket{op="read",le="0.1"} 1
+latency_seconds_bucket{op="read",le="1"} 2
+latency_seconds_bucket{op="read",le="+Inf"} 3
+latency_seconds_sum{op="read"} 2.55
+latency_seconds_count{op="read"} 3
+`, b.String())
+}
+
+func Test_Registry_Panic(t *testing.T) {
+	registry := metrics.NewRegistry()
+	counter := registry.NewCounter("jobs_total", "", "queue")
+
+	require.Panics(t, func() { registry.NewCounter("jobs-total", "") })
+	require.Panics(t, func() { registry.NewCounter("ok_total", "", "a:b") })
+	require.Panics(t, func() { registry.NewGauge("jobs_total", "", "queue") })
+	require.Panics(t, func() { registry.NewCounter("jobs_total", "", "topic") })
+	require.Panics(t, func() { counter.Inc() })
+	require.Panics(t, func() { counter.Add(-1, "email") })
+}
@@ -31,6 +31,14 @@ func (c *Connect) Config() microservices.Config {
 	return c.config
 }
 
+// Ping reports whether the connection to the broker is open.
+func (c *Connect) Ping(ctx context.Context) error {
+	if c.Conn == nil || c.Conn.IsClosed() {
+		return amqp091.ErrClosed
+	}
+	return nil
+}
+
 func (c *Connect) Emit(event string, message microservices.Message) error {
 	return nil
 }
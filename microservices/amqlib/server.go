@@ -195,7 +195,9 @@ func (c *Connect) HandlerRPC(ch *amqp091.Channel, q string, subs microservices.R
 			continue
 		}
 
+		done := sub.Observe(ctx)
 		reply, err := sub.Factory(ctx)
+		done(err)
 		if err != nil {
 			ctx.ErrorHandler(err)
 			continue
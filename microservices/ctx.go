@@ -6,6 +6,8 @@ import (
 	"encoding/gob"
 	"io"
 	"reflect"
+
+	"github.com/tinh-tinh/tinhtinh/v2/tracing"
 )
 
 type Ctx interface {
@@ -19,6 +21,7 @@ type Ctx interface {
 	Scan(val any) error
 	Path() string
 	Reply(data any) ([]byte, error)
+	Context() context.Context
 }
 
 type DefaultCtx struct {
@@ -27,11 +30,14 @@ type DefaultCtx struct {
 	context context.Context
 }
 
+// NewCtx creates the context of a message. The trace propagated in the
+// traceparent and tracestate headers of the message is restored in its
+// Context.
 func NewCtx(data Message, service Service) Ctx {
 	return &DefaultCtx{
 		message: data,
 		service: service,
-		context: context.Background(),
+		context: tracing.Extract(context.Background(), tracing.MapCarrier(data.Headers)),
 	}
 }
 
@@ -100,3 +106,10 @@ func (c *DefaultCtx) Path() string {
 func (c *DefaultCtx) Reply(data any) ([]byte, error) {
 	return c.service.Config().Serializer(data)
 }
+
+// Context returns the context of the message, holding the values set with Set
+// and the trace of the message. Pass it to WithContext to propagate the trace
+// to the messages sent by the handler.
+func (c *DefaultCtx) Context() context.Context {
+	return c.context
+}
@@ -43,6 +43,7 @@ func (h *Handler) OnEvent(name string, fnc EventFactoryFunc) {
 			Name:        name,
 			Factory:     fnc,
 			Middlewares: append(h.globalMiddlewares, h.middlewares...),
+			store:       store,
 		})
 	}
 	h.middlewares = nil
@@ -65,6 +66,7 @@ func (h *Handler) OnReply(name string, fnc RpcFactoryFnc) {
 			Name:        name,
 			Factory:     fnc,
 			Middlewares: append(h.globalMiddlewares, h.middlewares...),
+			store:       store,
 		})
 	}
 	h.middlewares = nil
@@ -0,0 +1,27 @@
+package microservices
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tinh-tinh/tinhtinh/v2/health"
+)
+
+// Pinger is implemented by the clients able to check that their broker or
+// server is reachable.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// HealthIndicator creates a health indicator checking that the client can
+// reach its transport. The client must implement Pinger, as the clients of
+// the TCP, NATS, Redis, Kafka and RabbitMQ transports do.
+func HealthIndicator(name string, client ClientProxy) health.Indicator {
+	return health.Check(name, func(ctx context.Context) error {
+		pinger, ok := client.(Pinger)
+		if !ok {
+			return fmt.Errorf("client %T does not implement microservices.Pinger", client)
+		}
+		return pinger.Ping(ctx)
+	})
+}
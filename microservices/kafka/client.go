@@ -1,6 +1,7 @@
 package kafka
 
 import (
+	"context"
 	"log"
 	"time"
 
@@ -63,6 +64,21 @@ func (c *Connect) Timeout(duration time.Duration) microservices.ClientProxy {
 	return c
 }
 
+// Ping connects to the brokers and closes the connection. The dial timeout of
+// sarama applies, the context is only checked before connecting.
+func (c *Connect) Ping(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	config := sarama.NewConfig()
+	config.Version = c.Conn.Version
+	client, err := sarama.NewClient(c.Conn.Brokers, config)
+	if err != nil {
+		return err
+	}
+	return client.Close()
+}
+
 func (c *Connect) Send(path string, request, response any, headers ...microservices.Header) error {
 	log.Println("Kafka not support rpc")
 	return nil
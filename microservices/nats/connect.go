@@ -74,6 +74,11 @@ func (c *Connect) Timeout(duration time.Duration) microservices.ClientProxy {
 	return c
 }
 
+// Ping makes a round trip to the server.
+func (c *Connect) Ping(ctx context.Context) error {
+	return c.Conn.FlushWithContext(ctx)
+}
+
 // Server usage
 func New(module core.ModuleParam, opts ...Options) microservices.Service {
 	connect := &Connect{
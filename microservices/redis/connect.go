@@ -52,6 +52,11 @@ func (c *Connect) Config() microservices.Config {
 	return c.config
 }
 
+// Ping sends a PING command to the server.
+func (c *Connect) Ping(ctx context.Context) error {
+	return c.Conn.Ping(ctx).Err()
+}
+
 func (c *Connect) Send(path string, request any, response any, headers ...microservices.Header) error {
 	log.Println("Redis not support rpc")
 	return nil
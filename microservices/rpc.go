@@ -6,6 +6,15 @@ type RpcHandler struct {
 	Name        string
 	Factory     RpcFactoryFnc
 	Middlewares []Middleware
+	// store registering the handler, notifying its observers.
+	store *Store
+}
+
+// Observe notifies the observers of the store that the handler starts with
+// ctx. The transports call it before running the handler, then the returned
+// function with the error of the handler.
+func (h *RpcHandler) Observe(ctx Ctx) func(err error) {
+	return h.store.observe(ctx, "rpc", h.Name)
 }
 
 type RpcHandlers []*RpcHandler
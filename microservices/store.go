@@ -1,12 +1,93 @@
 package microservices
 
-import "github.com/tinh-tinh/tinhtinh/v2/core"
+import (
+	"context"
+	"sync"
+
+	"github.com/tinh-tinh/tinhtinh/v2/core"
+)
 
 const STORE core.Provide = "STORE"
 
 type Store struct {
 	Subscribers []*SubscribeHandler
 	RpcHandlers RpcHandlers
+
+	mu               sync.RWMutex
+	observers        []func(kind string, name string) func(err error)
+	contextObservers []func(ctx context.Context, kind string, name string) (context.Context, func(err error))
+}
+
+// AddHandlerObserver registers a function called when a handler of the store
+// starts. The function it returns is called with the error of the handler
+// once it returned, so the handlers can be measured.
+func (s *Store) AddHandlerObserver(observer func(kind string, name string) func(err error)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.observers = append(s.observers, observer)
+}
+
+// AddContextObserver registers a function called with the context of the
+// message when a handler of the store starts, such as a tracer starting a
+// span. The handler runs with the context it returns, and the function it
+// returns is called with the error of the handler once it returned.
+func (s *Store) AddContextObserver(observer func(ctx context.Context, kind string, name string) (context.Context, func(err error))) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.contextObservers = append(s.contextObservers, observer)
+}
+
+// observe notifies the observers that the handler of ctx starts and returns
+// the function to call when it returned.
+func (s *Store) observe(ctx Ctx, kind string, name string) func(err error) {
+	if s == nil {
+		return func(err error) {}
+	}
+	s.mu.RLock()
+	observers := s.observers
+	contextObservers := s.contextObservers
+	s.mu.RUnlock()
+
+	done := make([]func(err error), 0, len(observers)+len(contextObservers))
+	for _, observer := range observers {
+		done = append(done, observer(kind, name))
+	}
+	if c, ok := ctx.(*DefaultCtx); ok {
+		for _, observer := range contextObservers {
+			var d func(err error)
+			c.context, d = observer(c.context, kind, name)
+			done = append(done, d)
+		}
+	}
+	return func(err error) {
+		for i := len(done) - 1; i >= 0; i-- {
+			done[i](err)
+		}
+	}
+}
+
+// DiscoverHandlers lists the event and RPC handlers of the store for the
+// core.DiscoveryService. The Handler of each entry is the *SubscribeHandler
+// or the *RpcHandler.
+func (s *Store) DiscoverHandlers() []core.HandlerInfo {
+	handlers := make([]core.HandlerInfo, 0, len(s.Subscribers)+len(s.RpcHandlers))
+	for _, h := range s.Subscribers {
+		handlers = append(handlers, core.HandlerInfo{
+			Kind:        "event",
+			Name:        h.Name,
+			Middlewares: len(h.Middlewares),
+			Handler:     h,
+		})
+	}
+	for _, h := range s.RpcHandlers {
+		handlers = append(handlers, core.HandlerInfo{
+			Kind:        "rpc",
+			Name:        h.Name,
+			Middlewares: len(h.Middlewares),
+			Handler:     h,
+		})
+	}
+	return handlers
 }
 
 func Register(transports ...string) core.Modules {
@@ -0,0 +1,45 @@
+package microservices_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tinh-tinh/tinhtinh/microservices"
+	"github.com/tinh-tinh/tinhtinh/v2/core"
+)
+
+func Test_Store_DiscoverHandlers(t *testing.T) {
+	appService := func(module core.Module) core.Provider {
+		handler := microservices.NewHandler(module)
+
+		handler.OnEvent("user.created", func(ctx microservices.Ctx) error {
+			return nil
+		})
+		handler.OnReply("user.get", func(ctx microservices.Ctx) ([]byte, error) {
+			return nil, nil
+		})
+
+		return handler
+	}
+
+	appModule := func() core.Module {
+		return core.NewModule(core.NewModuleOptions{
+			Imports:   []core.Modules{microservices.Register()},
+			Providers: []core.Providers{appService},
+		})
+	}
+
+	app := core.CreateFactory(appModule)
+	discovery, ok := app.Module.Ref(core.DISCOVERY).(*core.DiscoveryService)
+	require.True(t, ok)
+
+	handlers := discovery.Handlers()
+	require.Len(t, handlers, 2)
+	require.Equal(t, "event", handlers[0].Kind)
+	require.Equal(t, "user.created", handlers[0].Name)
+	require.Equal(t, microservices.STORE, handlers[0].Source)
+	require.IsType(t, &microservices.SubscribeHandler{}, handlers[0].Handler)
+	require.Equal(t, "rpc", handlers[1].Kind)
+	require.Equal(t, "user.get", handlers[1].Name)
+	require.IsType(t, &microservices.RpcHandler{}, handlers[1].Handler)
+}
@@ -10,6 +10,8 @@ type SubscribeHandler struct {
 	Name        string
 	Factory     EventFactory
 	Middlewares []Middleware
+	// store registering the handler, notifying its observers.
+	store *Store
 }
 
 type EventFactoryFunc func(ctx Ctx) error
@@ -46,5 +48,9 @@ func (s *SubscribeHandler) Handle(svc Service, data Message) error {
 		mergeHandler = mid(mergeHandler)
 	}
 
-	return mergeHandler.Handle(NewCtx(data, svc))
+	ctx := NewCtx(data, svc)
+	done := s.store.observe(ctx, "event", s.Name)
+	err := mergeHandler.Handle(ctx)
+	done(err)
+	return err
 }
@@ -1,6 +1,7 @@
 package tcp
 
 import (
+	"context"
 	"errors"
 	"net"
 	"net/rpc"
@@ -16,6 +17,7 @@ type Options struct {
 }
 
 type Client struct {
+	addr      string
 	config    microservices.Config
 	eventConn net.Conn
 	rpcClient *rpc.Client
@@ -52,6 +54,7 @@ func NewClient(opt Options) microservices.ClientProxy {
 	}
 
 	client := &Client{
+		addr:      opt.Addr,
 		eventConn: eventConn,
 		rpcClient: rpcClient,
 		config:    microservices.NewConfig(opt.Config),
@@ -129,3 +132,13 @@ func (client *Client) Send(path string, data any, response any, headers ...micro
 		return err
 	}
 }
+
+// Ping opens then closes a connection to the server.
+func (client *Client) Ping(ctx context.Context) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", client.addr)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
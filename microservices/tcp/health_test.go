@@ -0,0 +1,36 @@
+package tcp_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tinh-tinh/tinhtinh/microservices"
+	"github.com/tinh-tinh/tinhtinh/microservices/tcp"
+)
+
+func Test_HealthIndicator(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	client := tcp.NewClient(tcp.Options{Addr: listener.Addr().String()})
+	indicator := microservices.HealthIndicator("orders", client)
+	require.Equal(t, "orders", indicator.Name())
+
+	_, err = indicator.Check(context.Background())
+	require.Nil(t, err)
+
+	listener.Close()
+	_, err = indicator.Check(context.Background())
+	require.NotNil(t, err)
+}
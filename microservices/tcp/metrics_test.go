@@ -0,0 +1,66 @@
+package tcp_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tinh-tinh/tinhtinh/microservices"
+	"github.com/tinh-tinh/tinhtinh/microservices/tcp"
+	"github.com/tinh-tinh/tinhtinh/v2/core"
+	"github.com/tinh-tinh/tinhtinh/v2/metrics"
+)
+
+func Test_Metrics(t *testing.T) {
+	handlers := func(module core.Module) core.Provider {
+		handler := microservices.NewHandler(module, microservices.TCP)
+
+		handler.OnReply("add", func(ctx microservices.Ctx) (reply []byte, err error) {
+			args := Args{}
+			if err := ctx.PayloadParser(&args); err != nil {
+				return nil, err
+			}
+			return ctx.Reply(args.A + args.B)
+		})
+
+		handler.OnReply("fail", func(ctx microservices.Ctx) (reply []byte, err error) {
+			return nil, errors.New("failed")
+		})
+
+		return handler
+	}
+
+	appModule := func() core.Module {
+		return core.NewModule(core.NewModuleOptions{
+			Imports: []core.Modules{
+				metrics.Module(metrics.Options{}),
+				microservices.Register(microservices.TCP),
+			},
+			Providers: []core.Providers{handlers},
+		})
+	}
+
+	app := core.CreateFactory(appModule)
+	app.ConnectMicroservice(tcp.NewServer(tcp.Options{
+		Addr: "localhost:5175",
+	}))
+	app.StartAllMicroservices()
+	app.PrepareBeforeListen()
+
+	time.Sleep(100 * time.Millisecond)
+
+	client := tcp.NewClient(tcp.Options{Addr: "localhost:5175"})
+	var reply int
+	require.Nil(t, client.Send("add", Args{A: 1, B: 2}, &reply))
+	require.Equal(t, 3, reply)
+	require.NotNil(t, client.Send("fail", Args{}, &reply))
+
+	var b strings.Builder
+	registry := app.Module.Ref(metrics.REGISTRY).(*metrics.Registry)
+	_, err := registry.WriteTo(&b)
+	require.Nil(t, err)
+	require.Contains(t, b.String(), `microservice_handled_total{kind="rpc",name="add",status="success"} 1`)
+	require.Contains(t, b.String(), `microservice_handled_total{kind="rpc",name="fail",status="failure"} 1`)
+}
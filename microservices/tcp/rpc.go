@@ -45,7 +45,9 @@ func (g *RpcGateway) Call(request *[]byte, reply *[]byte) error {
 	}
 
 	ctx := microservices.NewCtx(msg, g.service)
+	done := handler.Observe(ctx)
 	res, err := safeHandlerFnc(ctx)
+	done(err)
 	if err != nil {
 		return err
 	}
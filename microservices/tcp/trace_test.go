@@ -0,0 +1,182 @@
+package tcp_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tinh-tinh/tinhtinh/microservices"
+	"github.com/tinh-tinh/tinhtinh/microservices/tcp"
+	"github.com/tinh-tinh/tinhtinh/v2/core"
+	"github.com/tinh-tinh/tinhtinh/v2/tracing"
+)
+
+// spanRecorder keeps the exported spans by name.
+type spanRecorder struct {
+	mu    sync.Mutex
+	spans map[string]tracing.SpanData
+}
+
+func (r *spanRecorder) ExportSpans(ctx context.Context, spans []tracing.SpanData) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.spans == nil {
+		r.spans = make(map[string]tracing.SpanData)
+	}
+	for _, span := range spans {
+		r.spans[span.Name] = span
+	}
+	return nil
+}
+
+func (r *spanRecorder) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+func (r *spanRecorder) get(name string) tracing.SpanData {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.spans[name]
+}
+
+func Test_Tracing(t *testing.T) {
+	serverSpans := &spanRecorder{}
+	received := make(chan tracing.SpanContext, 1)
+
+	handlers := func(module core.Module) core.Provider {
+		handler := microservices.NewHandler(module, microservices.TCP)
+		handler.OnReply("add", func(ctx microservices.Ctx) (reply []byte, err error) {
+			received <- tracing.SpanContextFromContext(ctx.Context())
+			args := Args{}
+			if err := ctx.PayloadParser(&args); err != nil {
+				return nil, err
+			}
+			return ctx.Reply(args.A + args.B)
+		})
+		return handler
+	}
+
+	serverModule := func() core.Module {
+		return core.NewModule(core.NewModuleOptions{
+			Imports: []core.Modules{
+				tracing.Module(tracing.Options{ServiceName: "calculator", Exporter: serverSpans}),
+				microservices.Register(microservices.TCP),
+			},
+			Providers: []core.Providers{handlers},
+		})
+	}
+
+	serverApp := core.CreateFactory(serverModule)
+	serverApp.ConnectMicroservice(tcp.NewServer(tcp.Options{
+		Addr: "localhost:5185",
+	}))
+	serverApp.StartAllMicroservices()
+	serverApp.PrepareBeforeListen()
+
+	time.Sleep(100 * time.Millisecond)
+
+	clientSpans := &spanRecorder{}
+	controller := func(module core.Module) core.Controller {
+		ctrl := module.NewController("call")
+		client := microservices.InjectClient(module, microservices.TCP)
+
+		ctrl.Post("", func(ctx core.Ctx) error {
+			var reply int
+			err := microservices.WithContext(ctx.Req().Context(), client).Send("add", Args{A: 1, B: 2}, &reply)
+			if err != nil {
+				return err
+			}
+			return ctx.JSON(reply)
+		})
+		return ctrl
+	}
+
+	clientModule := func() core.Module {
+		return core.NewModule(core.NewModuleOptions{
+			Imports: []core.Modules{
+				tracing.Module(tracing.Options{ServiceName: "gateway", Exporter: clientSpans}),
+				microservices.RegisterClient(microservices.ClientOptions{
+					Name:      microservices.TCP,
+					Transport: tcp.NewClient(tcp.Options{Addr: "localhost:5185"}),
+				}),
+			},
+			Controllers: []core.Controllers{controller},
+		})
+	}
+
+	clientApp := core.CreateFactory(clientModule)
+	testServer := httptest.NewServer(clientApp.PrepareBeforeListen())
+	defer testServer.Close()
+
+	req, err := http.NewRequest(http.MethodPost, testServer.URL+"/call", nil)
+	require.Nil(t, err)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	resp, err := http.DefaultClient.Do(req)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	clientTracer := clientApp.Module.Ref(tracing.TRACER).(*tracing.Tracer)
+	require.Nil(t, clientTracer.ForceFlush(context.Background()))
+	serverTracer := serverApp.Module.Ref(tracing.TRACER).(*tracing.Tracer)
+	require.Nil(t, serverTracer.ForceFlush(context.Background()))
+
+	handlerSpan := clientSpans.get("handler POST /call")
+	send := clientSpans.get("send add")
+	require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", send.SpanContext.TraceID.String())
+	require.Equal(t, handlerSpan.SpanContext.SpanID, send.ParentSpanID)
+	require.Equal(t, tracing.KindClient, send.Kind)
+
+	rpc := serverSpans.get("rpc add")
+	require.Equal(t, "calculator", rpc.Service)
+	require.Equal(t, tracing.KindServer, rpc.Kind)
+	require.Equal(t, send.SpanContext.TraceID, rpc.SpanContext.TraceID)
+	require.Equal(t, send.SpanContext.SpanID, rpc.ParentSpanID)
+	require.Equal(t, rpc.SpanContext, <-received)
+}
+
+func Test_Tracing_WithoutTracer(t *testing.T) {
+	received := make(chan tracing.SpanContext, 1)
+	handlers := func(module core.Module) core.Provider {
+		handler := microservices.NewHandler(module, microservices.TCP)
+		handler.OnEvent("created", func(ctx microservices.Ctx) error {
+			received <- tracing.SpanContextFromContext(ctx.Context())
+			return nil
+		})
+		return handler
+	}
+
+	appModule := func() core.Module {
+		return core.NewModule(core.NewModuleOptions{
+			Imports:   []core.Modules{microservices.Register(microservices.TCP)},
+			Providers: []core.Providers{handlers},
+		})
+	}
+
+	app := core.CreateFactory(appModule)
+	app.ConnectMicroservice(tcp.NewServer(tcp.Options{
+		Addr: "localhost:5186",
+	}))
+	app.StartAllMicroservices()
+
+	time.Sleep(100 * time.Millisecond)
+
+	remote, err := tracing.ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	require.Nil(t, err)
+	ctx := tracing.ContextWithRemoteSpanContext(context.Background(), remote)
+
+	client := tcp.NewClient(tcp.Options{Addr: "localhost:5186"})
+	require.Nil(t, microservices.WithContext(ctx, client).Publish("created", "order"))
+
+	select {
+	case sc := <-received:
+		require.Equal(t, remote.TraceID, sc.TraceID)
+		require.Equal(t, remote.SpanID, sc.SpanID)
+		require.True(t, sc.Remote)
+	case <-time.After(time.Second):
+		t.Fatal("event not received")
+	}
+}
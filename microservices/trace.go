@@ -0,0 +1,59 @@
+package microservices
+
+import (
+	"context"
+	"time"
+
+	"github.com/tinh-tinh/tinhtinh/v2/tracing"
+)
+
+// tracedClient propagates the trace of a context in the headers of the
+// messages of a client.
+type tracedClient struct {
+	ClientProxy
+	ctx context.Context
+}
+
+// WithContext returns a client propagating the trace of ctx, such as the
+// context of an HTTP request or of a message, in the traceparent and
+// tracestate headers of the messages it publishes and sends. When ctx is
+// traced, publishing and sending are spans of the trace.
+//
+//	client := microservices.WithContext(ctx.Req().Context(), client)
+//	err := client.Send("orders.create", order, &reply)
+func WithContext(ctx context.Context, client ClientProxy) ClientProxy {
+	return &tracedClient{ClientProxy: client, ctx: ctx}
+}
+
+func (c *tracedClient) Timeout(duration time.Duration) ClientProxy {
+	return &tracedClient{ClientProxy: c.ClientProxy.Timeout(duration), ctx: c.ctx}
+}
+
+func (c *tracedClient) Publish(event string, data any, headers ...Header) error {
+	ctx, span := tracing.Start(c.ctx, "publish "+event, tracing.KindProducer)
+	defer span.End()
+	span.SetAttribute("messaging.operation", "publish")
+	span.SetAttribute("messaging.destination.name", event)
+
+	err := c.ClientProxy.Publish(event, data, append(headers, traceHeader(ctx))...)
+	span.RecordError(err)
+	return err
+}
+
+func (c *tracedClient) Send(path string, request any, response any, headers ...Header) error {
+	ctx, span := tracing.Start(c.ctx, "send "+path, tracing.KindClient)
+	defer span.End()
+	span.SetAttribute("messaging.operation", "send")
+	span.SetAttribute("messaging.destination.name", path)
+
+	err := c.ClientProxy.Send(path, request, response, append(headers, traceHeader(ctx))...)
+	span.RecordError(err)
+	return err
+}
+
+// traceHeader returns the trace headers of the span of ctx.
+func traceHeader(ctx context.Context) Header {
+	header := make(Header)
+	tracing.Inject(ctx, tracing.MapCarrier(header))
+	return header
+}
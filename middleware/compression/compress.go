@@ -1,15 +1,395 @@
+// Package compression compresses the responses with the content coding
+// negotiated from the Accept-Encoding header of the requests.
 package compression
 
 import (
+	"bufio"
+	"compress/flate"
+	"errors"
+	"io"
+	"mime"
+	"net"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/tinh-tinh/tinhtinh/v2/common/compress"
 )
 
-func Handler() func(http.Handler) http.Handler {
+// DefaultMinLength is the size, in bytes, from which the bodies are
+// compressed when no MinLength is given.
+const DefaultMinLength = 1024
+
+// DefaultSkipContentTypes are the content types which are not compressed when
+// no SkipContentTypes are given, as they are already compressed. A type
+// ending with "/*" matches all its subtypes.
+var DefaultSkipContentTypes = []string{
+	"image/*",
+	"video/*",
+	"audio/*",
+	"font/woff",
+	"font/woff2",
+	"application/zip",
+	"application/gzip",
+	"application/x-gzip",
+	"application/zstd",
+	"application/x-bzip2",
+	"application/x-xz",
+	"application/x-7z-compressed",
+	"application/x-rar-compressed",
+	"application/pdf",
+	"text/event-stream",
+}
+
+// Encoder is a content coding, such as br or zstd, which compresses the
+// responses in addition to gzip and deflate.
+//
+//	compression.Encoder{Name: "br", New: func(w io.Writer) (compress.Writer, error) {
+//		return brotli.NewWriterLevel(w, brotli.DefaultCompression), nil
+//	}}
+type Encoder struct {
+	// Name is the content coding in the Accept-Encoding and Content-Encoding
+	// headers.
+	Name string
+	// New creates a writer compressing to w. The writers are pooled and
+	// reset for each response.
+	New func(w io.Writer) (compress.Writer, error)
+}
+
+type Options struct {
+	// Level is the compression level of gzip and deflate, from
+	// flate.HuffmanOnly to flate.BestCompression. flate.NoCompression keeps
+	// the content coding without compressing. Default, when nil, is
+	// flate.DefaultCompression.
+	Level *int
+	// MinLength is the size, in bytes, from which the bodies are compressed.
+	// Default is DefaultMinLength.
+	MinLength int
+	// SkipContentTypes are the content types which are not compressed.
+	// Default is DefaultSkipContentTypes.
+	SkipContentTypes []string
+	// Encoders are the content codings preferred over gzip and deflate, in
+	// order of preference.
+	Encoders []Encoder
+}
+
+// Handler is a middleware compressing the responses on the fly with the
+// content coding preferred by the Accept-Encoding header of the request,
+// among the encoders of the options, gzip and deflate.
+//
+// The response is not compressed when its body is smaller than the
+// MinLength, its content type is skipped, it already has a Content-Encoding,
+// it answers a range request or it has no body. Server-Sent Events are not
+// compressed, so they are not delayed. The compressed responses have no
+// Content-Length and their entity tags become weak.
+func Handler(opts ...Options) func(http.Handler) http.Handler {
+	var opt Options
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.MinLength <= 0 {
+		opt.MinLength = DefaultMinLength
+	}
+	if opt.SkipContentTypes == nil {
+		opt.SkipContentTypes = DefaultSkipContentTypes
+	}
+	level := flate.DefaultCompression
+	if opt.Level != nil && *opt.Level >= flate.HuffmanOnly && *opt.Level <= flate.BestCompression {
+		level = *opt.Level
+	}
+
+	encoders := append([]Encoder{}, opt.Encoders...)
+	encoders = append(encoders,
+		Encoder{Name: "gzip", New: func(w io.Writer) (compress.Writer, error) {
+			return compress.NewWriter(w, compress.Gzip, level)
+		}},
+		// The deflate content coding is the zlib format.
+		Encoder{Name: "deflate", New: func(w io.Writer) (compress.Writer, error) {
+			return compress.NewWriter(w, compress.Zlib, level)
+		}},
+	)
+
+	names := make([]string, 0, len(encoders))
+	pools := make(map[string]*sync.Pool, len(encoders))
+	for _, encoder := range encoders {
+		name := strings.ToLower(encoder.Name)
+		if _, ok := pools[name]; ok {
+			continue
+		}
+		names = append(names, name)
+		pools[name] = &sync.Pool{
+			New: func() any {
+				w, err := encoder.New(io.Discard)
+				if err != nil {
+					return nil
+				}
+				return w
+			},
+		}
+	}
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			w.Header().Set("Content-Encoding", "gzip")
-			w.Header().Set("Vary", "Accept-Encoding")
-			next.ServeHTTP(w, r)
+			if r.Header.Get("Range") != "" || strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			encoding := negotiate(r.Header.Values("Accept-Encoding"), names)
+			cw := &compressWriter{
+				ResponseWriter: w,
+				opt:            &opt,
+				encoding:       encoding,
+				pool:           pools[encoding],
+			}
+			defer cw.close()
+			next.ServeHTTP(cw, r)
 		})
 	}
 }
+
+// negotiate returns the content coding of names preferred by the
+// Accept-Encoding header, empty when the response is not compressed. The
+// codings of the same quality are chosen in the order of names.
+func negotiate(header []string, names []string) string {
+	qualities := map[string]float64{}
+	for _, value := range header {
+		for _, part := range strings.Split(value, ",") {
+			name, params, _ := strings.Cut(part, ";")
+			name = strings.ToLower(strings.TrimSpace(name))
+			if name == "" {
+				continue
+			}
+			q := 1.0
+			params = strings.TrimSpace(params)
+			if value, ok := strings.CutPrefix(params, "q="); ok {
+				parsed, err := strconv.ParseFloat(value, 64)
+				if err != nil {
+					continue
+				}
+				q = parsed
+			}
+			qualities[name] = q
+		}
+	}
+
+	best, bestQ := "", 0.0
+	for _, name := range names {
+		q, ok := qualities[name]
+		if !ok {
+			q, ok = qualities["*"]
+		}
+		if ok && q > bestQ {
+			best, bestQ = name, q
+		}
+	}
+	return best
+}
+
+// compressWriter compresses the body written through it once it reaches the
+// MinLength of the options. The smaller bodies are buffered and written as is
+// when the handler returns.
+type compressWriter struct {
+	http.ResponseWriter
+	opt      *Options
+	encoding string
+	pool     *sync.Pool
+
+	status int
+	// decided reports whether the headers are written, then the body is
+	// compressed by writer when it is not nil.
+	decided bool
+	writer  compress.Writer
+	buf     []byte
+}
+
+func (w *compressWriter) WriteHeader(status int) {
+	if status < http.StatusOK && status != http.StatusSwitchingProtocols {
+		// The informational responses, such as 103 Early Hints, are
+		// followed by the final response.
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	if w.status != 0 {
+		return
+	}
+	w.status = status
+
+	if !w.compressible() {
+		w.decide(false)
+		return
+	}
+	header := w.Header()
+	if !varies(header, "Accept-Encoding") {
+		header.Add("Vary", "Accept-Encoding")
+	}
+	if w.encoding == "" || w.pool == nil {
+		w.decide(false)
+		return
+	}
+	if length, err := strconv.Atoi(header.Get("Content-Length")); err == nil && length < w.opt.MinLength {
+		w.decide(false)
+	}
+}
+
+// compressible reports whether the response may be compressed, from its
+// status and headers.
+func (w *compressWriter) compressible() bool {
+	switch {
+	case w.status < http.StatusOK,
+		w.status == http.StatusNoContent,
+		w.status == http.StatusNotModified,
+		w.status == http.StatusPartialContent:
+		return false
+	}
+	header := w.Header()
+	if header.Get("Content-Encoding") != "" || header.Get("Content-Range") != "" {
+		return false
+	}
+	contentType := header.Get("Content-Type")
+	return contentType == "" || !w.skipped(contentType)
+}
+
+// skipped reports whether the content type is one of the skipped types.
+func (w *compressWriter) skipped(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, skip := range w.opt.SkipContentTypes {
+		if prefix, ok := strings.CutSuffix(skip, "*"); ok {
+			if strings.HasPrefix(mediaType, prefix) {
+				return true
+			}
+		} else if mediaType == skip {
+			return true
+		}
+	}
+	return false
+}
+
+// decide writes the headers of the response, which is compressed when
+// compressed is true and a writer is available.
+func (w *compressWriter) decide(compressed bool) {
+	if w.decided {
+		return
+	}
+	w.decided = true
+	header := w.Header()
+
+	if compressed && header.Get("Content-Type") == "" {
+		if len(w.buf) == 0 {
+			// The content type is sniffed from the first bytes of the body,
+			// which must not be compressed.
+			compressed = false
+		} else {
+			header.Set("Content-Type", http.DetectContentType(w.buf))
+		}
+	}
+	if compressed && w.skipped(header.Get("Content-Type")) {
+		compressed = false
+	}
+	if compressed {
+		w.writer, _ = w.pool.Get().(compress.Writer)
+	}
+	if w.writer != nil {
+		w.writer.Reset(w.ResponseWriter)
+		header.Set("Content-Encoding", w.encoding)
+		header.Del("Content-Length")
+		header.Del("Accept-Ranges")
+		// The compressed body is not byte-identical to the one of the entity
+		// tag.
+		if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			header.Set("ETag", "W/"+etag)
+		}
+	}
+
+	w.ResponseWriter.WriteHeader(w.status)
+	if len(w.buf) > 0 {
+		w.write(w.buf)
+		w.buf = nil
+	}
+}
+
+func (w *compressWriter) write(b []byte) (int, error) {
+	if w.writer != nil {
+		return w.writer.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.decided {
+		return w.write(b)
+	}
+	w.buf = append(w.buf, b...)
+	if len(w.buf) >= w.opt.MinLength {
+		w.decide(true)
+	}
+	return len(b), nil
+}
+
+// Flush writes the buffered body, compressed, and flushes it to the client.
+func (w *compressWriter) Flush() {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if !w.decided {
+		w.decide(w.compressible())
+	}
+	if w.writer != nil {
+		w.writer.Flush()
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hijacker, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return hijacker.Hijack()
+	}
+	return nil, nil, errors.New("compression: the response writer does not support hijacking")
+}
+
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// close writes the buffered body, as is, or ends the compressed body and
+// returns its writer to the pool.
+func (w *compressWriter) close() {
+	if w.status == 0 {
+		if len(w.buf) == 0 {
+			// The handler wrote nothing, or hijacked the connection.
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+	if !w.decided {
+		w.decide(false)
+	}
+	if w.writer != nil {
+		w.writer.Close()
+		w.writer.Reset(io.Discard)
+		w.pool.Put(w.writer)
+		w.writer = nil
+	}
+}
+
+// varies reports whether the Vary header of the response lists the header.
+func varies(header http.Header, name string) bool {
+	for _, value := range header.Values("Vary") {
+		for _, field := range strings.Split(value, ",") {
+			field = strings.TrimSpace(field)
+			if field == "*" || strings.EqualFold(field, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -1,25 +1,66 @@
 package compression_test
 
 import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
+	"github.com/tinh-tinh/tinhtinh/v2/common/compress"
 	"github.com/tinh-tinh/tinhtinh/v2/core"
 	"github.com/tinh-tinh/tinhtinh/v2/middleware/compression"
 )
 
-func Test_Compress(t *testing.T) {
+var large = strings.Repeat("tinh tinh ", 500)
+
+func newServer(t *testing.T, opts ...compression.Options) *httptest.Server {
 	appController := func(module core.Module) core.Controller {
 		ctrl := module.NewController("test")
 
 		ctrl.Get("", func(ctx core.Ctx) error {
+			return ctx.JSON(core.Map{
+				"data": large,
+			})
+		})
+
+		ctrl.Get("small", func(ctx core.Ctx) error {
 			return ctx.JSON(core.Map{
 				"data": "ok",
 			})
 		})
 
+		ctrl.Get("image", func(ctx core.Ctx) error {
+			ctx.Res().Header().Set("Content-Type", "image/png")
+			return ctx.SendString(large)
+		})
+
+		ctrl.Get("file", func(ctx core.Ctx) error {
+			return ctx.StreamableContent("file.txt", strings.NewReader(large))
+		})
+
+		ctrl.Get("stream", func(ctx core.Ctx) error {
+			w := ctx.Res().ResponseWriter
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write([]byte(large))
+			w.(http.Flusher).Flush()
+			w.Write([]byte(large))
+			return nil
+		})
+
+		ctrl.Get("events", func(ctx core.Ctx) error {
+			w := ctx.Res().ResponseWriter
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.Write([]byte("data: " + large + "\n\n"))
+			w.(http.Flusher).Flush()
+			return nil
+		})
+
 		return ctrl
 	}
 
@@ -29,22 +70,133 @@ func Test_Compress(t *testing.T) {
 		})
 	}
 
-	app := core.CreateFactory(appModule)
+	app := core.CreateFactory(appModule, core.AppOptions{ETag: core.ETagStrong})
 	app.SetGlobalPrefix("/api")
 
-	app.Use(compression.Handler())
+	app.Use(compression.Handler(opts...))
 
 	testServer := httptest.NewServer(app.PrepareBeforeListen())
-	defer testServer.Close()
-
-	testClient := testServer.Client()
+	t.Cleanup(testServer.Close)
+	return testServer
+}
 
-	req, err := http.NewRequest("GET", testServer.URL+"/api/test", nil)
+func get(t *testing.T, testServer *httptest.Server, path string, header ...string) (*http.Response, []byte) {
+	req, err := http.NewRequest("GET", testServer.URL+path, nil)
 	require.Nil(t, err)
-	req.Header.Set("Accept-Encoding", "gzip")
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
 
-	resp, err := testClient.Do(req)
+	resp, err := testServer.Client().Do(req)
+	require.Nil(t, err)
+	data, err := io.ReadAll(resp.Body)
 	require.Nil(t, err)
+	return resp, data
+}
+
+func Test_Compress(t *testing.T) {
+	testServer := newServer(t)
+
+	resp, data := get(t, testServer, "/api/test", "Accept-Encoding", "gzip")
 	require.Equal(t, http.StatusOK, resp.StatusCode)
 	require.Equal(t, "gzip", resp.Header.Get("Content-Encoding"))
+	require.Equal(t, "Accept-Encoding", resp.Header.Get("Vary"))
+	require.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	require.True(t, strings.HasPrefix(resp.Header.Get("ETag"), `W/"`))
+
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	require.Nil(t, err)
+	body, err := io.ReadAll(reader)
+	require.Nil(t, err)
+	require.Equal(t, `{"data":"`+large+`"}`, string(body))
+
+	// The weak entity tag still matches the conditional requests.
+	resp, _ = get(t, testServer, "/api/test", "Accept-Encoding", "gzip", "If-None-Match", resp.Header.Get("ETag"))
+	require.Equal(t, http.StatusNotModified, resp.StatusCode)
+
+	resp, data = get(t, testServer, "/api/test", "Accept-Encoding", "gzip;q=0.5, deflate")
+	require.Equal(t, "deflate", resp.Header.Get("Content-Encoding"))
+	zreader, err := zlib.NewReader(bytes.NewReader(data))
+	require.Nil(t, err)
+	body, err = io.ReadAll(zreader)
+	require.Nil(t, err)
+	require.Equal(t, `{"data":"`+large+`"}`, string(body))
+
+	resp, _ = get(t, testServer, "/api/test", "Accept-Encoding", "*")
+	require.Equal(t, "gzip", resp.Header.Get("Content-Encoding"))
+
+	resp, data = get(t, testServer, "/api/test", "Accept-Encoding", "identity, gzip;q=0")
+	require.Empty(t, resp.Header.Get("Content-Encoding"))
+	require.Equal(t, `{"data":"`+large+`"}`, string(data))
+
+	resp, data = get(t, testServer, "/api/test/stream", "Accept-Encoding", "gzip")
+	require.Equal(t, "gzip", resp.Header.Get("Content-Encoding"))
+	body, err = compress.Decode(data, compress.Gzip)
+	require.Nil(t, err)
+	require.Equal(t, large+large, string(body))
+
+	_, data = get(t, testServer, "/api/test/file", "Accept-Encoding", "gzip")
+	require.Less(t, len(data), len(large))
+
+	// Level 0 stores the body without compressing it.
+	level := flate.NoCompression
+	testServer = newServer(t, compression.Options{Level: &level})
+	resp, data = get(t, testServer, "/api/test/file", "Accept-Encoding", "gzip")
+	require.Equal(t, "gzip", resp.Header.Get("Content-Encoding"))
+	require.Greater(t, len(data), len(large))
+	body, err = compress.Decode(data, compress.Gzip)
+	require.Nil(t, err)
+	require.Equal(t, large, string(body))
+}
+
+func Test_Skip(t *testing.T) {
+	testServer := newServer(t)
+
+	resp, data := get(t, testServer, "/api/test/small", "Accept-Encoding", "gzip")
+	require.Empty(t, resp.Header.Get("Content-Encoding"))
+	require.Equal(t, "Accept-Encoding", resp.Header.Get("Vary"))
+	require.Equal(t, `{"data":"ok"}`, string(data))
+
+	resp, data = get(t, testServer, "/api/test/image", "Accept-Encoding", "gzip")
+	require.Empty(t, resp.Header.Get("Content-Encoding"))
+	require.Equal(t, large, string(data))
+
+	resp, data = get(t, testServer, "/api/test/file", "Accept-Encoding", "gzip", "Range", "bytes=0-3")
+	require.Equal(t, http.StatusPartialContent, resp.StatusCode)
+	require.Empty(t, resp.Header.Get("Content-Encoding"))
+	require.Equal(t, "tinh", string(data))
+
+	resp, data = get(t, testServer, "/api/test/events", "Accept-Encoding", "gzip")
+	require.Empty(t, resp.Header.Get("Content-Encoding"))
+	require.Equal(t, "data: "+large+"\n\n", string(data))
+
+	resp, data = get(t, testServer, "/api/test")
+	require.Empty(t, resp.Header.Get("Content-Encoding"))
+	require.Equal(t, `{"data":"`+large+`"}`, string(data))
+}
+
+func Test_Options(t *testing.T) {
+	testServer := newServer(t, compression.Options{
+		MinLength:        16,
+		SkipContentTypes: []string{"application/json"},
+		Encoders: []compression.Encoder{{
+			Name: "x-test",
+			New: func(w io.Writer) (compress.Writer, error) {
+				return compress.NewWriter(w, compress.Gzip, gzip.BestSpeed)
+			},
+		}},
+	})
+
+	resp, data := get(t, testServer, "/api/test/file", "Accept-Encoding", "gzip, x-test")
+	require.Equal(t, "x-test", resp.Header.Get("Content-Encoding"))
+	require.Empty(t, resp.Header.Get("Accept-Ranges"))
+	body, err := compress.Decode(data, compress.Gzip)
+	require.Nil(t, err)
+	require.Equal(t, large, string(body))
+
+	resp, _ = get(t, testServer, "/api/test/file", "Accept-Encoding", "gzip, x-test;q=0.5")
+	require.Equal(t, "gzip", resp.Header.Get("Content-Encoding"))
+
+	resp, _ = get(t, testServer, "/api/test/small", "Accept-Encoding", "gzip")
+	require.Empty(t, resp.Header.Get("Content-Encoding"))
 }
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	clogger "github.com/tinh-tinh/tinhtinh/v2/common/logger"
+	"github.com/tinh-tinh/tinhtinh/v2/middleware/requestid"
 )
 
 const (
@@ -28,6 +29,8 @@ type LogContext struct {
 	ResponseHeaders http.Header
 	// StartTime is when the request started
 	StartTime time.Time
+	// RequestID is the ID of the request set by the requestid middleware
+	RequestID string
 }
 
 // CustomFormatter is a function type for custom log formatting.
@@ -50,6 +53,10 @@ type MiddlewareOptions struct {
 	// When set, this takes precedence over Format.
 	CustomFormatter CustomFormatter
 	SkipPaths       []string
+	// RequestIDHeader is the header of the request ID, read when the
+	// requestid middleware runs outside of this one. Default is
+	// "X-Request-Id".
+	RequestIDHeader string
 }
 
 type wrappedWriter struct {
@@ -89,6 +96,7 @@ func (w *wrappedWriter) WriteHeader(statusCode int) {
 // - ${content-length}: the Content-Length header of the response
 // - ${latency}: the latency of the request in milliseconds
 // - ${date}: the current date and time in the format 2006-01-02 15:04:05
+// - ${request-id}: the ID of the request set by the requestid middleware
 //
 // Alternatively, use CustomFormatter for fully custom log formatting:
 //
@@ -115,6 +123,9 @@ func Handler(opt MiddlewareOptions) func(http.Handler) http.Handler {
 		Format: opt.OutputFormat,
 	})
 	level := opt.Level
+	if opt.RequestIDHeader == "" {
+		opt.RequestIDHeader = requestid.DefaultHeader
+	}
 
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -139,6 +150,10 @@ func Handler(opt MiddlewareOptions) func(http.Handler) http.Handler {
 				Latency:         time.Since(start),
 				ResponseHeaders: wrapped.Header(),
 				StartTime:       start,
+				RequestID:       requestid.FromContext(r.Context()),
+			}
+			if ctx.RequestID == "" {
+				ctx.RequestID = r.Header.Get(opt.RequestIDHeader)
 			}
 
 			content := formatLogMessage(opt, ctx)
@@ -200,6 +215,8 @@ func replaceSpec(content, spec string, ctx LogContext) string {
 		return strings.ReplaceAll(content, "${latency}", ctx.Latency.String())
 	case "date":
 		return strings.ReplaceAll(content, "${date}", ctx.StartTime.Format("2006-01-02 15:04:05"))
+	case "request-id":
+		return strings.ReplaceAll(content, "${request-id}", html.EscapeString(ctx.RequestID))
 	default:
 		return content
 	}
@@ -4,12 +4,16 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/require"
 	"github.com/tinh-tinh/tinhtinh/v2/core"
 	"github.com/tinh-tinh/tinhtinh/v2/middleware/logger"
+	"github.com/tinh-tinh/tinhtinh/v2/middleware/requestid"
 )
 
 func TestMiddleware(t *testing.T) {
@@ -516,3 +520,58 @@ func TestSkipPaths(t *testing.T) {
 		require.True(t, logCalled, "Logger should be called for non-skipped path")
 	})
 }
+
+func TestRequestID(t *testing.T) {
+	appController := func(module core.Module) core.Controller {
+		ctrl := module.NewController("test")
+		ctrl.Get("", func(ctx core.Ctx) error {
+			return ctx.JSON(core.Map{"data": ctx.RequestID()})
+		})
+		return ctrl
+	}
+
+	appModule := func() core.Module {
+		return core.NewModule(core.NewModuleOptions{
+			Controllers: []core.Controllers{appController},
+		})
+	}
+
+	var captured string
+	dir := t.TempDir()
+	app := core.CreateFactory(appModule)
+	app.SetGlobalPrefix("/api")
+	app.Use(requestid.Handler(requestid.Options{}))
+	app.Use(logger.Handler(logger.MiddlewareOptions{
+		CustomFormatter: func(ctx logger.LogContext) string {
+			captured = ctx.RequestID
+			return "log"
+		},
+	}))
+	app.Use(logger.Handler(logger.MiddlewareOptions{
+		Path:   dir,
+		Format: "${request-id} ${path}",
+	}))
+
+	testServer := httptest.NewServer(app.PrepareBeforeListen())
+	defer testServer.Close()
+
+	req, err := http.NewRequest(http.MethodGet, testServer.URL+"/api/test", nil)
+	require.Nil(t, err)
+	req.Header.Set("X-Request-Id", "abc-123")
+	resp, err := testServer.Client().Do(req)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "abc-123", resp.Header.Get("X-Request-Id"))
+	require.Equal(t, "abc-123", captured)
+
+	require.Eventually(t, func() bool {
+		files, _ := os.ReadDir(dir)
+		for _, file := range files {
+			data, _ := os.ReadFile(filepath.Join(dir, file.Name()))
+			if strings.Contains(string(data), "abc-123 /api/test") {
+				return true
+			}
+		}
+		return false
+	}, 3*time.Second, 100*time.Millisecond)
+}
@@ -0,0 +1,102 @@
+// Package requestid identifies each request with an ID, taken from the
+// request header when the client or a proxy sent one, generated otherwise.
+// The ID is echoed in the response header and stored in the context of the
+// request, so the logs of a request can be correlated.
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+// DefaultHeader is the header carrying the request ID when none is given.
+const DefaultHeader = "X-Request-Id"
+
+// maxLength is the length above which an incoming request ID is replaced.
+const maxLength = 128
+
+type Options struct {
+	// Header carrying the request ID in the request and the response.
+	// Default is "X-Request-Id".
+	Header string
+	// Generator creates the ID of the requests without one. Default
+	// generates a random UUID.
+	Generator func() string
+	// IgnoreIncoming generates an ID for every request, ignoring the ID sent
+	// by the client.
+	IgnoreIncoming bool
+}
+
+type contextKey struct{}
+
+// Handler returns a middleware setting the ID of each request. An incoming
+// ID is kept when it has at most 128 printable ASCII characters. The ID is
+// set in the header of the request, so middlewares wrapping this one can read
+// it once the request is served, and in the header of the response.
+func Handler(opt Options) func(http.Handler) http.Handler {
+	if opt.Header == "" {
+		opt.Header = DefaultHeader
+	}
+	if opt.Generator == nil {
+		opt.Generator = NewID
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(opt.Header)
+			if opt.IgnoreIncoming || !valid(id) {
+				id = opt.Generator()
+				r.Header.Set(opt.Header, id)
+			}
+			w.Header().Set(opt.Header, id)
+			next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), id)))
+		})
+	}
+}
+
+// NewContext returns a copy of the context holding the request ID.
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the request ID of the context, or an empty string.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// NewID returns a random UUID (version 4).
+func NewID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	var buf [36]byte
+	hex.Encode(buf[0:8], b[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], b[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], b[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], b[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], b[10:])
+	return string(buf[:])
+}
+
+// valid reports whether an incoming ID can be kept: not empty, at most 128
+// characters, printable ASCII without spaces so it is safe to log.
+func valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
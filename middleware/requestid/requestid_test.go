@@ -0,0 +1,75 @@
+package requestid_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tinh-tinh/tinhtinh/v2/middleware/requestid"
+)
+
+func Test_Handler(t *testing.T) {
+	var id string
+	handler := requestid.Handler(requestid.Options{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id = requestid.FromContext(r.Context())
+	}))
+
+	t.Run("generated", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		require.Regexp(t, regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`), id)
+		require.Equal(t, id, rec.Header().Get(requestid.DefaultHeader))
+		require.Equal(t, id, req.Header.Get(requestid.DefaultHeader))
+	})
+
+	t.Run("incoming", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Request-Id", "abc-123")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		require.Equal(t, "abc-123", id)
+		require.Equal(t, "abc-123", rec.Header().Get("X-Request-Id"))
+	})
+
+	t.Run("invalid incoming", func(t *testing.T) {
+		for _, incoming := range []string{"with space", strings.Repeat("a", 129), "tab\tid"} {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("X-Request-Id", incoming)
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			require.NotEqual(t, incoming, id)
+			require.Len(t, id, 36)
+		}
+	})
+}
+
+func Test_Options(t *testing.T) {
+	var id string
+	handler := requestid.Handler(requestid.Options{
+		Header:         "X-Correlation-Id",
+		Generator:      func() string { return "generated" },
+		IgnoreIncoming: true,
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id = requestid.FromContext(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Correlation-Id", "abc-123")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	require.Equal(t, "generated", id)
+	require.Equal(t, "generated", rec.Header().Get("X-Correlation-Id"))
+	require.Empty(t, rec.Header().Get("X-Request-Id"))
+}
+
+func Test_NewID(t *testing.T) {
+	require.NotEqual(t, requestid.NewID(), requestid.NewID())
+	require.Empty(t, requestid.FromContext(httptest.NewRequest(http.MethodGet, "/", nil).Context()))
+}
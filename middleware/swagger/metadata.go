@@ -0,0 +1,39 @@
+package swagger
+
+import "github.com/tinh-tinh/tinhtinh/v2/core"
+
+// Summary sets the summary of the operation.
+func Summary(summary string) *core.Metadata {
+	return core.SetMetadata(core.DOC_SUMMARY, summary)
+}
+
+// Description sets the description of the operation.
+func Description(description string) *core.Metadata {
+	return core.SetMetadata(core.DOC_DESCRIPTION, description)
+}
+
+// Deprecated marks the operation as deprecated.
+func Deprecated() *core.Metadata {
+	return core.SetMetadata(core.DOC_DEPRECATED, true)
+}
+
+// Security sets the security schemes required by the operation. The names
+// must match the SecuritySchemes of the document options.
+func Security(names ...string) *core.Metadata {
+	return core.SetMetadata(core.DOC_SECURITY, names)
+}
+
+// Response documents a response of the operation with an optional example
+// body.
+func Response(status int, description string, example ...interface{}) *core.Metadata {
+	res := core.DocResponse{Status: status, Description: description}
+	if len(example) > 0 {
+		res.Example = example[0]
+	}
+	return core.SetMetadata(core.DOC_RESPONSE, res)
+}
+
+// Exclude hides the route from the document.
+func Exclude() *core.Metadata {
+	return core.SetMetadata(core.DOC_EXCLUDE, true)
+}
@@ -0,0 +1,152 @@
+// Package swagger serves the OpenAPI document of the application together
+// with an interactive documentation page.
+//
+// The page and its assets are embedded in the binary so the documentation
+// works offline.
+package swagger
+
+import (
+	"embed"
+	"html/template"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/tinh-tinh/tinhtinh/v2/common/exception"
+	"github.com/tinh-tinh/tinhtinh/v2/common/openapi"
+	"github.com/tinh-tinh/tinhtinh/v2/core"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed ui
+var assets embed.FS
+
+var page = template.Must(template.ParseFS(assets, "ui/index.html"))
+
+// DefaultPath is the path the documentation is served at when none is given.
+const DefaultPath = "docs"
+
+type Options struct {
+	// Path the documentation is served at, below the global prefix.
+	// Default is "docs".
+	Path string
+	// Document configures the info, servers and security schemes of the
+	// generated document.
+	Document openapi.Options
+	// Guards protect every documentation route.
+	Guards []core.Guard
+}
+
+// Module creates a module serving the documentation of the application.
+//
+// The following routes are mounted below the global prefix:
+//
+//   - GET /{path}: the interactive documentation page
+//   - GET /{path}/openapi.json: the document in JSON
+//   - GET /{path}/openapi.yaml: the document in YAML
+//   - GET /{path}/assets/{file}: the embedded assets of the page
+//
+// The document is built from the registered routes on the first request, so
+// it includes the routes of every module regardless of the import order. The
+// documentation routes themselves are excluded from it.
+func Module(opt Options) core.Modules {
+	path := strings.Trim(opt.Path, "/")
+	if path == "" {
+		path = DefaultPath
+	}
+
+	controller := func(module core.Module) core.Controller {
+		ctrl := module.NewController(path)
+		spec := &spec{opt: opt.Document}
+
+		ctrl.Metadata(core.SetMetadata(core.DOC_EXCLUDE, true)).Guard(opt.Guards...).Registry()
+
+		ctrl.Get("", func(ctx core.Ctx) error {
+			app, err := getApp(ctx)
+			if err != nil {
+				return err
+			}
+			base := core.IfSlashPrefixString(app.Prefix) + core.IfSlashPrefixString(path)
+			title := opt.Document.Title
+			if title == "" {
+				title = "API Documentation"
+			}
+
+			ctx.Res().Header().Set("Content-Type", "text/html; charset=utf-8")
+			return page.Execute(ctx.Res(), map[string]string{
+				"Title": title,
+				"Base":  base,
+				"Spec":  base + "/openapi.json",
+			})
+		})
+
+		ctrl.Get("openapi.json", func(ctx core.Ctx) error {
+			doc, err := spec.get(ctx)
+			if err != nil {
+				return err
+			}
+			return ctx.JSON(doc)
+		})
+
+		ctrl.Get("openapi.yaml", func(ctx core.Ctx) error {
+			doc, err := spec.get(ctx)
+			if err != nil {
+				return err
+			}
+			data, err := yaml.Marshal(doc)
+			if err != nil {
+				return err
+			}
+			ctx.Res().Header().Set("Content-Type", "application/yaml")
+			_, err = ctx.Res().Write(data)
+			return err
+		})
+
+		ctrl.Get("assets/{file}", func(ctx core.Ctx) error {
+			name := ctx.Path("file")
+			data, err := assets.ReadFile("ui/" + name)
+			if err != nil || name == "index.html" {
+				return exception.NotFound("asset not found")
+			}
+			ctx.Res().Header().Set("Content-Type", mime.TypeByExtension(filepath.Ext(name)))
+			_, err = ctx.Res().Write(data)
+			return err
+		})
+
+		return ctrl
+	}
+
+	return func(module core.Module) core.Module {
+		return module.New(core.NewModuleOptions{
+			Controllers: []core.Controllers{controller},
+		})
+	}
+}
+
+// spec builds the document once and caches it.
+type spec struct {
+	opt  openapi.Options
+	once sync.Once
+	doc  *openapi.Document
+}
+
+func (s *spec) get(ctx core.Ctx) (*openapi.Document, error) {
+	app, err := getApp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.once.Do(func() {
+		s.doc = app.OpenAPI(s.opt)
+	})
+	return s.doc, nil
+}
+
+func getApp(ctx core.Ctx) (*core.App, error) {
+	app, ok := ctx.Ref(core.APP).(*core.App)
+	if !ok {
+		return nil, exception.ThrowHttp("application not found", http.StatusInternalServerError)
+	}
+	return app, nil
+}
@@ -0,0 +1,134 @@
+package swagger_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tinh-tinh/tinhtinh/v2/common/openapi"
+	"github.com/tinh-tinh/tinhtinh/v2/core"
+	"github.com/tinh-tinh/tinhtinh/v2/middleware/swagger"
+	"gopkg.in/yaml.v3"
+)
+
+type CreatePostDto struct {
+	Title string `json:"title" validate:"required"`
+}
+
+func appModule(opt swagger.Options) core.ModuleParam {
+	postController := func(module core.Module) core.Controller {
+		ctrl := module.NewController("posts")
+
+		ctrl.Metadata(swagger.Summary("List posts"), swagger.Response(200, "Posts", []CreatePostDto{{Title: "a"}})).
+			Get("", func(ctx core.Ctx) error {
+				return ctx.JSON(core.Map{"data": "list"})
+			})
+
+		ctrl.Metadata(swagger.Security("bearerAuth"), swagger.Deprecated(), swagger.Description("Create a post")).
+			Pipe(core.BodyParser[CreatePostDto]{}).
+			Post("", func(ctx core.Ctx) error {
+				return ctx.JSON(core.Map{"data": "created"})
+			})
+
+		ctrl.Metadata(swagger.Exclude()).Get("internal", func(ctx core.Ctx) error {
+			return ctx.JSON(core.Map{"data": "internal"})
+		})
+
+		return ctrl
+	}
+
+	return func() core.Module {
+		return core.NewModule(core.NewModuleOptions{
+			Imports:     []core.Modules{swagger.Module(opt)},
+			Controllers: []core.Controllers{postController},
+		})
+	}
+}
+
+func Test_Module(t *testing.T) {
+	app := core.CreateFactory(appModule(swagger.Options{
+		Document: openapi.Options{
+			Title: "Blog",
+			SecuritySchemes: map[string]*openapi.SecurityScheme{
+				"bearerAuth": {Type: "http", Scheme: "bearer"},
+			},
+		},
+	}))
+	app.SetGlobalPrefix("/api")
+
+	testServer := httptest.NewServer(app.PrepareBeforeListen())
+	defer testServer.Close()
+	testClient := testServer.Client()
+
+	resp, err := testClient.Get(testServer.URL + "/api/docs")
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "text/html; charset=utf-8", resp.Header.Get("Content-Type"))
+	data, err := io.ReadAll(resp.Body)
+	require.Nil(t, err)
+	require.Contains(t, string(data), "<title>Blog</title>")
+	require.Contains(t, string(data), `data-spec="/api/docs/openapi.json"`)
+
+	resp, err = testClient.Get(testServer.URL + "/api/docs/openapi.json")
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var doc openapi.Document
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&doc))
+	require.Equal(t, "Blog", doc.Info.Title)
+	require.Len(t, doc.Paths, 1)
+
+	posts := *doc.Paths["/api/posts"]
+	require.Equal(t, "List posts", posts["get"].Summary)
+	require.Equal(t, "Posts", posts["get"].Responses["200"].Description)
+	require.Equal(t, "array", posts["get"].Responses["200"].Content["application/json"].Schema.Type)
+	require.True(t, posts["post"].Deprecated)
+	require.Equal(t, "Create a post", posts["post"].Description)
+	require.Equal(t, []openapi.SecurityRequirement{{"bearerAuth": {}}}, posts["post"].Security)
+
+	resp, err = testClient.Get(testServer.URL + "/api/docs/openapi.yaml")
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "application/yaml", resp.Header.Get("Content-Type"))
+	data, err = io.ReadAll(resp.Body)
+	require.Nil(t, err)
+	var yamlDoc map[string]interface{}
+	require.Nil(t, yaml.Unmarshal(data, &yamlDoc))
+	require.Equal(t, openapi.Version, yamlDoc["openapi"])
+
+	resp, err = testClient.Get(testServer.URL + "/api/docs/assets/app.js")
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.True(t, strings.HasPrefix(resp.Header.Get("Content-Type"), "text/javascript"))
+
+	resp, err = testClient.Get(testServer.URL + "/api/docs/assets/unknown.js")
+	require.Nil(t, err)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func Test_Module_Guard(t *testing.T) {
+	app := core.CreateFactory(appModule(swagger.Options{
+		Path: "/reference/",
+		Guards: []core.Guard{
+			func(ctx core.Ctx) bool {
+				return ctx.Query("key") == "secret"
+			},
+		},
+	}))
+
+	testServer := httptest.NewServer(app.PrepareBeforeListen())
+	defer testServer.Close()
+	testClient := testServer.Client()
+
+	resp, err := testClient.Get(testServer.URL + "/reference/openapi.json")
+	require.Nil(t, err)
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	resp, err = testClient.Get(testServer.URL + "/reference/openapi.json?key=secret")
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+}
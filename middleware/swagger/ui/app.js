@@ -0,0 +1,157 @@
+(function () {
+  'use strict';
+
+  const main = document.getElementById('operations');
+  const specUrl = main.dataset.spec;
+  const METHODS = ['get', 'post', 'put', 'patch', 'delete'];
+
+  function el(tag, attrs, children) {
+    const node = document.createElement(tag);
+    Object.entries(attrs || {}).forEach(([k, v]) => {
+      if (k === 'text') node.textContent = v;
+      else node.setAttribute(k, v);
+    });
+    (children || []).forEach(c => c && node.appendChild(c));
+    return node;
+  }
+
+  function resolve(spec, schema) {
+    if (schema && schema.$ref) {
+      const name = schema.$ref.split('/').pop();
+      return spec.components.schemas[name] || {};
+    }
+    return schema || {};
+  }
+
+  // Builds an example value from a schema, following $ref once per type.
+  function example(spec, schema, seen) {
+    seen = seen || {};
+    if (schema && schema.$ref) {
+      if (seen[schema.$ref]) return {};
+      seen = Object.assign({}, seen, { [schema.$ref]: true });
+    }
+    const s = resolve(spec, schema);
+    if (s.example !== undefined) return s.example;
+    if (s.default !== undefined) return s.default;
+    if (s.enum) return s.enum[0];
+    switch (s.type) {
+      case 'object': {
+        const out = {};
+        Object.entries(s.properties || {}).forEach(([k, v]) => { out[k] = example(spec, v, seen); });
+        return out;
+      }
+      case 'array': return [example(spec, s.items, seen)];
+      case 'integer':
+      case 'number': return 0;
+      case 'boolean': return false;
+      case 'string': return s.format === 'email' ? 'user@example.com' : 'string';
+      default: return null;
+    }
+  }
+
+  function paramsTable(params) {
+    const rows = params.map(p => el('tr', {}, [
+      el('td', { text: p.name + (p.required ? ' *' : '') }),
+      el('td', { text: p.in }),
+      el('td', { text: (p.schema && (p.schema.format || p.schema.type)) || '' }),
+      el('td', {}, [el('input', { 'data-name': p.name, 'data-in': p.in, placeholder: p.description || p.name })]),
+    ]));
+    return el('table', {}, [el('tr', {}, ['Name', 'In', 'Type', 'Value'].map(t => el('th', { text: t })))].concat(rows));
+  }
+
+  async function execute(path, method, body, output) {
+    let url = path;
+    const query = new URLSearchParams();
+    const headers = { 'Accept': 'application/json' };
+    body.querySelectorAll('input[data-name]').forEach(input => {
+      if (input.value === '') return;
+      const name = input.dataset.name;
+      switch (input.dataset.in) {
+        case 'path': url = url.replace('{' + name + '}', encodeURIComponent(input.value)); break;
+        case 'query': query.append(name, input.value); break;
+        case 'header': headers[name] = input.value; break;
+      }
+    });
+    const auth = document.getElementById('authorization').value;
+    if (auth) headers['Authorization'] = auth;
+    const init = { method: method.toUpperCase(), headers };
+    const textarea = body.querySelector('textarea');
+    if (textarea) {
+      headers['Content-Type'] = 'application/json';
+      init.body = textarea.value;
+    }
+    const qs = query.toString();
+    try {
+      const res = await fetch(url + (qs ? '?' + qs : ''), init);
+      const text = await res.text();
+      let pretty = text;
+      try { pretty = JSON.stringify(JSON.parse(text), null, 2); } catch (e) { /* not json */ }
+      output.replaceChildren(el('div', { class: 'status', text: res.status + ' ' + res.statusText }), el('pre', { text: pretty }));
+    } catch (e) {
+      output.replaceChildren(el('pre', { text: String(e) }));
+    }
+  }
+
+  function operation(spec, path, method, op) {
+    const body = el('div', { class: 'body' });
+    if (op.description) body.appendChild(el('p', { text: op.description }));
+    if (op.parameters && op.parameters.length) {
+      body.appendChild(el('h3', { text: 'Parameters' }));
+      body.appendChild(paramsTable(op.parameters));
+    }
+    if (op.requestBody) {
+      const media = op.requestBody.content['application/json'] || {};
+      body.appendChild(el('h3', { text: 'Request body' }));
+      const textarea = el('textarea');
+      textarea.value = JSON.stringify(example(spec, media.schema), null, 2);
+      body.appendChild(textarea);
+    }
+    body.appendChild(el('h3', { text: 'Responses' }));
+    Object.entries(op.responses || {}).forEach(([code, res]) => {
+      body.appendChild(el('div', { text: code + ' ' + (res.description || '') }));
+      const media = res.content && res.content['application/json'];
+      if (media) {
+        const value = media.example !== undefined ? media.example : example(spec, media.schema);
+        body.appendChild(el('pre', { text: JSON.stringify(value, null, 2) }));
+      }
+    });
+    const output = el('div');
+    const button = el('button', { text: 'Execute' });
+    button.addEventListener('click', () => execute(path, method, body, output));
+    body.appendChild(button);
+    body.appendChild(output);
+
+    const secured = (op.security || spec.security || []).length > 0;
+    return el('details', { class: 'op' + (op.deprecated ? ' deprecated' : '') }, [
+      el('summary', {}, [
+        el('span', { class: 'method ' + method, text: method }),
+        el('span', { class: 'path', text: path }),
+        el('span', { class: 'summary', text: op.summary || '' }),
+        secured ? el('span', { class: 'lock', text: '\u{1F512}' }) : null,
+      ]),
+      body,
+    ]);
+  }
+
+  function render(spec) {
+    document.getElementById('version').textContent = 'v' + spec.info.version;
+    const groups = {};
+    Object.entries(spec.paths || {}).forEach(([path, item]) => {
+      METHODS.forEach(method => {
+        const op = item[method];
+        if (!op) return;
+        const tag = (op.tags && op.tags[0]) || 'default';
+        (groups[tag] = groups[tag] || []).push(operation(spec, path, method, op));
+      });
+    });
+    Object.keys(groups).sort().forEach(tag => {
+      main.appendChild(el('h2', { class: 'tag', text: tag }));
+      groups[tag].forEach(node => main.appendChild(node));
+    });
+  }
+
+  fetch(specUrl)
+    .then(res => res.json())
+    .then(render)
+    .catch(err => main.appendChild(el('pre', { text: 'Failed to load ' + specUrl + ': ' + err })));
+})();
@@ -0,0 +1,141 @@
+package throttler
+
+import (
+	"math"
+	"time"
+)
+
+// Algorithm counts the requests of a key against its limit.
+type Algorithm string
+
+const (
+	// FixedWindow allows Limit requests in each window of TTL. The windows
+	// are aligned on the clock, so a client can send up to twice the limit
+	// around the end of a window.
+	FixedWindow Algorithm = "fixed-window"
+	// SlidingWindow allows Limit requests in any period of TTL, estimated
+	// from the counts of the current and the previous window.
+	SlidingWindow Algorithm = "sliding-window"
+	// TokenBucket allows bursts of Limit requests, the bucket refilling by
+	// Limit tokens every TTL.
+	TokenBucket Algorithm = "token-bucket"
+)
+
+// Result is the outcome of a request counted by a throttler.
+type Result struct {
+	// Allowed reports whether the request is under the limit.
+	Allowed bool
+	// Limit is the number of requests allowed by the policy.
+	Limit int
+	// Remaining is the number of requests still allowed.
+	Remaining int
+	// Reset is the time until the quota is fully restored.
+	Reset time.Duration
+	// RetryAfter is the time to wait before the next request is allowed. It
+	// is zero when requests are still allowed.
+	RetryAfter time.Duration
+}
+
+// take counts a request of a key at now, updating its state in the store.
+func (a Algorithm) take(store Store, key string, limit int, ttl time.Duration, now time.Time) Result {
+	var res Result
+	switch a {
+	case SlidingWindow:
+		store.Update(key, 2*ttl, func(state State) State {
+			state, res = slidingWindow(state, limit, ttl, now)
+			return state
+		})
+	case TokenBucket:
+		store.Update(key, ttl, func(state State) State {
+			state, res = tokenBucket(state, limit, ttl, now)
+			return state
+		})
+	default:
+		store.Update(key, ttl, func(state State) State {
+			state, res = fixedWindow(state, limit, ttl, now)
+			return state
+		})
+	}
+	return res
+}
+
+func fixedWindow(state State, limit int, ttl time.Duration, now time.Time) (State, Result) {
+	start := now.Truncate(ttl)
+	if !state.Start.Equal(start) {
+		state = State{Start: start}
+	}
+	res := Result{Limit: limit, Reset: start.Add(ttl).Sub(now)}
+	if state.Count < limit {
+		state.Count++
+		res.Allowed = true
+	} else {
+		res.RetryAfter = res.Reset
+	}
+	res.Remaining = limit - state.Count
+	return state, res
+}
+
+func slidingWindow(state State, limit int, ttl time.Duration, now time.Time) (State, Result) {
+	start := now.Truncate(ttl)
+	switch {
+	case state.Start.Equal(start):
+	case state.Start.Equal(start.Add(-ttl)):
+		state = State{Start: start, Previous: state.Count}
+	default:
+		state = State{Start: start}
+	}
+
+	elapsed := now.Sub(start)
+	weight := 1 - float64(elapsed)/float64(ttl)
+	estimated := float64(state.Previous)*weight + float64(state.Count)
+
+	res := Result{Limit: limit, Reset: start.Add(ttl).Sub(now)}
+	if estimated+1 <= float64(limit) {
+		state.Count++
+		estimated++
+		res.Allowed = true
+	} else {
+		res.RetryAfter = slidingRetryAfter(state, limit, ttl, elapsed)
+	}
+	res.Remaining = max(int(float64(limit)-estimated), 0)
+	if state.Count > 0 {
+		// The requests of the window weigh until the end of the next one.
+		res.Reset += ttl
+	}
+	return state, res
+}
+
+// slidingRetryAfter returns the time until the estimated count of the sliding
+// window is low enough to allow a request.
+func slidingRetryAfter(state State, limit int, ttl time.Duration, elapsed time.Duration) time.Duration {
+	allowed := float64(limit - 1)
+	if float64(state.Count) <= allowed && state.Previous > 0 {
+		// Allowed once the previous window weighs less.
+		at := time.Duration(float64(ttl) * (1 - (allowed-float64(state.Count))/float64(state.Previous)))
+		return max(at-elapsed, time.Nanosecond)
+	}
+	// Allowed in the next window, once the current one weighs less.
+	at := time.Duration(float64(ttl) * (1 - allowed/float64(state.Count)))
+	return ttl - elapsed + at
+}
+
+func tokenBucket(state State, limit int, ttl time.Duration, now time.Time) (State, Result) {
+	rate := float64(limit) / float64(ttl)
+	if state.Start.IsZero() {
+		state = State{Start: now, Tokens: float64(limit)}
+	} else if elapsed := now.Sub(state.Start); elapsed > 0 {
+		state.Tokens = math.Min(float64(limit), state.Tokens+float64(elapsed)*rate)
+		state.Start = now
+	}
+
+	res := Result{Limit: limit}
+	if state.Tokens >= 1 {
+		state.Tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = time.Duration(math.Ceil((1 - state.Tokens) / rate))
+	}
+	res.Remaining = int(state.Tokens)
+	res.Reset = time.Duration(math.Ceil((float64(limit) - state.Tokens) / rate))
+	return state, res
+}
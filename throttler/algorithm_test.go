@@ -0,0 +1,101 @@
+package throttler_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tinh-tinh/tinhtinh/v2/throttler"
+)
+
+func Test_FixedWindow(t *testing.T) {
+	th := throttler.New(throttler.Options{})
+	limit := throttler.Limit{Limit: 3, TTL: time.Hour, Algorithm: throttler.FixedWindow}
+
+	for i := 2; i >= 0; i-- {
+		res := th.Take("client", limit)
+		require.True(t, res.Allowed)
+		require.Equal(t, 3, res.Limit)
+		require.Equal(t, i, res.Remaining)
+		require.Zero(t, res.RetryAfter)
+	}
+
+	res := th.Take("client", limit)
+	require.False(t, res.Allowed)
+	require.Equal(t, 0, res.Remaining)
+	require.Equal(t, res.Reset, res.RetryAfter)
+	require.True(t, res.RetryAfter > 0 && res.RetryAfter <= time.Hour)
+
+	require.True(t, th.Take("other", limit).Allowed)
+}
+
+func Test_FixedWindow_Reset(t *testing.T) {
+	th := throttler.New(throttler.Options{})
+	limit := throttler.Limit{Limit: 1, TTL: 50 * time.Millisecond}
+
+	require.True(t, th.Take("client", limit).Allowed)
+	res := th.Take("client", limit)
+	require.False(t, res.Allowed)
+
+	time.Sleep(res.RetryAfter)
+	require.True(t, th.Take("client", limit).Allowed)
+}
+
+func Test_SlidingWindow(t *testing.T) {
+	th := throttler.New(throttler.Options{})
+	limit := throttler.Limit{Limit: 2, TTL: time.Hour, Algorithm: throttler.SlidingWindow}
+
+	require.True(t, th.Take("client", limit).Allowed)
+	res := th.Take("client", limit)
+	require.True(t, res.Allowed)
+	require.Equal(t, 0, res.Remaining)
+
+	res = th.Take("client", limit)
+	require.False(t, res.Allowed)
+	// The requests of the window weigh until the end of the next one.
+	require.True(t, res.RetryAfter > 0 && res.RetryAfter <= 2*time.Hour)
+	require.True(t, res.Reset > time.Hour)
+}
+
+func Test_SlidingWindow_Previous(t *testing.T) {
+	th := throttler.New(throttler.Options{})
+	ttl := 200 * time.Millisecond
+	limit := throttler.Limit{Limit: 4, TTL: ttl, Algorithm: throttler.SlidingWindow}
+
+	// Start at the beginning of a window.
+	time.Sleep(time.Until(time.Now().Truncate(ttl).Add(ttl)))
+	for i := 0; i < 4; i++ {
+		require.True(t, th.Take("client", limit).Allowed)
+	}
+	require.False(t, th.Take("client", limit).Allowed)
+
+	// At the beginning of the next window, the previous one still weighs
+	// nearly all its requests, unlike with a fixed window.
+	time.Sleep(time.Until(time.Now().Truncate(ttl).Add(ttl + 10*time.Millisecond)))
+	res := th.Take("client", limit)
+	require.False(t, res.Allowed)
+	require.True(t, res.RetryAfter > 0 && res.RetryAfter < ttl)
+
+	time.Sleep(res.RetryAfter)
+	require.True(t, th.Take("client", limit).Allowed)
+}
+
+func Test_TokenBucket(t *testing.T) {
+	th := throttler.New(throttler.Options{Algorithm: throttler.TokenBucket})
+	limit := throttler.Limit{Limit: 2, TTL: 200 * time.Millisecond}
+
+	res := th.Take("client", limit)
+	require.True(t, res.Allowed)
+	require.Equal(t, 1, res.Remaining)
+	require.True(t, th.Take("client", limit).Allowed)
+
+	res = th.Take("client", limit)
+	require.False(t, res.Allowed)
+	// A token is added every 100ms.
+	require.True(t, res.RetryAfter > 0 && res.RetryAfter <= 100*time.Millisecond)
+	require.True(t, res.Reset > 100*time.Millisecond && res.Reset <= 200*time.Millisecond)
+
+	time.Sleep(res.RetryAfter)
+	require.True(t, th.Take("client", limit).Allowed)
+	require.False(t, th.Take("client", limit).Allowed)
+}
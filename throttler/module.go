@@ -0,0 +1,33 @@
+package throttler
+
+import "github.com/tinh-tinh/tinhtinh/v2/core"
+
+// THROTTLER is the name of the provider of the *Throttler. The throttler
+// module is global, so the throttler can be injected in every module.
+const THROTTLER core.Provide = "THROTTLER"
+
+// Module creates a global module providing a throttler created from the
+// options. The routes are throttled by the Handler middleware:
+//
+//	appModule := core.NewModule(core.NewModuleOptions{
+//		Imports:     []core.Modules{throttler.Module(throttler.Options{Limit: 10, TTL: time.Minute})},
+//		Middlewares: []core.Middleware{throttler.Handler},
+//	})
+func Module(opt Options) core.Modules {
+	return func(module core.Module) core.Module {
+		throttlerModule := module.New(core.NewModuleOptions{Global: true})
+		throttlerModule.NewProvider(core.ProviderOptions{
+			Name:  THROTTLER,
+			Value: New(opt),
+		})
+		throttlerModule.Export(THROTTLER)
+		return throttlerModule
+	}
+}
+
+// InjectThrottler returns the throttler of the module, or nil if the
+// throttler module is not imported.
+func InjectThrottler(module core.Module) *Throttler {
+	t, _ := module.Ref(THROTTLER).(*Throttler)
+	return t
+}
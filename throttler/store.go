@@ -0,0 +1,54 @@
+package throttler
+
+import (
+	"sync"
+	"time"
+
+	"github.com/tinh-tinh/tinhtinh/v2/common/memory"
+)
+
+// State is the state of a key, as kept by the algorithms.
+type State struct {
+	// Start is the start of the current window, or the time of the last
+	// refill of a token bucket.
+	Start time.Time
+	// Count is the number of requests of the current window.
+	Count int
+	// Previous is the number of requests of the previous window, used by the
+	// sliding window.
+	Previous int
+	// Tokens left in a token bucket.
+	Tokens float64
+}
+
+// Store keeps the state of the keys. Update must be atomic for a key, so the
+// requests of a client are counted exactly, even across instances when the
+// store is shared.
+type Store interface {
+	// Update calls fn with the state of the key, the zero State when the key
+	// has none, and keeps the state returned for ttl.
+	Update(key string, ttl time.Duration, fn func(state State) State) State
+}
+
+type memoryStore struct {
+	mu    sync.Mutex
+	store *memory.Store
+}
+
+// NewMemoryStore creates a store keeping the states in memory, in a
+// memory.Store.
+func NewMemoryStore() Store {
+	return &memoryStore{store: memory.New(memory.Options{})}
+}
+
+func (m *memoryStore) Update(key string, ttl time.Duration, fn func(state State) State) State {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	state, _ := m.store.Get(key).(State)
+	state = fn(state)
+	// The memory store expires the values by second, from a timestamp which
+	// may be up to a second late.
+	m.store.Set(key, state, ttl.Truncate(time.Second)+2*time.Second)
+	return state
+}
@@ -0,0 +1,220 @@
+// Package throttler limits the rate of the requests of each client, keyed by
+// IP, by user or by a custom extractor. The limits are set for the whole
+// application and overridden by route with the Throttle metadata.
+package throttler
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/tinh-tinh/tinhtinh/v2/common/exception"
+	"github.com/tinh-tinh/tinhtinh/v2/core"
+)
+
+const (
+	// THROTTLE is the metadata key of the limit of a route.
+	THROTTLE = "throttle"
+	// SKIP_THROTTLE is the metadata key of the routes which are not
+	// throttled.
+	SKIP_THROTTLE = "skip_throttle"
+)
+
+const (
+	// DefaultLimit is the number of requests allowed by TTL when none is
+	// given.
+	DefaultLimit = 100
+	// DefaultTTL is the period of the limit when none is given.
+	DefaultTTL = time.Minute
+	// DefaultMessage is the message of the responses to the throttled
+	// requests.
+	DefaultMessage = "Too many requests"
+)
+
+// KeyExtractor returns the key identifying the client of a request. The
+// requests of a key are counted together.
+type KeyExtractor func(ctx core.Ctx) string
+
+// Limit is a rate limit. Set on a route with Throttle, its zero fields keep
+// the values of the throttler.
+type Limit struct {
+	// Limit is the number of requests allowed by TTL.
+	Limit int
+	// TTL is the period of the limit.
+	TTL time.Duration
+	// Algorithm counting the requests.
+	Algorithm Algorithm
+	// KeyExtractor identifies the client of the requests.
+	KeyExtractor KeyExtractor
+}
+
+type Options struct {
+	// Limit is the number of requests allowed by TTL. Default is
+	// DefaultLimit.
+	Limit int
+	// TTL is the period of the limit. Default is DefaultTTL.
+	TTL time.Duration
+	// Algorithm counting the requests. Default is FixedWindow.
+	Algorithm Algorithm
+	// KeyExtractor identifies the client of the requests. Default is ByIP.
+	KeyExtractor KeyExtractor
+	// Store keeping the counts. Default keeps them in memory, so they are
+	// not shared between the instances of the application.
+	Store Store
+	// Message of the responses to the throttled requests. Default is
+	// DefaultMessage.
+	Message string
+	// DisableHeaders removes the RateLimit headers from the responses.
+	DisableHeaders bool
+}
+
+// Throttler counts the requests of the clients against the limits.
+type Throttler struct {
+	opt Options
+}
+
+// New creates a throttler from the options.
+func New(opt Options) *Throttler {
+	if opt.Limit <= 0 {
+		opt.Limit = DefaultLimit
+	}
+	if opt.TTL <= 0 {
+		opt.TTL = DefaultTTL
+	}
+	if opt.Algorithm == "" {
+		opt.Algorithm = FixedWindow
+	}
+	if opt.KeyExtractor == nil {
+		opt.KeyExtractor = ByIP
+	}
+	if opt.Store == nil {
+		opt.Store = NewMemoryStore()
+	}
+	if opt.Message == "" {
+		opt.Message = DefaultMessage
+	}
+	return &Throttler{opt: opt}
+}
+
+// Take counts a request of the key against the limit. The zero fields of the
+// limit take the values of the options, the key extractor is not used.
+func (t *Throttler) Take(key string, limit Limit) Result {
+	limit = t.resolve(limit)
+	return limit.Algorithm.take(t.opt.Store, key, limit.Limit, limit.TTL, time.Now())
+}
+
+// Handler is a middleware throttling the requests of the route. The requests
+// are counted by route, with the limit of the Throttle metadata of the route
+// when it has one. The RateLimit headers tell the client its quota, and the
+// requests over the limit are answered with a 429 status and a Retry-After
+// header.
+func (t *Throttler) Handler(ctx core.Ctx) error {
+	if core.Reflector[bool](SKIP_THROTTLE, ctx) {
+		return ctx.Next()
+	}
+	limit := t.resolve(core.Reflector[Limit](THROTTLE, ctx))
+
+	key := "throttler:" + route(ctx) + ":" + limit.KeyExtractor(ctx)
+	res := limit.Algorithm.take(t.opt.Store, key, limit.Limit, limit.TTL, time.Now())
+
+	header := ctx.Res().Header()
+	if !t.opt.DisableHeaders {
+		header.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", limit.Limit, seconds(limit.TTL)))
+		header.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+		header.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		header.Set("RateLimit-Reset", strconv.Itoa(seconds(res.Reset)))
+	}
+	if !res.Allowed {
+		header.Set("Retry-After", strconv.Itoa(seconds(res.RetryAfter)))
+		return exception.ThrowHttp(t.opt.Message, http.StatusTooManyRequests)
+	}
+	return ctx.Next()
+}
+
+// resolve fills the zero fields of the limit with the options.
+func (t *Throttler) resolve(limit Limit) Limit {
+	if limit.Limit <= 0 {
+		limit.Limit = t.opt.Limit
+	}
+	if limit.TTL <= 0 {
+		limit.TTL = t.opt.TTL
+	}
+	if limit.Algorithm == "" {
+		limit.Algorithm = t.opt.Algorithm
+	}
+	if limit.KeyExtractor == nil {
+		limit.KeyExtractor = t.opt.KeyExtractor
+	}
+	return limit
+}
+
+// Handler is a middleware throttling the requests with the throttler of the
+// module. It does nothing when the module is not imported.
+//
+//	appModule.Use(throttler.Handler)
+func Handler(ctx core.Ctx) error {
+	t, ok := ctx.Ref(THROTTLER).(*Throttler)
+	if !ok {
+		return ctx.Next()
+	}
+	return t.Handler(ctx)
+}
+
+// Throttle sets the limit of a route, overriding the options of the
+// throttler.
+func Throttle(limit Limit) *core.Metadata {
+	return core.SetMetadata(THROTTLE, limit)
+}
+
+// SkipThrottle disables the throttling of a route.
+func SkipThrottle() *core.Metadata {
+	return core.SetMetadata(SKIP_THROTTLE, true)
+}
+
+// ByIP identifies the clients by the IP address of the request.
+func ByIP(ctx core.Ctx) string {
+	addr := ctx.Req().RemoteAddr
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+// ByUser identifies the clients by the user set under core.USER, and by IP
+// address when the request has no user.
+func ByUser(ctx core.Ctx) string {
+	if user := core.UserID(ctx); user != "" {
+		return "user:" + user
+	}
+	return ByIP(ctx)
+}
+
+// ByHeader identifies the clients by a header of the request, such as an API
+// key, and by IP address when the request has none.
+func ByHeader(name string) KeyExtractor {
+	return func(ctx core.Ctx) string {
+		if value := ctx.Headers(name); value != "" {
+			return "header:" + value
+		}
+		return ByIP(ctx)
+	}
+}
+
+// route returns the pattern of the route of the request, or its path when the
+// route is unknown.
+func route(ctx core.Ctx) string {
+	if app, ok := ctx.Ref(core.APP).(*core.App); ok {
+		if r, ok := app.MatchRoute(ctx.Req()); ok {
+			return r.Pattern
+		}
+	}
+	return ctx.Req().Method + " " + ctx.Req().URL.Path
+}
+
+// seconds rounds the duration up to a number of seconds.
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,214 @@
+package throttler_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tinh-tinh/tinhtinh/v2/core"
+	"github.com/tinh-tinh/tinhtinh/v2/throttler"
+)
+
+func Test_Module(t *testing.T) {
+	controller := func(module core.Module) core.Controller {
+		ctrl := module.NewController("test")
+
+		ctrl.Get("", func(ctx core.Ctx) error {
+			return ctx.JSON(core.Map{"data": "ok"})
+		})
+
+		ctrl.Metadata(throttler.Throttle(throttler.Limit{Limit: 1})).Get("strict", func(ctx core.Ctx) error {
+			return ctx.JSON(core.Map{"data": "ok"})
+		})
+
+		ctrl.Metadata(throttler.SkipThrottle()).Get("free", func(ctx core.Ctx) error {
+			return ctx.JSON(core.Map{"data": "ok"})
+		})
+
+		return ctrl
+	}
+
+	appModule := func() core.Module {
+		return core.NewModule(core.NewModuleOptions{
+			Imports: []core.Modules{
+				throttler.Module(throttler.Options{Limit: 2, TTL: time.Hour}),
+			},
+			Middlewares: []core.Middleware{throttler.Handler},
+			Controllers: []core.Controllers{controller},
+		})
+	}
+
+	app := core.CreateFactory(appModule)
+	app.SetGlobalPrefix("/api")
+	require.NotNil(t, throttler.InjectThrottler(app.Module))
+
+	testServer := httptest.NewServer(app.PrepareBeforeListen())
+	defer testServer.Close()
+	testClient := testServer.Client()
+
+	resp, err := testClient.Get(testServer.URL + "/api/test")
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "2", resp.Header.Get("RateLimit-Limit"))
+	require.Equal(t, "1", resp.Header.Get("RateLimit-Remaining"))
+	require.Equal(t, "2;w=3600", resp.Header.Get("RateLimit-Policy"))
+	reset, err := strconv.Atoi(resp.Header.Get("RateLimit-Reset"))
+	require.Nil(t, err)
+	require.True(t, reset > 0 && reset <= 3600)
+
+	resp, err = testClient.Get(testServer.URL + "/api/test")
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "0", resp.Header.Get("RateLimit-Remaining"))
+
+	resp, err = testClient.Get(testServer.URL + "/api/test")
+	require.Nil(t, err)
+	require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	require.Equal(t, resp.Header.Get("RateLimit-Reset"), resp.Header.Get("Retry-After"))
+
+	// The routes are counted separately, with their own limit.
+	resp, err = testClient.Get(testServer.URL + "/api/test/strict")
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "1", resp.Header.Get("RateLimit-Limit"))
+
+	resp, err = testClient.Get(testServer.URL + "/api/test/strict")
+	require.Nil(t, err)
+	require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+
+	for i := 0; i < 5; i++ {
+		resp, err = testClient.Get(testServer.URL + "/api/test/free")
+		require.Nil(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Empty(t, resp.Header.Get("RateLimit-Limit"))
+	}
+}
+
+func Test_KeyExtractor(t *testing.T) {
+	const apiKey = "X-Api-Key"
+	auth := func(ctx core.Ctx) bool {
+		if user := ctx.Query("user"); user != "" {
+			ctx.Set(core.USER, user)
+		}
+		return true
+	}
+
+	controller := func(module core.Module) core.Controller {
+		ctrl := module.NewController("test")
+
+		ctrl.Guard(auth).Get("user", func(ctx core.Ctx) error {
+			return ctx.JSON(core.Map{"data": "ok"})
+		})
+
+		ctrl.Metadata(throttler.Throttle(throttler.Limit{KeyExtractor: throttler.ByHeader(apiKey)})).Get("key", func(ctx core.Ctx) error {
+			return ctx.JSON(core.Map{"data": "ok"})
+		})
+
+		return ctrl
+	}
+
+	th := throttler.New(throttler.Options{
+		Limit:        1,
+		TTL:          time.Hour,
+		KeyExtractor: throttler.ByUser,
+		Message:      "slow down",
+	})
+
+	appModule := func() core.Module {
+		return core.NewModule(core.NewModuleOptions{
+			Controllers: []core.Controllers{controller},
+		})
+	}
+
+	app := core.CreateFactory(appModule)
+	app.Module.Use(th.Handler)
+
+	testServer := httptest.NewServer(app.PrepareBeforeListen())
+	defer testServer.Close()
+	testClient := testServer.Client()
+
+	get := func(path string, header ...string) *http.Response {
+		req, err := http.NewRequest(http.MethodGet, testServer.URL+path, nil)
+		require.Nil(t, err)
+		if len(header) > 0 {
+			req.Header.Set(apiKey, header[0])
+		}
+		resp, err := testClient.Do(req)
+		require.Nil(t, err)
+		return resp
+	}
+
+	require.Equal(t, http.StatusOK, get("/test/user?user=alice").StatusCode)
+	require.Equal(t, http.StatusTooManyRequests, get("/test/user?user=alice").StatusCode)
+	require.Equal(t, http.StatusOK, get("/test/user?user=bob").StatusCode)
+	require.Equal(t, http.StatusOK, get("/test/user").StatusCode)
+	require.Equal(t, http.StatusTooManyRequests, get("/test/user").StatusCode)
+
+	require.Equal(t, http.StatusOK, get("/test/key", "k1").StatusCode)
+	require.Equal(t, http.StatusOK, get("/test/key", "k2").StatusCode)
+	resp := get("/test/key", "k1")
+	require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+
+	var body core.Map
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&body))
+	require.Equal(t, "slow down", body["error"])
+}
+
+func Test_DisableHeaders(t *testing.T) {
+	th := throttler.New(throttler.Options{Limit: 1, DisableHeaders: true})
+
+	controller := func(module core.Module) core.Controller {
+		ctrl := module.NewController("test").Use(th.Handler)
+		ctrl.Get("", func(ctx core.Ctx) error {
+			return ctx.JSON(core.Map{"data": "ok"})
+		})
+		return ctrl
+	}
+
+	app := core.CreateFactory(func() core.Module {
+		return core.NewModule(core.NewModuleOptions{
+			Controllers: []core.Controllers{controller},
+		})
+	})
+
+	testServer := httptest.NewServer(app.PrepareBeforeListen())
+	defer testServer.Close()
+
+	resp, err := testServer.Client().Get(testServer.URL + "/test")
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Empty(t, resp.Header.Get("RateLimit-Limit"))
+
+	resp, err = testServer.Client().Get(testServer.URL + "/test")
+	require.Nil(t, err)
+	require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	require.NotEmpty(t, resp.Header.Get("Retry-After"))
+}
+
+func Test_Handler_WithoutModule(t *testing.T) {
+	controller := func(module core.Module) core.Controller {
+		ctrl := module.NewController("test").Use(throttler.Handler)
+		ctrl.Get("", func(ctx core.Ctx) error {
+			return ctx.JSON(core.Map{"data": "ok"})
+		})
+		return ctrl
+	}
+
+	app := core.CreateFactory(func() core.Module {
+		return core.NewModule(core.NewModuleOptions{
+			Controllers: []core.Controllers{controller},
+		})
+	})
+
+	testServer := httptest.NewServer(app.PrepareBeforeListen())
+	defer testServer.Close()
+
+	resp, err := testServer.Client().Get(testServer.URL + "/test")
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Empty(t, resp.Header.Get("RateLimit-Limit"))
+}
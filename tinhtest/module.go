@@ -0,0 +1,177 @@
+// Package tinhtest builds applications for tests. Providers of the module
+// tree can be replaced by mocks, and requests are handled in-process without
+// binding a port.
+//
+//	app := tinhtest.CreateTestingModule(appModule).
+//		OverrideProvider("db").UseValue(mockDB).
+//		Compile()
+//
+//	resp := app.Inject("GET", "/api/users", nil, nil)
+package tinhtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	"github.com/tinh-tinh/tinhtinh/v2/core"
+)
+
+// TestingModule collects the overrides of a module tree before it is built.
+type TestingModule struct {
+	module    core.ModuleParam
+	options   []core.AppOptions
+	overrides []core.ProviderOverride
+}
+
+// CreateTestingModule creates a testing module from the module function of
+// the application. The options are passed to core.CreateFactory on Compile.
+func CreateTestingModule(module core.ModuleParam, opt ...core.AppOptions) *TestingModule {
+	return &TestingModule{module: module, options: opt}
+}
+
+// OverrideBuilder replaces a provider of the testing module.
+type OverrideBuilder struct {
+	testing *TestingModule
+	name    core.Provide
+}
+
+// OverrideProvider starts the override of the provider with the given name.
+// The override is applied in every module the provider is registered in.
+func (tm *TestingModule) OverrideProvider(name core.Provide) *OverrideBuilder {
+	return &OverrideBuilder{testing: tm, name: name}
+}
+
+// UseValue replaces the provider with the given value.
+func (b *OverrideBuilder) UseValue(value interface{}) *TestingModule {
+	b.testing.overrides = append(b.testing.overrides, core.ProviderOverride{
+		Name:  b.name,
+		Value: value,
+	})
+	return b.testing
+}
+
+// UseFactory replaces the provider with the given factory, called with the
+// providers listed in inject.
+func (b *OverrideBuilder) UseFactory(factory core.Factory, inject ...core.Provide) *TestingModule {
+	b.testing.overrides = append(b.testing.overrides, core.ProviderOverride{
+		Name:    b.name,
+		Factory: factory,
+		Inject:  inject,
+	})
+	return b.testing
+}
+
+// Compile builds the application with the overrides applied. Like
+// core.CreateFactory, it panics if the module tree cannot be built.
+func (tm *TestingModule) Compile() *App {
+	opts := append([]core.AppOptions{{Overrides: tm.overrides}}, tm.options...)
+	return &App{App: core.CreateFactory(tm.module, opts...)}
+}
+
+// App is an application compiled by a testing module.
+type App struct {
+	*core.App
+	once    sync.Once
+	handler http.Handler
+}
+
+// NewApp wraps an application created with core.CreateFactory so it can be
+// used with Request.
+func NewApp(app *core.App) *App {
+	return &App{App: app}
+}
+
+// Handler returns the http.Handler of the application. The routes are
+// registered on the first call, so the global prefix, middlewares and
+// versioning must be configured before.
+func (app *App) Handler() http.Handler {
+	app.once.Do(func() {
+		app.handler = app.PrepareBeforeListen()
+	})
+	return app.handler
+}
+
+// Inject sends a request to the application in-process and returns the
+// recorded response.
+//
+// The body can be nil, a string, a []byte, an io.Reader or any other value,
+// which is encoded to JSON. The Content-Type header defaults to
+// application/json when a body is given.
+func (app *App) Inject(method string, path string, body interface{}, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, toReader(body))
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	return app.Do(req)
+}
+
+// Do sends the request to the application in-process and returns the
+// recorded response.
+func (app *App) Do(req *http.Request) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	app.Handler().ServeHTTP(recorder, req)
+	return recorder
+}
+
+// Get returns the value of the provider with the given name, looked up in
+// every module of the tree, exported or not. It returns nil if the provider
+// does not exist or is request-scoped.
+func (app *App) Get(name core.Provide) interface{} {
+	dynMod, ok := app.Module.(*core.DynamicModule)
+	if !ok {
+		return app.Module.Ref(name)
+	}
+	module := findModule(dynMod, name)
+	if module == nil {
+		return nil
+	}
+	for _, p := range module.DataProviders {
+		if p.GetName() == name && p.GetScope() == core.Request {
+			return nil
+		}
+	}
+	return module.Ref(name)
+}
+
+// findModule returns the first module of the tree holding the provider.
+func findModule(module *core.DynamicModule, name core.Provide) *core.DynamicModule {
+	for _, p := range module.DataProviders {
+		if p.GetName() == name {
+			return module
+		}
+	}
+	for _, sub := range module.SubModules {
+		if found := findModule(sub, name); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func toReader(body interface{}) io.Reader {
+	switch b := body.(type) {
+	case nil:
+		return nil
+	case io.Reader:
+		return b
+	case string:
+		return strings.NewReader(b)
+	case []byte:
+		return bytes.NewReader(b)
+	default:
+		data, err := json.Marshal(b)
+		if err != nil {
+			panic(err)
+		}
+		return bytes.NewReader(data)
+	}
+}
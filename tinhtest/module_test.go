@@ -0,0 +1,132 @@
+package tinhtest_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tinh-tinh/tinhtinh/v2/core"
+	"github.com/tinh-tinh/tinhtinh/v2/tinhtest"
+)
+
+type repository interface {
+	Find() string
+}
+
+type realRepository struct{}
+
+func (realRepository) Find() string { return "real" }
+
+type mockRepository struct{ value string }
+
+func (m mockRepository) Find() string { return m.value }
+
+func appModule() core.Module {
+	repoModule := func(module core.Module) core.Module {
+		repo := module.New(core.NewModuleOptions{})
+		repo.NewProvider(core.ProviderOptions{
+			Name:  "repo",
+			Value: realRepository{},
+		})
+		repo.Export("repo")
+		return repo
+	}
+
+	tenant := func(module core.Module) core.Provider {
+		return module.NewProvider(core.ProviderOptions{
+			Name: "tenant",
+			Factory: func(param ...interface{}) interface{} {
+				return param[0].(*http.Request).Header.Get("x-tenant")
+			},
+			Inject: []core.Provide{core.REQUEST},
+		})
+	}
+
+	service := func(module core.Module) core.Provider {
+		return module.NewProvider(core.ProviderOptions{
+			Name: "service",
+			Factory: func(param ...interface{}) interface{} {
+				return "service:" + param[0].(repository).Find()
+			},
+			Inject: []core.Provide{"repo"},
+		})
+	}
+
+	controller := func(module core.Module) core.Controller {
+		ctrl := module.NewController("test")
+		ctrl.Guard(func(ctx core.Ctx) bool {
+			return ctx.Headers("x-tenant") != "blocked"
+		}).Interceptor(func(ctx core.Ctx) core.CallHandler {
+			return func(data any) any {
+				res := data.(core.Map)
+				res["intercepted"] = true
+				return res
+			}
+		}).Post("", func(ctx core.Ctx) error {
+			var body core.Map
+			if err := ctx.BodyParser(&body); err != nil {
+				return err
+			}
+			return ctx.JSON(core.Map{
+				"service": ctrl.Ref("service"),
+				"tenant":  ctrl.Ref("tenant", ctx),
+				"body":    body,
+			})
+		})
+		return ctrl
+	}
+
+	return core.NewModule(core.NewModuleOptions{
+		Imports:     []core.Modules{repoModule},
+		Providers:   []core.Providers{tenant, service},
+		Controllers: []core.Controllers{controller},
+	})
+}
+
+func Test_TestingModule(t *testing.T) {
+	app := tinhtest.CreateTestingModule(appModule).Compile()
+	app.SetGlobalPrefix("/api")
+
+	resp := app.Inject("POST", "/api/test", core.Map{"name": "abc"}, map[string]string{"x-tenant": "1"})
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	var res core.Map
+	require.Nil(t, json.Unmarshal(resp.Body.Bytes(), &res))
+	require.Equal(t, "service:real", res["service"])
+	require.Equal(t, "1", res["tenant"])
+	require.Equal(t, map[string]interface{}{"name": "abc"}, res["body"])
+	require.Equal(t, true, res["intercepted"])
+
+	resp = app.Inject("POST", "/api/test", nil, map[string]string{"x-tenant": "blocked"})
+	require.Equal(t, http.StatusForbidden, resp.Code)
+
+	require.Equal(t, "service:real", app.Get("service"))
+	require.Equal(t, realRepository{}, app.Get("repo"))
+	require.Nil(t, app.Get("tenant"))
+	require.Nil(t, app.Get("unknown"))
+}
+
+func Test_OverrideProvider(t *testing.T) {
+	app := tinhtest.CreateTestingModule(appModule).
+		OverrideProvider("repo").UseValue(mockRepository{value: "mock"}).
+		OverrideProvider("tenant").UseFactory(func(param ...interface{}) interface{} {
+		return "tenant-" + param[0].(*http.Request).Method
+	}, core.REQUEST).
+		Compile()
+
+	require.Equal(t, mockRepository{value: "mock"}, app.Get("repo"))
+	require.Equal(t, "service:mock", app.Get("service"))
+
+	resp := app.Inject("POST", "/test", `{"name":"abc"}`, nil)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	var res core.Map
+	require.Nil(t, json.Unmarshal(resp.Body.Bytes(), &res))
+	require.Equal(t, "service:mock", res["service"])
+	require.Equal(t, "tenant-POST", res["tenant"])
+
+	// Overrides do not leak to applications built afterwards.
+	real := tinhtest.CreateTestingModule(appModule).Compile()
+	require.Equal(t, "service:real", real.Get("service"))
+}
@@ -0,0 +1,307 @@
+package tinhtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// origin is the origin of the requests sent to a path. It uses https, as the
+// cookies set by the application are marked as Secure.
+const origin = "https://example.com"
+
+// Agent sends requests to an application and keeps the cookies it receives
+// between calls, so session and signed cookie flows can be tested.
+type Agent struct {
+	app *App
+	jar http.CookieJar
+}
+
+// Request creates an agent sending requests to the application. The requests
+// are sent to a path, over https, or to an absolute URL such as
+// "http://localhost/api/users". The cookies are sent back as a browser would,
+// according to their domain, path and Secure attribute.
+//
+//	tinhtest.Request(app).Post("/users").
+//		JSON(body).
+//		Expect(201).
+//		ExpectJSON("data.name", "abc").
+//		End(t)
+func Request(app *App) *Agent {
+	jar, _ := cookiejar.New(nil)
+	return &Agent{app: app, jar: jar}
+}
+
+// Get starts a GET request.
+func (a *Agent) Get(path string) *Test { return a.newTest(http.MethodGet, path) }
+
+// Post starts a POST request.
+func (a *Agent) Post(path string) *Test { return a.newTest(http.MethodPost, path) }
+
+// Put starts a PUT request.
+func (a *Agent) Put(path string) *Test { return a.newTest(http.MethodPut, path) }
+
+// Patch starts a PATCH request.
+func (a *Agent) Patch(path string) *Test { return a.newTest(http.MethodPatch, path) }
+
+// Delete starts a DELETE request.
+func (a *Agent) Delete(path string) *Test { return a.newTest(http.MethodDelete, path) }
+
+// Head starts a HEAD request.
+func (a *Agent) Head(path string) *Test { return a.newTest(http.MethodHead, path) }
+
+// Options starts an OPTIONS request.
+func (a *Agent) Options(path string) *Test { return a.newTest(http.MethodOptions, path) }
+
+func (a *Agent) newTest(method string, path string) *Test {
+	return &Test{
+		agent:  a,
+		method: method,
+		path:   path,
+		header: make(http.Header),
+	}
+}
+
+// Test is a request under construction with the expectations checked once it
+// is sent by End.
+type Test struct {
+	agent   *Agent
+	method  string
+	path    string
+	header  http.Header
+	body    []byte
+	expects []expectation
+}
+
+// expectation checks the response and returns a description of the failure,
+// or an empty string.
+type expectation func(res *httptest.ResponseRecorder) string
+
+// Header sets a header of the request.
+func (t *Test) Header(key string, value string) *Test {
+	t.header.Set(key, value)
+	return t
+}
+
+// Query adds a query parameter to the request.
+func (t *Test) Query(key string, value string) *Test {
+	sep := "?"
+	if strings.Contains(t.path, "?") {
+		sep = "&"
+	}
+	t.path += sep + url.QueryEscape(key) + "=" + url.QueryEscape(value)
+	return t
+}
+
+// JSON sets the body of the request to the JSON encoding of the value.
+func (t *Test) JSON(body interface{}) *Test {
+	data, err := json.Marshal(body)
+	if err != nil {
+		panic(err)
+	}
+	t.body = data
+	t.header.Set("Content-Type", "application/json")
+	return t
+}
+
+// Send sets the raw body of the request.
+func (t *Test) Send(body string) *Test {
+	t.body = []byte(body)
+	return t
+}
+
+// Expect expects the response to have the status code.
+func (t *Test) Expect(status int) *Test {
+	t.expects = append(t.expects, func(res *httptest.ResponseRecorder) string {
+		if res.Code != status {
+			return fmt.Sprintf("expected status %d, got %d", status, res.Code)
+		}
+		return ""
+	})
+	return t
+}
+
+// ExpectHeader expects the response to have the header with the value.
+func (t *Test) ExpectHeader(key string, value string) *Test {
+	t.expects = append(t.expects, func(res *httptest.ResponseRecorder) string {
+		if got := res.Header().Get(key); got != value {
+			return fmt.Sprintf("expected header %s to be %q, got %q", key, value, got)
+		}
+		return ""
+	})
+	return t
+}
+
+// ExpectBody expects the response body to be the string.
+func (t *Test) ExpectBody(body string) *Test {
+	t.expects = append(t.expects, func(res *httptest.ResponseRecorder) string {
+		if got := res.Body.String(); got != body {
+			return fmt.Sprintf("expected body %q, got %q", body, got)
+		}
+		return ""
+	})
+	return t
+}
+
+// ExpectJSON expects the value at the path of the JSON response body to equal
+// the value. The path is a dot separated list of object keys and array
+// indexes, such as "data.items.0.id". An empty path compares the whole body.
+func (t *Test) ExpectJSON(path string, value interface{}) *Test {
+	t.expects = append(t.expects, func(res *httptest.ResponseRecorder) string {
+		var body interface{}
+		if err := json.Unmarshal(res.Body.Bytes(), &body); err != nil {
+			return fmt.Sprintf("expected JSON body: %v", err)
+		}
+		got, err := lookupJSON(body, path)
+		if err != nil {
+			return fmt.Sprintf("expected JSON %s: %v", path, err)
+		}
+		want, err := normalizeJSON(value)
+		if err != nil {
+			return fmt.Sprintf("expected JSON %s: %v", path, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			return fmt.Sprintf("expected JSON %s to be %v, got %v", path, want, got)
+		}
+		return ""
+	})
+	return t
+}
+
+// End sends the request and checks the expectations. Each failed expectation
+// is reported to tb together with the request, the response and the route
+// that handled it. The recorded response is returned.
+func (t *Test) End(tb testing.TB) *httptest.ResponseRecorder {
+	tb.Helper()
+
+	target := t.path
+	if !strings.Contains(target, "://") {
+		target = origin + target
+	}
+	req := httptest.NewRequest(t.method, target, bytes.NewReader(t.body))
+	for k, v := range t.header {
+		req.Header[k] = v
+	}
+	jarURL := requestURL(req)
+	for _, c := range t.agent.jar.Cookies(jarURL) {
+		req.AddCookie(c)
+	}
+
+	res := t.agent.app.Do(req)
+	t.agent.jar.SetCookies(jarURL, res.Result().Cookies())
+
+	var failures []string
+	for _, expect := range t.expects {
+		if msg := expect(res); msg != "" {
+			failures = append(failures, msg)
+		}
+	}
+	if len(failures) > 0 {
+		tb.Errorf("%s\n\n%s", strings.Join(failures, "\n"), t.report(req, res))
+	}
+	return res
+}
+
+// requestURL returns the URL of the request, which the cookies are stored
+// for and matched against by their domain, path and Secure attribute.
+func requestURL(req *http.Request) *url.URL {
+	u := *req.URL
+	u.Scheme = "http"
+	if req.TLS != nil {
+		u.Scheme = "https"
+	}
+	u.Host = req.Host
+	return &u
+}
+
+// report describes the request, the response and the matched route.
+func (t *Test) report(req *http.Request, res *httptest.ResponseRecorder) string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "Request: %s %s\n", t.method, t.path)
+	writeHeader(&sb, req.Header)
+	if len(t.body) > 0 {
+		fmt.Fprintf(&sb, "%s\n", t.body)
+	}
+
+	fmt.Fprintf(&sb, "\nResponse: %d %s\n", res.Code, http.StatusText(res.Code))
+	writeHeader(&sb, res.Header())
+	if res.Body.Len() > 0 {
+		fmt.Fprintf(&sb, "%s\n", res.Body.String())
+	}
+
+	fmt.Fprintf(&sb, "\nRoute: %s", t.agent.app.matchRoute(req))
+	return sb.String()
+}
+
+func writeHeader(sb *strings.Builder, header http.Header) {
+	keys := make([]string, 0, len(header))
+	for k := range header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(sb, "  %s: %s\n", k, strings.Join(header[k], ", "))
+	}
+}
+
+// matchRoute describes the route the request was dispatched to.
+func (app *App) matchRoute(req *http.Request) string {
+	route, ok := app.MatchRoute(req)
+	if !ok {
+		return "no route matched"
+	}
+	desc := fmt.Sprintf("%s (controller %s", route.Pattern, route.Name)
+	if route.Version != "" {
+		desc += ", version " + route.Version
+	}
+	return desc + ")"
+}
+
+// lookupJSON returns the value at the dot separated path of the decoded body.
+func lookupJSON(body interface{}, path string) (interface{}, error) {
+	if path == "" {
+		return body, nil
+	}
+	current := body
+	for _, key := range strings.Split(path, ".") {
+		switch v := current.(type) {
+		case map[string]interface{}:
+			val, ok := v[key]
+			if !ok {
+				return nil, fmt.Errorf("key %q not found", key)
+			}
+			current = val
+		case []interface{}:
+			idx, err := strconv.Atoi(key)
+			if err != nil || idx < 0 || idx >= len(v) {
+				return nil, fmt.Errorf("index %q out of range", key)
+			}
+			current = v[idx]
+		default:
+			return nil, fmt.Errorf("cannot read %q of %v", key, current)
+		}
+	}
+	return current, nil
+}
+
+// normalizeJSON converts the value to the types produced by decoding JSON, so
+// an int can be compared with a decoded float64 for example.
+func normalizeJSON(value interface{}) (interface{}, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var normalized interface{}
+	err = json.Unmarshal(data, &normalized)
+	return normalized, err
+}
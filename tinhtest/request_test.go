@@ -0,0 +1,182 @@
+package tinhtest_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tinh-tinh/tinhtinh/v2/core"
+	"github.com/tinh-tinh/tinhtinh/v2/middleware/cookie"
+	"github.com/tinh-tinh/tinhtinh/v2/middleware/session"
+	"github.com/tinh-tinh/tinhtinh/v2/tinhtest"
+)
+
+// recordTB records the failures reported by the client.
+type recordTB struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recordTB) Helper() {}
+
+func (r *recordTB) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func sessionModule() core.Module {
+	controller := func(module core.Module) core.Controller {
+		ctrl := module.NewController("users")
+
+		ctrl.Post("", func(ctx core.Ctx) error {
+			var body core.Map
+			if err := ctx.BodyParser(&body); err != nil {
+				return err
+			}
+			ctx.Session("name", body["name"])
+			if _, err := ctx.SignedCookie("token", "secret-token"); err != nil {
+				return err
+			}
+			ctx.Res().Header().Set("X-Created", "true")
+			ctx.Status(http.StatusCreated)
+			return ctx.JSON(core.Map{
+				"data": core.Map{"name": body["name"], "roles": []string{"admin"}, "age": 20},
+			})
+		})
+
+		ctrl.Get("me", func(ctx core.Ctx) error {
+			token, err := ctx.SignedCookie("token")
+			if err != nil {
+				return err
+			}
+			return ctx.JSON(core.Map{
+				"name":  ctx.Session("name"),
+				"token": token,
+			})
+		})
+
+		ctrl.Version("2").Get("me", func(ctx core.Ctx) error {
+			return ctx.JSON(core.Map{"version": 2})
+		})
+
+		return ctrl
+	}
+
+	return core.NewModule(core.NewModuleOptions{
+		Controllers: []core.Controllers{controller},
+	})
+}
+
+func newSessionApp() *tinhtest.App {
+	app := tinhtest.CreateTestingModule(sessionModule, core.AppOptions{
+		Session: session.New(session.Options{Secret: "secret"}),
+	}).Compile()
+	app.SetGlobalPrefix("/api")
+	app.EnableVersioning(core.VersionOptions{
+		Type:   core.HeaderVersion,
+		Header: "X-Api-Version",
+	})
+	app.Use(cookie.Handler(cookie.Options{
+		Key: "abc&1*~#^2^#s0^=)^^7%b34",
+	}))
+	return app
+}
+
+func Test_Request(t *testing.T) {
+	agent := tinhtest.Request(newSessionApp())
+
+	agent.Post("/api/users").
+		JSON(core.Map{"name": "abc"}).
+		Header("X-Tenant", "1").
+		Expect(http.StatusCreated).
+		ExpectHeader("X-Created", "true").
+		ExpectJSON("data.name", "abc").
+		ExpectJSON("data.age", 20).
+		ExpectJSON("data.roles.0", "admin").
+		End(t)
+
+	res := agent.Get("/api/users/me").
+		Expect(http.StatusOK).
+		ExpectJSON("", core.Map{"name": "abc", "token": "secret-token"}).
+		End(t)
+	require.Contains(t, res.Body.String(), "secret-token")
+
+	// A new agent has its own cookie jar.
+	tinhtest.Request(newSessionApp()).Get("/api/users/me").
+		Expect(http.StatusInternalServerError).
+		End(t)
+}
+
+func Test_Request_Failure(t *testing.T) {
+	agent := tinhtest.Request(newSessionApp())
+
+	tb := &recordTB{}
+	agent.Get("/api/users/me").
+		Query("page", "1").
+		Header("X-Api-Version", "2").
+		Expect(http.StatusCreated).
+		ExpectHeader("X-Created", "true").
+		ExpectJSON("data.name", "abc").
+		ExpectBody("{}").
+		End(tb)
+
+	require.Len(t, tb.errors, 1)
+	msg := tb.errors[0]
+	require.Contains(t, msg, "expected status 201, got 200")
+	require.Contains(t, msg, `expected header X-Created to be "true", got ""`)
+	require.Contains(t, msg, `expected JSON data.name: key "data" not found`)
+	require.Contains(t, msg, "expected body")
+	require.Contains(t, msg, "Request: GET /api/users/me?page=1")
+	require.Contains(t, msg, "X-Api-Version: 2")
+	require.Contains(t, msg, "Response: 200 OK")
+	require.Contains(t, msg, "Route: GET /api/users/me (controller users, version 2)")
+
+	tb = &recordTB{}
+	agent.Get("/api/users/me").Expect(http.StatusCreated).End(tb)
+	require.Len(t, tb.errors, 1)
+	require.Contains(t, tb.errors[0], "Route: GET /api/users/me (controller users)")
+	require.NotContains(t, tb.errors[0], "version 2")
+
+	tb = &recordTB{}
+	agent.Get("/api/unknown").Expect(http.StatusOK).End(tb)
+	require.Len(t, tb.errors, 1)
+	require.Contains(t, tb.errors[0], "Route: no route matched")
+}
+
+func Test_Request_Cookies(t *testing.T) {
+	controller := func(module core.Module) core.Controller {
+		ctrl := module.NewController("cookies")
+
+		ctrl.Post("", func(ctx core.Ctx) error {
+			http.SetCookie(ctx.Res(), &http.Cookie{Name: "admin", Value: "1", Path: "/api/cookies/admin"})
+			http.SetCookie(ctx.Res(), &http.Cookie{Name: "secure", Value: "1", Path: "/", Secure: true})
+			return ctx.JSON(core.Map{})
+		})
+
+		ctrl.Get("admin", func(ctx core.Ctx) error {
+			return ctx.JSON(core.Map{"cookies": len(ctx.Req().Cookies())})
+		})
+
+		ctrl.Get("", func(ctx core.Ctx) error {
+			return ctx.JSON(core.Map{"cookies": len(ctx.Req().Cookies())})
+		})
+
+		return ctrl
+	}
+
+	app := tinhtest.CreateTestingModule(func() core.Module {
+		return core.NewModule(core.NewModuleOptions{
+			Controllers: []core.Controllers{controller},
+		})
+	}).Compile()
+	app.SetGlobalPrefix("/api")
+
+	agent := tinhtest.Request(app)
+	agent.Post("/api/cookies").Expect(http.StatusOK).End(t)
+
+	agent.Get("/api/cookies/admin").ExpectJSON("cookies", 2).End(t)
+	agent.Get("/api/cookies").ExpectJSON("cookies", 1).End(t)
+	// The Secure cookies are not sent without TLS.
+	agent.Get("http://example.com/api/cookies/admin").ExpectJSON("cookies", 1).End(t)
+	agent.Get("http://example.com/api/cookies").ExpectJSON("cookies", 0).End(t)
+}
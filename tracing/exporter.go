@@ -0,0 +1,101 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Exporter sends the ended spans to a backend. The tracer calls ExportSpans
+// with one batch at a time.
+type Exporter interface {
+	ExportSpans(ctx context.Context, spans []SpanData) error
+	Shutdown(ctx context.Context) error
+}
+
+// jsonSpan is a span written by the JSON exporter.
+type jsonSpan struct {
+	Service      string         `json:"service"`
+	Name         string         `json:"name"`
+	Kind         SpanKind       `json:"kind"`
+	TraceID      TraceID        `json:"traceId"`
+	SpanID       SpanID         `json:"spanId"`
+	ParentSpanID string         `json:"parentSpanId,omitempty"`
+	TraceState   string         `json:"traceState,omitempty"`
+	Start        time.Time      `json:"start"`
+	End          time.Time      `json:"end"`
+	Duration     string         `json:"duration"`
+	Attributes   map[string]any `json:"attributes,omitempty"`
+	Error        string         `json:"error,omitempty"`
+}
+
+type jsonExporter struct {
+	mu     sync.Mutex
+	w      io.Writer
+	closer io.Closer
+}
+
+// NewJSONExporter creates an exporter writing each span to w as a line of
+// JSON.
+func NewJSONExporter(w io.Writer) Exporter {
+	return &jsonExporter{w: w}
+}
+
+// NewStdoutExporter creates an exporter writing each span to stdout as a line
+// of JSON.
+func NewStdoutExporter() Exporter {
+	return NewJSONExporter(os.Stdout)
+}
+
+// NewFileExporter creates an exporter appending each span to the file as a
+// line of JSON. The file is created if needed and closed by Shutdown.
+func NewFileExporter(path string) (Exporter, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &jsonExporter{w: file, closer: file}, nil
+}
+
+func (e *jsonExporter) ExportSpans(ctx context.Context, spans []SpanData) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	encoder := json.NewEncoder(e.w)
+	for _, span := range spans {
+		line := jsonSpan{
+			Service:    span.Service,
+			Name:       span.Name,
+			Kind:       span.Kind,
+			TraceID:    span.SpanContext.TraceID,
+			SpanID:     span.SpanContext.SpanID,
+			TraceState: span.SpanContext.TraceState,
+			Start:      span.Start,
+			End:        span.End,
+			Duration:   span.End.Sub(span.Start).String(),
+			Attributes: span.Attributes,
+			Error:      span.Error,
+		}
+		if span.ParentSpanID.IsValid() {
+			line.ParentSpanID = span.ParentSpanID.String()
+		}
+		if err := encoder.Encode(line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *jsonExporter) Shutdown(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.closer == nil {
+		return nil
+	}
+	err := e.closer.Close()
+	e.closer = nil
+	return err
+}
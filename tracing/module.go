@@ -0,0 +1,151 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/tinh-tinh/tinhtinh/v2/core"
+	"github.com/tinh-tinh/tinhtinh/v2/internal/instrument"
+)
+
+// TRACER is the name of the provider of the *Tracer. The tracing module is
+// global, so the tracer can be injected in every module.
+const TRACER core.Provide = "TRACER"
+
+// instrumentation is the name of the provider instrumenting the application.
+const instrumentation core.Provide = "TRACING_INSTRUMENTATION"
+
+// shutdownTimeout is the time the last spans have to be exported when the
+// application shuts down.
+const shutdownTimeout = 5 * time.Second
+
+// HandlerObservable is implemented by the providers running handlers outside
+// of the HTTP routes, such as the store of the microservices package. The
+// observer is called with the context of the message when a handler starts.
+// It returns the context the handler runs with and the function called when
+// the handler returned.
+type HandlerObservable interface {
+	AddContextObserver(observer func(ctx context.Context, kind string, name string) (context.Context, func(err error)))
+}
+
+// Module creates a global module tracing the application with a tracer
+// created from the options. The tracer is shut down, exporting its last
+// spans, before the application shuts down.
+//
+// Every HTTP request is a server span named after the method and the pattern
+// of its route, child of the traceparent header when the request has one.
+// The middlewares, guards, pipes and handlers serving it are child spans.
+// The handlers of the microservices are server spans for the RPC and
+// consumer spans for the events.
+func Module(opt Options) core.Modules {
+	return func(module core.Module) core.Module {
+		tracer := NewTracer(opt)
+
+		tracingModule := module.New(core.NewModuleOptions{Global: true})
+		tracingModule.NewProvider(core.ProviderOptions{
+			Name:  TRACER,
+			Value: tracer,
+		})
+		tracingModule.Export(TRACER)
+		tracingModule.NewProvider(core.ProviderOptions{
+			Name:  instrumentation,
+			Value: &instrumenter{module: tracingModule, tracer: tracer},
+		})
+		return tracingModule
+	}
+}
+
+// instrumenter starts the spans of the HTTP requests and of the handlers.
+type instrumenter struct {
+	module core.Module
+	tracer *Tracer
+}
+
+// OnApplicationBootstrap installs the HTTP middleware and the stage observer
+// on the App, and the observer on the providers running handlers.
+func (i *instrumenter) OnApplicationBootstrap() error {
+	instrument.Bootstrap(i.module, func(app *core.App) {
+		app.Use(i.middleware(app))
+		app.ObserveStages(i.observeStage)
+	}, func(value interface{}) {
+		if observable, ok := value.(HandlerObservable); ok {
+			observable.AddContextObserver(i.observeHandler)
+		}
+	})
+	return nil
+}
+
+// BeforeApplicationShutdown exports the last spans then shuts the exporter
+// down.
+func (i *instrumenter) BeforeApplicationShutdown(signal string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	return i.tracer.Shutdown(ctx)
+}
+
+// middleware starts the span of the requests served by the App.
+func (i *instrumenter) middleware(app *core.App) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := Extract(r.Context(), HeaderCarrier(r.Header))
+
+			name := r.Method
+			path, _, ok := instrument.Route(app, r)
+			if ok {
+				name += " " + path
+			}
+			ctx, span := i.tracer.Start(ctx, name, KindServer)
+			defer span.End()
+			span.SetAttribute("http.request.method", r.Method)
+			span.SetAttribute("url.path", r.URL.Path)
+			if path != "" {
+				span.SetAttribute("http.route", path)
+			}
+			if agent := r.UserAgent(); agent != "" {
+				span.SetAttribute("user_agent.original", agent)
+			}
+
+			rec := instrument.NewStatusRecorder(w)
+			next.ServeHTTP(rec, r.WithContext(ctx))
+
+			status := rec.Status()
+			span.SetAttribute("http.response.status_code", status)
+			if status >= http.StatusInternalServerError {
+				span.RecordError(errStatus(status))
+			}
+		})
+	}
+}
+
+// observeStage starts the span of a stage of a route.
+func (i *instrumenter) observeStage(ctx context.Context, stage core.Stage, name string) (context.Context, func(err error)) {
+	ctx, span := i.tracer.Start(ctx, string(stage)+" "+name, KindInternal)
+	span.SetAttribute("tinhtinh.stage", string(stage))
+	return ctx, func(err error) {
+		span.RecordError(err)
+		span.End()
+	}
+}
+
+// observeHandler starts the span of a handler of the microservices.
+func (i *instrumenter) observeHandler(ctx context.Context, kind string, name string) (context.Context, func(err error)) {
+	spanKind := KindConsumer
+	if kind == "rpc" {
+		spanKind = KindServer
+	}
+	ctx, span := i.tracer.Start(ctx, kind+" "+name, spanKind)
+	span.SetAttribute("messaging.operation", kind)
+	span.SetAttribute("messaging.destination.name", name)
+	return ctx, func(err error) {
+		span.RecordError(err)
+		span.End()
+	}
+}
+
+// errStatus is the error of a request answered with a server error.
+type errStatus int
+
+func (e errStatus) Error() string {
+	return http.StatusText(int(e))
+}
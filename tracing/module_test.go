@@ -0,0 +1,101 @@
+package tracing_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tinh-tinh/tinhtinh/v2/core"
+	"github.com/tinh-tinh/tinhtinh/v2/tracing"
+)
+
+func authGuard(ctx core.Ctx) bool {
+	return ctx.Headers("Authorization") != ""
+}
+
+func Test_Module(t *testing.T) {
+	exporter := &memoryExporter{}
+
+	userController := func(module core.Module) core.Controller {
+		ctrl := module.NewController("users")
+		ctrl.Guard(authGuard).Get("{id}", func(ctx core.Ctx) error {
+			_, span := tracing.Start(ctx.Req().Context(), "load user", tracing.KindClient)
+			span.End()
+			return ctx.JSON(core.Map{"data": ctx.Path("id")})
+		})
+		ctrl.Get("", func(ctx core.Ctx) error {
+			return ctx.Status(http.StatusInternalServerError).JSON(core.Map{"error": "failed"})
+		})
+		return ctrl
+	}
+
+	appModule := func() core.Module {
+		return core.NewModule(core.NewModuleOptions{
+			Imports: []core.Modules{tracing.Module(tracing.Options{
+				ServiceName: "users",
+				Exporter:    exporter,
+			})},
+			Controllers: []core.Controllers{userController},
+		})
+	}
+
+	app := core.CreateFactory(appModule)
+	app.SetGlobalPrefix("api")
+
+	testServer := httptest.NewServer(app.PrepareBeforeListen())
+	defer testServer.Close()
+
+	req, err := http.NewRequest(http.MethodGet, testServer.URL+"/api/users/42", nil)
+	require.Nil(t, err)
+	req.Header.Set("Authorization", "Bearer token")
+	req.Header.Set("traceparent", traceparent)
+	req.Header.Set("tracestate", "vendor=a")
+	resp, err := http.DefaultClient.Do(req)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err = http.Get(testServer.URL + "/api/users")
+	require.Nil(t, err)
+	require.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+
+	tracer := app.Module.Ref(tracing.TRACER).(*tracing.Tracer)
+	require.Nil(t, tracer.ForceFlush(context.Background()))
+
+	remote, err := tracing.ParseTraceparent(traceparent)
+	require.Nil(t, err)
+
+	server := exporter.get("GET /api/users/{id}")
+	require.Equal(t, "users", server.Service)
+	require.Equal(t, tracing.KindServer, server.Kind)
+	require.Equal(t, remote.TraceID, server.SpanContext.TraceID)
+	require.Equal(t, remote.SpanID, server.ParentSpanID)
+	require.Equal(t, "vendor=a", server.SpanContext.TraceState)
+	require.Equal(t, "/api/users/{id}", server.Attributes["http.route"])
+	require.Equal(t, 200, server.Attributes["http.response.status_code"])
+
+	guard := exporter.get("guard github.com/tinh-tinh/tinhtinh/v2/tracing_test.authGuard")
+	require.Equal(t, server.SpanContext.SpanID, guard.ParentSpanID)
+	require.Equal(t, "guard", guard.Attributes["tinhtinh.stage"])
+
+	handler := exporter.get("handler GET /api/users/{id}")
+	require.Equal(t, server.SpanContext.SpanID, handler.ParentSpanID)
+
+	load := exporter.get("load user")
+	require.Equal(t, remote.TraceID, load.SpanContext.TraceID)
+	require.Equal(t, handler.SpanContext.SpanID, load.ParentSpanID)
+
+	failed := exporter.get("GET /api/users")
+	require.NotEqual(t, remote.TraceID, failed.SpanContext.TraceID)
+	require.False(t, failed.ParentSpanID.IsValid())
+	require.Equal(t, "Internal Server Error", failed.Error)
+
+	unmatched := exporter.len()
+	resp, err = http.Get(testServer.URL + "/api/unknown")
+	require.Nil(t, err)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	require.Nil(t, tracer.ForceFlush(context.Background()))
+	require.Equal(t, unmatched+1, exporter.len())
+	require.Equal(t, "GET", exporter.get("GET").Name)
+}
@@ -0,0 +1,210 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// DefaultOTLPEndpoint is the traces endpoint of a collector running locally.
+const DefaultOTLPEndpoint = "http://localhost:4318/v1/traces"
+
+// scopeName names the instrumentation in the OTLP payloads.
+const scopeName = "github.com/tinh-tinh/tinhtinh/v2/tracing"
+
+type OTLPOptions struct {
+	// Endpoint is the URL the spans are posted to. Default is
+	// DefaultOTLPEndpoint.
+	Endpoint string
+	// Headers are added to the requests, for example to authenticate.
+	Headers map[string]string
+	// Timeout of each request. Default is 10 seconds.
+	Timeout time.Duration
+	// Client sending the requests. Default is a client with the timeout.
+	Client *http.Client
+}
+
+type otlpExporter struct {
+	opt OTLPOptions
+}
+
+// NewOTLPExporter creates an exporter posting the spans to an OpenTelemetry
+// collector with OTLP over HTTP, encoded in JSON.
+func NewOTLPExporter(opt OTLPOptions) Exporter {
+	if opt.Endpoint == "" {
+		opt.Endpoint = DefaultOTLPEndpoint
+	}
+	if opt.Timeout <= 0 {
+		opt.Timeout = 10 * time.Second
+	}
+	if opt.Client == nil {
+		opt.Client = &http.Client{Timeout: opt.Timeout}
+	}
+	return &otlpExporter{opt: opt}
+}
+
+func (e *otlpExporter) ExportSpans(ctx context.Context, spans []SpanData) error {
+	if len(spans) == 0 {
+		return nil
+	}
+	body, err := json.Marshal(encodeOTLP(spans))
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, e.opt.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.opt.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range e.opt.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := e.opt.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("otlp: %s responded %d: %s", e.opt.Endpoint, resp.StatusCode, bytes.TrimSpace(msg))
+	}
+	_, err = io.Copy(io.Discard, resp.Body)
+	return err
+}
+
+func (e *otlpExporter) Shutdown(ctx context.Context) error {
+	e.opt.Client.CloseIdleConnections()
+	return nil
+}
+
+// The types below follow the JSON encoding of the OTLP trace service request.
+type (
+	otlpRequest struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+	otlpResourceSpans struct {
+		Resource   otlpResource     `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+	}
+	otlpResource struct {
+		Attributes []otlpAttribute `json:"attributes"`
+	}
+	otlpScopeSpans struct {
+		Scope otlpScope  `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+	otlpScope struct {
+		Name string `json:"name"`
+	}
+	otlpSpan struct {
+		TraceID           string          `json:"traceId"`
+		SpanID            string          `json:"spanId"`
+		ParentSpanID      string          `json:"parentSpanId,omitempty"`
+		TraceState        string          `json:"traceState,omitempty"`
+		Name              string          `json:"name"`
+		Kind              int             `json:"kind"`
+		StartTimeUnixNano string          `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+		Attributes        []otlpAttribute `json:"attributes,omitempty"`
+		Status            otlpStatus      `json:"status"`
+	}
+	otlpAttribute struct {
+		Key   string    `json:"key"`
+		Value otlpValue `json:"value"`
+	}
+	otlpValue struct {
+		StringValue *string  `json:"stringValue,omitempty"`
+		BoolValue   *bool    `json:"boolValue,omitempty"`
+		IntValue    *string  `json:"intValue,omitempty"`
+		DoubleValue *float64 `json:"doubleValue,omitempty"`
+	}
+	otlpStatus struct {
+		Code    int    `json:"code,omitempty"`
+		Message string `json:"message,omitempty"`
+	}
+)
+
+// otlpStatusError is the code of the status of a failed span.
+const otlpStatusError = 2
+
+// encodeOTLP groups the spans by service into an OTLP request.
+func encodeOTLP(spans []SpanData) otlpRequest {
+	var req otlpRequest
+	index := make(map[string]int)
+	for _, span := range spans {
+		i, ok := index[span.Service]
+		if !ok {
+			i = len(req.ResourceSpans)
+			index[span.Service] = i
+			req.ResourceSpans = append(req.ResourceSpans, otlpResourceSpans{
+				Resource: otlpResource{Attributes: []otlpAttribute{
+					{Key: "service.name", Value: otlpAttributeValue(span.Service)},
+				}},
+				ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: scopeName}}},
+			})
+		}
+		scope := &req.ResourceSpans[i].ScopeSpans[0]
+		scope.Spans = append(scope.Spans, encodeOTLPSpan(span))
+	}
+	return req
+}
+
+func encodeOTLPSpan(span SpanData) otlpSpan {
+	s := otlpSpan{
+		TraceID:           span.SpanContext.TraceID.String(),
+		SpanID:            span.SpanContext.SpanID.String(),
+		TraceState:        span.SpanContext.TraceState,
+		Name:              span.Name,
+		Kind:              int(span.Kind),
+		StartTimeUnixNano: strconv.FormatInt(span.Start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(span.End.UnixNano(), 10),
+	}
+	if span.ParentSpanID.IsValid() {
+		s.ParentSpanID = span.ParentSpanID.String()
+	}
+	for _, key := range sortedKeys(span.Attributes) {
+		s.Attributes = append(s.Attributes, otlpAttribute{Key: key, Value: otlpAttributeValue(span.Attributes[key])})
+	}
+	if span.Error != "" {
+		s.Status = otlpStatus{Code: otlpStatusError, Message: span.Error}
+	}
+	return s
+}
+
+func otlpAttributeValue(value any) otlpValue {
+	switch v := value.(type) {
+	case string:
+		return otlpValue{StringValue: &v}
+	case bool:
+		return otlpValue{BoolValue: &v}
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		s := fmt.Sprint(v)
+		return otlpValue{IntValue: &s}
+	case float32:
+		f := float64(v)
+		return otlpValue{DoubleValue: &f}
+	case float64:
+		return otlpValue{DoubleValue: &v}
+	}
+	s := fmt.Sprint(value)
+	return otlpValue{StringValue: &s}
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
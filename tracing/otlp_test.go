@@ -0,0 +1,100 @@
+package tracing_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tinh-tinh/tinhtinh/v2/tracing"
+)
+
+func Test_OTLPExporter(t *testing.T) {
+	var received map[string]any
+	var header http.Header
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/v1/traces", r.URL.Path)
+		header = r.Header
+		require.Nil(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer collector.Close()
+
+	tracer := tracing.NewTracer(tracing.Options{
+		ServiceName: "orders",
+		Exporter: tracing.NewOTLPExporter(tracing.OTLPOptions{
+			Endpoint: collector.URL + "/v1/traces",
+			Headers:  map[string]string{"Authorization": "Bearer token"},
+		}),
+	})
+	ctx, root := tracer.Start(context.Background(), "GET /orders", tracing.KindServer)
+	root.SetAttribute("http.response.status_code", 500)
+	root.SetAttribute("cached", false)
+	root.SetAttribute("ratio", 0.5)
+	root.RecordError(errors.New("Internal Server Error"))
+	_, child := tracer.Start(ctx, "handler", tracing.KindInternal)
+	child.End()
+	root.End()
+	require.Nil(t, tracer.ForceFlush(context.Background()))
+
+	require.Equal(t, "application/json", header.Get("Content-Type"))
+	require.Equal(t, "Bearer token", header.Get("Authorization"))
+
+	resourceSpans := received["resourceSpans"].([]any)
+	require.Len(t, resourceSpans, 1)
+	resource := resourceSpans[0].(map[string]any)
+	require.Equal(t, []any{map[string]any{
+		"key":   "service.name",
+		"value": map[string]any{"stringValue": "orders"},
+	}}, resource["resource"].(map[string]any)["attributes"])
+
+	scope := resource["scopeSpans"].([]any)[0].(map[string]any)
+	spans := scope["spans"].([]any)
+	require.Len(t, spans, 2)
+
+	handler := spans[0].(map[string]any)
+	require.Equal(t, "handler", handler["name"])
+	require.Equal(t, float64(1), handler["kind"])
+	require.Equal(t, root.SpanContext().SpanID.String(), handler["parentSpanId"])
+	require.Equal(t, root.SpanContext().TraceID.String(), handler["traceId"])
+
+	server := spans[1].(map[string]any)
+	require.Equal(t, float64(2), server["kind"])
+	require.NotContains(t, server, "parentSpanId")
+	require.Equal(t, map[string]any{"code": float64(2), "message": "Internal Server Error"}, server["status"])
+	require.Equal(t, []any{
+		map[string]any{"key": "cached", "value": map[string]any{"boolValue": false}},
+		map[string]any{"key": "http.response.status_code", "value": map[string]any{"intValue": "500"}},
+		map[string]any{"key": "ratio", "value": map[string]any{"doubleValue": 0.5}},
+	}, server["attributes"])
+	require.NotEmpty(t, server["startTimeUnixNano"])
+
+	require.Nil(t, tracer.Shutdown(context.Background()))
+}
+
+func Test_OTLPExporter_Error(t *testing.T) {
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "quota exceeded", http.StatusTooManyRequests)
+	}))
+	defer collector.Close()
+
+	exporter := tracing.NewOTLPExporter(tracing.OTLPOptions{Endpoint: collector.URL})
+	err := exporter.ExportSpans(context.Background(), []tracing.SpanData{{Name: "span"}})
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "429")
+	require.Contains(t, err.Error(), "quota exceeded")
+
+	errs := make(chan error, 1)
+	tracer := tracing.NewTracer(tracing.Options{
+		Exporter:     exporter,
+		BatchSize:    1,
+		ErrorHandler: func(err error) { errs <- err },
+	})
+	_, span := tracer.Start(context.Background(), "span", tracing.KindInternal)
+	span.End()
+	require.Contains(t, (<-errs).Error(), "429")
+	tracer.Shutdown(context.Background())
+}
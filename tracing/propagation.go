@@ -0,0 +1,102 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// maxTracestate is the length above which a tracestate header is dropped.
+const maxTracestate = 512
+
+// Carrier holds the headers the span context is propagated with.
+type Carrier interface {
+	Get(key string) string
+	Set(key string, value string)
+}
+
+// HeaderCarrier adapts the headers of an HTTP request or response.
+type HeaderCarrier http.Header
+
+// Get returns the values of the header joined by commas, as several
+// tracestate headers form a single list.
+func (h HeaderCarrier) Get(key string) string {
+	return strings.Join(http.Header(h).Values(key), ",")
+}
+
+func (h HeaderCarrier) Set(key string, value string) {
+	http.Header(h).Set(key, value)
+}
+
+// MapCarrier adapts headers held in a map, such as the headers of the
+// messages of the microservices.
+type MapCarrier map[string]string
+
+func (m MapCarrier) Get(key string) string {
+	return m[key]
+}
+
+func (m MapCarrier) Set(key string, value string) {
+	m[key] = value
+}
+
+type spanKey struct{}
+
+type remoteKey struct{}
+
+// ContextWithSpan returns a copy of the context holding the span.
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// SpanFromContext returns the span of the context, or nil.
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// ContextWithRemoteSpanContext returns a copy of the context holding a span
+// context received from another service.
+func ContextWithRemoteSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	sc.Remote = true
+	return context.WithValue(ctx, remoteKey{}, sc)
+}
+
+// SpanContextFromContext returns the span context of the span of the context,
+// or the remote span context it holds. The span context is invalid when the
+// context has neither.
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	if span := SpanFromContext(ctx); span != nil {
+		return span.SpanContext()
+	}
+	sc, _ := ctx.Value(remoteKey{}).(SpanContext)
+	return sc
+}
+
+// Extract returns a copy of the context holding the span context of the
+// traceparent and tracestate headers of the carrier. The context is returned
+// unchanged when the traceparent is missing or invalid.
+func Extract(ctx context.Context, carrier Carrier) context.Context {
+	sc, err := ParseTraceparent(carrier.Get(TraceparentHeader))
+	if err != nil {
+		return ctx
+	}
+	if state := strings.TrimSpace(carrier.Get(TracestateHeader)); len(state) <= maxTracestate {
+		sc.TraceState = state
+	}
+	return ContextWithRemoteSpanContext(ctx, sc)
+}
+
+// Inject sets the traceparent and tracestate headers of the carrier from the
+// span context of the context. Nothing is set when the context has no valid
+// span context.
+func Inject(ctx context.Context, carrier Carrier) {
+	sc := SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return
+	}
+	carrier.Set(TraceparentHeader, sc.Traceparent())
+	if sc.TraceState != "" {
+		carrier.Set(TracestateHeader, sc.TraceState)
+	}
+}
@@ -0,0 +1,136 @@
+// Package tracing traces the requests of the application and propagates the
+// traces to the other services with the W3C Trace Context headers,
+// traceparent and tracestate.
+//
+// The module starts a span for every HTTP request, continuing the trace of the
+// traceparent header, and a span for each middleware, guard, pipe and handler
+// serving it. The microservices package propagates the trace in the headers
+// of the messages, so the handlers of the other services continue it. The
+// ended spans are exported in batches through an Exporter: JSON lines written
+// to stdout or to a file, or OTLP over HTTP to a collector.
+package tracing
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// TraceparentHeader is the header identifying the parent span of a
+	// request.
+	TraceparentHeader = "traceparent"
+	// TracestateHeader is the header carrying the vendor specific data of a
+	// trace.
+	TracestateHeader = "tracestate"
+)
+
+// TraceID identifies a trace.
+type TraceID [16]byte
+
+// IsValid reports whether the trace ID is not all zeros.
+func (t TraceID) IsValid() bool {
+	return t != TraceID{}
+}
+
+func (t TraceID) String() string {
+	return hex.EncodeToString(t[:])
+}
+
+func (t TraceID) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// SpanID identifies a span in a trace.
+type SpanID [8]byte
+
+// IsValid reports whether the span ID is not all zeros.
+func (s SpanID) IsValid() bool {
+	return s != SpanID{}
+}
+
+func (s SpanID) String() string {
+	return hex.EncodeToString(s[:])
+}
+
+func (s SpanID) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// SpanContext is the part of a span propagated to the other services.
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	// Sampled reports whether the spans of the trace are recorded.
+	Sampled bool
+	// TraceState is the value of the tracestate header, passed on unchanged.
+	TraceState string
+	// Remote reports whether the span context was received from another
+	// service.
+	Remote bool
+}
+
+// IsValid reports whether the trace ID and the span ID are set.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// Traceparent formats the span context as a traceparent header.
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return "00-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-" + flags
+}
+
+var errTraceparent = errors.New("invalid traceparent")
+
+// ParseTraceparent parses a traceparent header. Headers of a version above 00
+// are accepted when they start with the fields of version 00.
+func ParseTraceparent(value string) (SpanContext, error) {
+	value = strings.TrimSpace(value)
+	if len(value) < 55 || (len(value) > 55 && value[55] != '-') {
+		return SpanContext{}, errTraceparent
+	}
+	if value[2] != '-' || value[35] != '-' || value[52] != '-' {
+		return SpanContext{}, errTraceparent
+	}
+
+	version, err := decodeHex(value[0:2])
+	if err != nil || version[0] == 0xff || (version[0] == 0 && len(value) != 55) {
+		return SpanContext{}, errTraceparent
+	}
+
+	var sc SpanContext
+	traceID, err := decodeHex(value[3:35])
+	if err != nil {
+		return SpanContext{}, errTraceparent
+	}
+	copy(sc.TraceID[:], traceID)
+	spanID, err := decodeHex(value[36:52])
+	if err != nil {
+		return SpanContext{}, errTraceparent
+	}
+	copy(sc.SpanID[:], spanID)
+	flags, err := decodeHex(value[53:55])
+	if err != nil {
+		return SpanContext{}, errTraceparent
+	}
+	sc.Sampled = flags[0]&0x01 == 1
+
+	if !sc.IsValid() {
+		return SpanContext{}, fmt.Errorf("%w: zero trace or span ID", errTraceparent)
+	}
+	return sc, nil
+}
+
+// decodeHex decodes lowercase hexadecimal, the only case allowed by the
+// specification.
+func decodeHex(s string) ([]byte, error) {
+	if strings.ToLower(s) != s {
+		return nil, errTraceparent
+	}
+	return hex.DecodeString(s)
+}
@@ -0,0 +1,66 @@
+package tracing_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tinh-tinh/tinhtinh/v2/tracing"
+)
+
+const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func Test_ParseTraceparent(t *testing.T) {
+	sc, err := tracing.ParseTraceparent(traceparent)
+	require.Nil(t, err)
+	require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", sc.TraceID.String())
+	require.Equal(t, "00f067aa0ba902b7", sc.SpanID.String())
+	require.True(t, sc.Sampled)
+	require.Equal(t, traceparent, sc.Traceparent())
+
+	sc, err = tracing.ParseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-extra")
+	require.Nil(t, err)
+	require.False(t, sc.Sampled)
+
+	for _, value := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736_00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e47zz-00f067aa0ba902b7-01",
+	} {
+		_, err := tracing.ParseTraceparent(value)
+		require.NotNil(t, err, value)
+	}
+}
+
+func Test_Propagation(t *testing.T) {
+	header := http.Header{}
+	header.Set("traceparent", traceparent)
+	header.Add("tracestate", "vendor1=a")
+	header.Add("tracestate", "vendor2=b")
+
+	ctx := tracing.Extract(context.Background(), tracing.HeaderCarrier(header))
+	sc := tracing.SpanContextFromContext(ctx)
+	require.True(t, sc.IsValid())
+	require.True(t, sc.Remote)
+	require.Equal(t, "vendor1=a,vendor2=b", sc.TraceState)
+	require.Nil(t, tracing.SpanFromContext(ctx))
+
+	carrier := tracing.MapCarrier{}
+	tracing.Inject(ctx, carrier)
+	require.Equal(t, traceparent, carrier["traceparent"])
+	require.Equal(t, "vendor1=a,vendor2=b", carrier["tracestate"])
+
+	header.Set("traceparent", "invalid")
+	ctx = tracing.Extract(context.Background(), tracing.HeaderCarrier(header))
+	require.False(t, tracing.SpanContextFromContext(ctx).IsValid())
+
+	carrier = tracing.MapCarrier{}
+	tracing.Inject(ctx, carrier)
+	require.Empty(t, carrier)
+}
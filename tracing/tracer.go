@@ -0,0 +1,344 @@
+package tracing
+
+import (
+	"context"
+	"encoding/binary"
+	"log"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultServiceName names the service of the spans when none is given.
+	DefaultServiceName = "tinhtinh"
+	// DefaultBatchSize is the number of spans exported at once.
+	DefaultBatchSize = 512
+	// DefaultBatchTimeout is the longest time an ended span waits before it
+	// is exported.
+	DefaultBatchTimeout = 5 * time.Second
+)
+
+// SpanKind describes the relationship of a span with the other spans of the
+// trace. The values are those of OTLP.
+type SpanKind int
+
+const (
+	KindInternal SpanKind = iota + 1
+	KindServer
+	KindClient
+	KindProducer
+	KindConsumer
+)
+
+func (k SpanKind) String() string {
+	switch k {
+	case KindServer:
+		return "server"
+	case KindClient:
+		return "client"
+	case KindProducer:
+		return "producer"
+	case KindConsumer:
+		return "consumer"
+	}
+	return "internal"
+}
+
+func (k SpanKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// SpanData is an ended span, as received by the exporters.
+type SpanData struct {
+	Service      string
+	Name         string
+	Kind         SpanKind
+	SpanContext  SpanContext
+	ParentSpanID SpanID
+	Start        time.Time
+	End          time.Time
+	Attributes   map[string]any
+	// Error is the message of the error the span ended with, if any.
+	Error string
+}
+
+type Options struct {
+	// ServiceName names the application in the exported spans. Default is
+	// "tinhtinh".
+	ServiceName string
+	// Exporter receiving the ended spans. Default writes them as JSON lines
+	// to stdout.
+	Exporter Exporter
+	// BatchSize is the number of spans exported at once. Default is
+	// DefaultBatchSize. Four batches are queued at most, the spans ending
+	// when the queue is full are dropped.
+	BatchSize int
+	// BatchTimeout is the longest time an ended span waits before it is
+	// exported. Default is DefaultBatchTimeout.
+	BatchTimeout time.Duration
+	// ErrorHandler receives the errors of the exporter. Default logs them.
+	ErrorHandler func(err error)
+}
+
+// Tracer starts spans and exports them in batches once they ended.
+type Tracer struct {
+	opt Options
+
+	mu     sync.Mutex
+	queue  []SpanData
+	closed bool
+
+	exportMu sync.Mutex
+	flush    chan struct{}
+	stop     chan struct{}
+	stopped  chan struct{}
+}
+
+// NewTracer creates a tracer and starts exporting its spans in the
+// background. Shutdown must be called to export the last spans.
+func NewTracer(opt Options) *Tracer {
+	if opt.ServiceName == "" {
+		opt.ServiceName = DefaultServiceName
+	}
+	if opt.Exporter == nil {
+		opt.Exporter = NewStdoutExporter()
+	}
+	if opt.BatchSize <= 0 {
+		opt.BatchSize = DefaultBatchSize
+	}
+	if opt.BatchTimeout <= 0 {
+		opt.BatchTimeout = DefaultBatchTimeout
+	}
+	if opt.ErrorHandler == nil {
+		opt.ErrorHandler = func(err error) {
+			log.Printf("tracing: export spans: %v\n", err)
+		}
+	}
+
+	t := &Tracer{
+		opt:     opt,
+		flush:   make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go t.run()
+	return t
+}
+
+// Start starts a span child of the span, or of the remote span context, of the
+// context. It returns a copy of the context holding the span, which must be
+// ended.
+func (t *Tracer) Start(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	parent := SpanContextFromContext(ctx)
+	sc := SpanContext{SpanID: newSpanID(), Sampled: true}
+	if parent.IsValid() {
+		sc.TraceID = parent.TraceID
+		sc.Sampled = parent.Sampled
+		sc.TraceState = parent.TraceState
+	} else {
+		sc.TraceID = newTraceID()
+	}
+
+	span := &Span{
+		name:   name,
+		kind:   kind,
+		sc:     sc,
+		parent: parent.SpanID,
+		start:  time.Now(),
+	}
+	if sc.Sampled {
+		span.tracer = t
+	}
+	return ContextWithSpan(ctx, span), span
+}
+
+// Start starts a span with the tracer of the span of the context. When the
+// context has no span, the span is not recorded and carries the remote span
+// context, if any, so the trace received is still propagated.
+func Start(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	if parent := SpanFromContext(ctx); parent != nil && parent.tracer != nil {
+		return parent.tracer.Start(ctx, name, kind)
+	}
+	span := &Span{name: name, kind: kind, sc: SpanContextFromContext(ctx)}
+	return ContextWithSpan(ctx, span), span
+}
+
+// ForceFlush exports the spans ended so far.
+func (t *Tracer) ForceFlush(ctx context.Context) error {
+	t.mu.Lock()
+	spans := t.queue
+	t.queue = nil
+	t.mu.Unlock()
+	return t.export(ctx, spans)
+}
+
+// Shutdown stops the tracer, exports the remaining spans then shuts the
+// exporter down. The spans ending afterwards are dropped.
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return nil
+	}
+	t.closed = true
+	t.mu.Unlock()
+
+	close(t.stop)
+	<-t.stopped
+	if err := t.ForceFlush(ctx); err != nil {
+		return err
+	}
+	return t.opt.Exporter.Shutdown(ctx)
+}
+
+// enqueue queues an ended span, requesting an export once a batch is full.
+func (t *Tracer) enqueue(data SpanData) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed || len(t.queue) >= 4*t.opt.BatchSize {
+		return
+	}
+	t.queue = append(t.queue, data)
+	if len(t.queue) >= t.opt.BatchSize {
+		select {
+		case t.flush <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// run exports the queued spans when a batch is full or the batch timeout
+// elapsed, until the tracer is shut down.
+func (t *Tracer) run() {
+	defer close(t.stopped)
+	ticker := time.NewTicker(t.opt.BatchTimeout)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-t.flush:
+		case <-t.stop:
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), t.opt.BatchTimeout)
+		if err := t.ForceFlush(ctx); err != nil {
+			t.opt.ErrorHandler(err)
+		}
+		cancel()
+	}
+}
+
+// export sends the spans to the exporter by batches, one export at a time.
+func (t *Tracer) export(ctx context.Context, spans []SpanData) error {
+	t.exportMu.Lock()
+	defer t.exportMu.Unlock()
+	for len(spans) > 0 {
+		n := min(len(spans), t.opt.BatchSize)
+		if err := t.opt.Exporter.ExportSpans(ctx, spans[:n]); err != nil {
+			return err
+		}
+		spans = spans[n:]
+	}
+	return nil
+}
+
+// Span is an operation of a trace. Its methods are safe for concurrent use.
+// A span of a trace not sampled records nothing.
+type Span struct {
+	tracer *Tracer
+	name   string
+	kind   SpanKind
+	sc     SpanContext
+	parent SpanID
+	start  time.Time
+
+	mu         sync.Mutex
+	attributes map[string]any
+	err        string
+	ended      bool
+}
+
+// SpanContext returns the span context propagated to the other services.
+func (s *Span) SpanContext() SpanContext {
+	return s.sc
+}
+
+// IsRecording reports whether the span is exported once ended.
+func (s *Span) IsRecording() bool {
+	return s.tracer != nil
+}
+
+// SetAttribute sets an attribute of the span. The values are usually strings,
+// booleans or numbers. Nothing is set once the span ended.
+func (s *Span) SetAttribute(key string, value any) {
+	if s.tracer == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended {
+		return
+	}
+	if s.attributes == nil {
+		s.attributes = make(map[string]any)
+	}
+	s.attributes[key] = value
+}
+
+// RecordError marks the span as failed with the error. A nil error is
+// ignored.
+func (s *Span) RecordError(err error) {
+	if err == nil || s.tracer == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.ended {
+		s.err = err.Error()
+	}
+}
+
+// End ends the span and queues it for the export. Only the first call has an
+// effect.
+func (s *Span) End() {
+	if s.tracer == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	data := SpanData{
+		Service:      s.tracer.opt.ServiceName,
+		Name:         s.name,
+		Kind:         s.kind,
+		SpanContext:  s.sc,
+		ParentSpanID: s.parent,
+		Start:        s.start,
+		End:          time.Now(),
+		Attributes:   s.attributes,
+		Error:        s.err,
+	}
+	s.mu.Unlock()
+	s.tracer.enqueue(data)
+}
+
+func newTraceID() TraceID {
+	var id TraceID
+	for !id.IsValid() {
+		binary.BigEndian.PutUint64(id[:8], rand.Uint64())
+		binary.BigEndian.PutUint64(id[8:], rand.Uint64())
+	}
+	return id
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+	for !id.IsValid() {
+		binary.BigEndian.PutUint64(id[:], rand.Uint64())
+	}
+	return id
+}
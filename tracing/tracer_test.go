@@ -0,0 +1,169 @@
+package tracing_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tinh-tinh/tinhtinh/v2/tracing"
+)
+
+// memoryExporter keeps the exported spans.
+type memoryExporter struct {
+	mu       sync.Mutex
+	spans    []tracing.SpanData
+	shutdown bool
+}
+
+func (e *memoryExporter) ExportSpans(ctx context.Context, spans []tracing.SpanData) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, spans...)
+	return nil
+}
+
+func (e *memoryExporter) Shutdown(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.shutdown = true
+	return nil
+}
+
+func (e *memoryExporter) get(name string) tracing.SpanData {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, span := range e.spans {
+		if span.Name == name {
+			return span
+		}
+	}
+	return tracing.SpanData{}
+}
+
+func (e *memoryExporter) len() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return len(e.spans)
+}
+
+func Test_Tracer(t *testing.T) {
+	exporter := &memoryExporter{}
+	tracer := tracing.NewTracer(tracing.Options{ServiceName: "orders", Exporter: exporter})
+
+	ctx, root := tracer.Start(context.Background(), "root", tracing.KindServer)
+	root.SetAttribute("http.route", "/orders")
+	_, child := tracing.Start(ctx, "child", tracing.KindInternal)
+	child.RecordError(errors.New("failed"))
+	child.End()
+	root.End()
+	root.End()
+	root.SetAttribute("ignored", true)
+
+	require.Nil(t, tracer.ForceFlush(context.Background()))
+	require.Equal(t, 2, exporter.len())
+
+	rootData := exporter.get("root")
+	require.Equal(t, "orders", rootData.Service)
+	require.Equal(t, tracing.KindServer, rootData.Kind)
+	require.False(t, rootData.ParentSpanID.IsValid())
+	require.Equal(t, map[string]any{"http.route": "/orders"}, rootData.Attributes)
+
+	childData := exporter.get("child")
+	require.Equal(t, rootData.SpanContext.TraceID, childData.SpanContext.TraceID)
+	require.Equal(t, rootData.SpanContext.SpanID, childData.ParentSpanID)
+	require.Equal(t, "failed", childData.Error)
+
+	// A trace not sampled by the caller is propagated but not recorded.
+	remote, err := tracing.ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+	require.Nil(t, err)
+	ctx, span := tracer.Start(tracing.ContextWithRemoteSpanContext(context.Background(), remote), "unsampled", tracing.KindServer)
+	require.False(t, span.IsRecording())
+	require.Equal(t, remote.TraceID, tracing.SpanContextFromContext(ctx).TraceID)
+	span.End()
+
+	require.Nil(t, tracer.Shutdown(context.Background()))
+	require.Equal(t, 2, exporter.len())
+	require.True(t, exporter.shutdown)
+
+	_, span = tracer.Start(context.Background(), "after", tracing.KindInternal)
+	span.End()
+	require.Nil(t, tracer.ForceFlush(context.Background()))
+	require.Equal(t, 2, exporter.len())
+}
+
+func Test_Tracer_Batch(t *testing.T) {
+	exporter := &memoryExporter{}
+	tracer := tracing.NewTracer(tracing.Options{
+		Exporter:     exporter,
+		BatchSize:    2,
+		BatchTimeout: time.Hour,
+	})
+	defer tracer.Shutdown(context.Background())
+
+	for i := 0; i < 2; i++ {
+		_, span := tracer.Start(context.Background(), "span", tracing.KindInternal)
+		span.End()
+	}
+	require.Eventually(t, func() bool { return exporter.len() == 2 }, time.Second, 10*time.Millisecond)
+}
+
+func Test_Start_WithoutTracer(t *testing.T) {
+	ctx, span := tracing.Start(context.Background(), "orphan", tracing.KindClient)
+	require.False(t, span.IsRecording())
+	require.False(t, tracing.SpanContextFromContext(ctx).IsValid())
+	span.End()
+}
+
+func Test_JSONExporter(t *testing.T) {
+	var buf bytes.Buffer
+	tracer := tracing.NewTracer(tracing.Options{Exporter: tracing.NewJSONExporter(&buf)})
+	ctx, root := tracer.Start(context.Background(), "root", tracing.KindServer)
+	_, child := tracer.Start(ctx, "child", tracing.KindClient)
+	child.SetAttribute("retries", 2)
+	child.End()
+	root.End()
+	require.Nil(t, tracer.Shutdown(context.Background()))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+
+	var span map[string]any
+	require.Nil(t, json.Unmarshal([]byte(lines[0]), &span))
+	require.Equal(t, "child", span["name"])
+	require.Equal(t, "client", span["kind"])
+	require.Equal(t, "tinhtinh", span["service"])
+	require.Equal(t, root.SpanContext().TraceID.String(), span["traceId"])
+	require.Equal(t, root.SpanContext().SpanID.String(), span["parentSpanId"])
+	require.Equal(t, map[string]any{"retries": float64(2)}, span["attributes"])
+
+	var parent map[string]any
+	require.Nil(t, json.Unmarshal([]byte(lines[1]), &parent))
+	require.Equal(t, "root", parent["name"])
+	require.NotContains(t, parent, "parentSpanId")
+}
+
+func Test_FileExporter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spans.json")
+	exporter, err := tracing.NewFileExporter(path)
+	require.Nil(t, err)
+
+	tracer := tracing.NewTracer(tracing.Options{Exporter: exporter})
+	_, span := tracer.Start(context.Background(), "job", tracing.KindInternal)
+	span.End()
+	require.Nil(t, tracer.Shutdown(context.Background()))
+
+	data, err := os.ReadFile(path)
+	require.Nil(t, err)
+	require.Contains(t, string(data), `"name":"job"`)
+
+	_, err = tracing.NewFileExporter(filepath.Join(t.TempDir(), "missing", "spans.json"))
+	require.NotNil(t, err)
+}